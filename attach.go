@@ -0,0 +1,134 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+)
+
+// attachedSchema is the schema name the source database is ATTACHed as on the target connection
+const attachedSchema = "sync_source"
+
+// canAttach returns whether the source can be ATTACHed to the target connection
+func canAttach(source, target TableConfig) bool {
+	return source.Driver == "sqlite3" && target.Driver == "sqlite3" && source.DSN != ""
+}
+
+// syncAttached ATTACHes the source database to the target connection and performs the sync with
+// set-based statements (DELETEs -> UPDATEs -> INSERTs). It returns the number of rows inserted,
+// updated, and deleted
+func (t table) syncAttached(source TableConfig) (int, int, int, error) {
+	// ATTACH only applies to a single connection, so we can't use the pool directly. This reads
+	// the target through the connection that is written to, since the statements do both
+	conn, err := t.writer().Connx(t.context())
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer conn.Close()
+	t.samplePool() // The connection is in use until it's closed
+
+	attach := fmt.Sprintf(
+		"ATTACH DATABASE %s AS %s",
+		quoteString(source.DSN),
+		quoteIdentifier("sqlite3", attachedSchema),
+	)
+	if _, err := conn.ExecContext(t.context(), attach); err != nil {
+		return 0, 0, 0, err
+	}
+
+	detach := "DETACH DATABASE " + quoteIdentifier("sqlite3", attachedSchema)
+	defer conn.ExecContext(t.context(), detach)
+
+	// The statements are executed in a single transaction, so a failed sync doesn't leave the
+	// target half-synced. It's begun after the ATTACH, which can't be executed in a transaction
+	tx, err := conn.BeginTxx(t.context(), nil)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer tx.Rollback() // This is a no-op once the transaction is committed
+
+	var counts [3]int
+	for i, statement := range t.attachedStatements(source.Table) {
+		if statement == "" {
+			continue
+		}
+
+		res, err := tx.Exec(statement)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		counts[i] = int(affected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, 0, err
+	}
+
+	deletes, updates, inserts := counts[0], counts[1], counts[2]
+	return inserts, updates, deletes, nil
+}
+
+// attachedStatements builds the DELETE, UPDATE, and INSERT statements (in that order) that sync
+// the target from the ATTACHed source table. The UPDATE is empty if every column is a primary key
+func (t table) attachedStatements(sourceTable string) [3]string {
+	quote := func(name string) string { return quoteIdentifier("sqlite3", name) }
+
+	target := quote(t.config.Table)
+	source := quote(attachedSchema) + "." + quote(sourceTable)
+
+	pkSet := map[string]struct{}{}
+	var pkMatch []string
+	for _, pk := range t.primaryKeys {
+		pkSet[pk] = struct{}{}
+		pkMatch = append(pkMatch, fmt.Sprintf("s.%s = %s.%s", quote(pk), target, quote(pk)))
+	}
+	matchClause := strings.Join(pkMatch, " AND ")
+
+	var quotedColumns, selectColumns, sets, differs []string
+	for _, col := range t.columns {
+		quotedColumns = append(quotedColumns, quote(col))
+		selectColumns = append(selectColumns, "s."+quote(col))
+
+		if _, ok := pkSet[col]; ok {
+			continue // Skip updating primary key columns
+		}
+
+		sets = append(sets, fmt.Sprintf("%s = s.%s", quote(col), quote(col)))
+		differs = append(differs, fmt.Sprintf("s.%s IS NOT %s.%s", quote(col), target, quote(col)))
+	}
+
+	var statements [3]string
+
+	statements[0] = fmt.Sprintf(
+		"DELETE FROM %s WHERE NOT EXISTS (SELECT 1 FROM %s AS s WHERE %s)",
+		target, source, matchClause,
+	)
+
+	if len(sets) > 0 {
+		statements[1] = fmt.Sprintf(
+			"UPDATE %s SET %s FROM %s AS s WHERE %s AND (%s)",
+			target,
+			strings.Join(sets, ", "),
+			source,
+			matchClause,
+			strings.Join(differs, " OR "),
+		)
+	}
+
+	statements[2] = fmt.Sprintf(
+		"INSERT INTO %s (%s) SELECT %s FROM %s AS s WHERE NOT EXISTS (SELECT 1 FROM %s WHERE %s)",
+		target,
+		strings.Join(quotedColumns, ", "),
+		strings.Join(selectColumns, ", "),
+		source,
+		target,
+		matchClause,
+	)
+
+	return statements
+}
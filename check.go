@@ -0,0 +1,59 @@
+package sync
+
+import "fmt"
+
+// CheckJobResult contains the results of checking a single job for drift
+type CheckJobResult struct {
+	Checksum string
+	Results  []SyncResult
+
+	// Exceeded is true if any target has drifted by more than the job's MaxDriftRows
+	Exceeded bool
+}
+
+// DriftRows is the number of rows that differ between the source and the target
+func (r SyncResult) DriftRows() int {
+	return r.NumInserts + r.NumUpdates + r.NumDeletes
+}
+
+// CheckJob compares a single job's source to each of its targets without writing anything, and
+// reports whether any target has drifted by more than the job's MaxDriftRows. Like a sync, the
+// targets are compared concurrently, with at most the job's MaxConcurrency at once
+func (c Config) CheckJob(jobName string) (CheckJobResult, error) {
+	// Find the job with the given name
+	job, ok := c.Jobs[jobName]
+	if !ok {
+		return CheckJobResult{}, fmt.Errorf("job '%s' not found in config", jobName)
+	}
+
+	job.DryRun = true // Checking never writes
+
+	synced, err := job.syncTargets(nil)
+	if err != nil {
+		return CheckJobResult{}, err
+	}
+
+	result := CheckJobResult{Checksum: synced.Checksum, Results: synced.Results}
+	for _, r := range synced.Results {
+		if r.DriftRows() > job.MaxDriftRows {
+			result.Exceeded = true
+		}
+	}
+
+	return result, nil
+}
+
+// CheckAllJobs checks all jobs in the sync config for drift, in the order that ExecAllJobs executes
+// them (see JobNames)
+func (c Config) CheckAllJobs() (map[string]CheckJobResult, map[string]error) {
+	results := make(map[string]CheckJobResult, len(c.Jobs))
+	errors := make(map[string]error, len(c.Jobs))
+
+	for _, jobName := range c.JobNames() {
+		result, err := c.CheckJob(jobName)
+		results[jobName] = result
+		errors[jobName] = err
+	}
+
+	return results, errors
+}
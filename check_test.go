@@ -0,0 +1,210 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckJob(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:check_job_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob'), (3, 'Charlie')")
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:check_job_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// The target has 2 differing rows: id=1 needs an update and id=3 needs an insert
+	target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Nick'), (2, 'Bob')")
+
+	newConfig := func(maxDriftRows int) Config {
+		return Config{
+			Jobs: map[string]JobConfig{
+				"users": {
+					PrimaryKeys:  []string{"id"},
+					Columns:      []string{"id", "name"},
+					Source:       sourceConfig,
+					Targets:      []TableConfig{targetConfig},
+					MaxDriftRows: maxDriftRows,
+				},
+			},
+		}
+	}
+
+	t.Run("below threshold", func(t *testing.T) {
+		result, err := newConfig(2).CheckJob("users")
+		require.NoError(t, err)
+		require.Len(t, result.Results, 1)
+		require.NoError(t, result.Results[0].Error)
+		assert.Equal(t, 2, result.Results[0].DriftRows())
+		assert.False(t, result.Exceeded)
+	})
+
+	t.Run("above threshold", func(t *testing.T) {
+		result, err := newConfig(1).CheckJob("users")
+		require.NoError(t, err)
+		require.Len(t, result.Results, 1)
+		require.NoError(t, result.Results[0].Error)
+		assert.Equal(t, 2, result.Results[0].DriftRows())
+		assert.True(t, result.Exceeded)
+	})
+
+	// Checking should never write to the target
+	var count int
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 2, count)
+}
+
+func TestCheckJob_checkpoint(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:check_job_checkpoint_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave", "Eve"} {
+		source.MustExec("INSERT INTO users (name) VALUES (?)", name)
+	}
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:check_job_checkpoint_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+	target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Nick')")
+
+	// An earlier sync was interrupted after the row with id=3
+	key, err := encodeKey(primaryKeyTuple{First: int64(3)})
+	require.NoError(t, err)
+
+	checkpointBytes, err := json.Marshal(map[string]string{targetConfig.id(): key})
+	require.NoError(t, err)
+
+	checkpointFile := filepath.Join(t.TempDir(), "checkpoints.json")
+	require.NoError(t, os.WriteFile(checkpointFile, checkpointBytes, 0o644))
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys:    []string{"id"},
+				Columns:        []string{"id", "name"},
+				Source:         sourceConfig,
+				Targets:        []TableConfig{targetConfig},
+				CheckpointFile: checkpointFile,
+			},
+		},
+	}
+
+	// Every drifted row is counted, including the ones before the checkpoint
+	result, err := config.CheckJob("users")
+	require.NoError(t, err)
+	require.Len(t, result.Results, 1)
+	require.NoError(t, result.Results[0].Error)
+	assert.Equal(t, 4, result.Results[0].NumInserts)
+	assert.Equal(t, 1, result.Results[0].NumUpdates)
+
+	// The checkpoint is left for the next sync to resume from
+	fileBytes, err := os.ReadFile(checkpointFile)
+	require.NoError(t, err)
+	assert.Equal(t, checkpointBytes, fileBytes)
+}
+
+func TestCheckJob_multiple_targets(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT)"
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:check_job_multiple_targets_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob'), (3, 'Charlie')")
+
+	// Each target is missing a different number of the source's rows
+	var targetConfigs []TableConfig
+	for i := 0; i < 4; i++ {
+		targetConfig := TableConfig{
+			Label:  fmt.Sprintf("target%d", i),
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    fmt.Sprintf("file:check_job_multiple_targets_%d.db?mode=memory&cache=shared", i),
+		}
+
+		target := table{config: targetConfig}
+		target.connect()
+		target.MustExec(createTable)
+		target.MustExec("INSERT INTO users (id, name) SELECT 1, 'Alice' WHERE ? < 3", i)
+		target.MustExec("INSERT INTO users (id, name) SELECT 2, 'Bob' WHERE ? < 2", i)
+		target.MustExec("INSERT INTO users (id, name) SELECT 3, 'Charlie' WHERE ? < 1", i)
+
+		targetConfigs = append(targetConfigs, targetConfig)
+	}
+
+	for _, maxConcurrency := range []int{0, 1, 2} {
+		config := Config{
+			Jobs: map[string]JobConfig{
+				"users": {
+					PrimaryKeys:    []string{"id"},
+					Columns:        []string{"id", "name"},
+					Source:         sourceConfig,
+					Targets:        targetConfigs,
+					MaxConcurrency: maxConcurrency,
+				},
+			},
+		}
+
+		// The results are in the same order as the targets, regardless of which finished first
+		result, err := config.CheckJob("users")
+		require.NoError(t, err)
+		require.Len(t, result.Results, len(targetConfigs))
+
+		for i, r := range result.Results {
+			require.NoError(t, r.Error)
+			assert.Equal(t, targetConfigs[i].Label, r.Target.Label)
+			assert.Equal(t, i, r.DriftRows())
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// checkpointInterval is how many statements are executed between persisting checkpoints
+const checkpointInterval = 100
+
+// checkpointStore persists the primary key of the last source row synced to each target, so that
+// an interrupted sync can resume where it left off instead of starting over
+type checkpointStore struct {
+	mu       sync.Mutex
+	filename string
+	keys     map[string]string // Maps table ids to JSON-encoded primary keys
+}
+
+// loadCheckpoints reads the checkpoint file. A missing file is treated as having no checkpoints
+func loadCheckpoints(filename string) (*checkpointStore, error) {
+	store := &checkpointStore{filename: filename, keys: map[string]string{}}
+
+	fileBytes, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(fileBytes, &store.keys); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// position returns how many of the (ordered) source rows were already synced to the target. If
+// there is no checkpoint, or the checkpointed row no longer exists in the source, it returns 0
+func (s *checkpointStore) position(
+	tableID string,
+	entries [][]any,
+	keyOf func([]any) primaryKeyTuple,
+) int {
+	s.mu.Lock()
+	checkpoint, ok := s.keys[tableID]
+	s.mu.Unlock()
+
+	if !ok {
+		return 0
+	}
+
+	for i, row := range entries {
+		if encoded, err := encodeKey(keyOf(row)); err == nil && encoded == checkpoint {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// save records key as the last source row synced to the table and persists the checkpoints
+func (s *checkpointStore) save(tableID string, key primaryKeyTuple) error {
+	encoded, err := encodeKey(key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[tableID] = encoded
+	return s.write()
+}
+
+// clear removes the table's checkpoint (once it is fully synced) and persists the checkpoints
+func (s *checkpointStore) clear(tableID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[tableID]; !ok {
+		return nil
+	}
+
+	delete(s.keys, tableID)
+	return s.write()
+}
+
+func (s *checkpointStore) write() error {
+	fileBytes, err := json.MarshalIndent(s.keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename it, so an interruption can't leave a partially written file
+	tmpFilename := s.filename + ".tmp"
+	if err := os.WriteFile(tmpFilename, fileBytes, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFilename, s.filename)
+}
+
+func encodeKey(key primaryKeyTuple) (string, error) {
+	encoded, err := json.Marshal(key)
+	return string(encoded), err
+}
+
+// applyDiffWithCheckpoints is like applyDiff, but UPDATEs and INSERTs are executed in source row
+// order so that progress can be checkpointed as the primary key of the last source row synced
+func (t table) applyDiffWithCheckpoints(
+	diff tableDiff,
+	sourceEntries [][]any,
+	checkpoints *checkpointStore,
+) error {
+	tableID := t.config.id()
+
+	for d, delete := range diff.deletes {
+		if _, err := t.exec(delete); err != nil {
+			return fmt.Errorf("failed to delete row %s: %w", diff.deleteKeys[d], err)
+		}
+	}
+
+	lastDone := -1 // Position of the last source row that was synced
+	var sinceCheckpoint int
+
+	saveCheckpoint := func() error {
+		if lastDone < 0 {
+			return nil
+		}
+		return checkpoints.save(tableID, t.keyOf(sourceEntries[lastDone]))
+	}
+
+	var u, i int
+	for u < len(diff.updates) || i < len(diff.inserts) {
+		var position int
+		var err error
+
+		// Execute whichever statement comes first in source row order
+		if i >= len(diff.inserts) ||
+			(u < len(diff.updates) && diff.updatePositions[u] < diff.insertPositions[i]) {
+			position = diff.updatePositions[u]
+			if _, err = t.exec(diff.updates[u]); err != nil {
+				err = fmt.Errorf("failed to update row %s: %w", diff.updateKeys[u], err)
+			}
+			u++
+		} else {
+			position = diff.insertPositions[i]
+			if _, err = t.exec(diff.inserts[i]); err != nil {
+				err = t.insertError(diff.insertRows[i:i+1], err)
+			}
+			i++
+		}
+
+		if err != nil {
+			// Persist our progress so the next run can resume from here
+			if checkpointErr := saveCheckpoint(); checkpointErr != nil {
+				return errors.Join(err, checkpointErr)
+			}
+			return err
+		}
+
+		lastDone = position
+		sinceCheckpoint++
+
+		if sinceCheckpoint >= checkpointInterval {
+			if err := saveCheckpoint(); err != nil {
+				return err
+			}
+			sinceCheckpoint = 0
+		}
+	}
+
+	// The target is fully synced, so there is nothing to resume
+	return checkpoints.clear(tableID)
+}
@@ -0,0 +1,80 @@
+package sync
+
+import "fmt"
+
+// TargetChecksum contains the checksum of a single target table
+type TargetChecksum struct {
+	Target   TableConfig
+	Checksum string
+	Error    error
+}
+
+// SourceChecksum reads a single job's source table and returns its checksum, without syncing
+// anything. A checksum only depends on the rows' values (of the job's checksummed columns), so it
+// can be compared with checksums computed elsewhere, e.g. for the same job in another environment
+func (c Config) SourceChecksum(jobName string) (string, error) {
+	job, ok := c.Jobs[jobName]
+	if !ok {
+		return "", fmt.Errorf("job '%s' not found in config", jobName)
+	}
+
+	if err := job.checkPrimaryKeyIndices(); err != nil {
+		return "", err
+	}
+
+	data, err := job.newSourceTable().readSource()
+	if err != nil {
+		return "", err
+	}
+
+	return data.checksum, nil
+}
+
+// TargetChecksums reads each of a single job's targets and returns their checksums (in the same
+// order as the job's targets), without syncing anything. Like a sync, the targets are read
+// concurrently, with at most the job's MaxConcurrency at once. A target's checksum matches its
+// source's when they are in sync, unless the target has its own primary keys
+func (c Config) TargetChecksums(jobName string) ([]TargetChecksum, error) {
+	job, ok := c.Jobs[jobName]
+	if !ok {
+		return nil, fmt.Errorf("job '%s' not found in config", jobName)
+	}
+
+	if err := job.checkPrimaryKeyIndices(); err != nil {
+		return nil, err
+	}
+
+	results := make([]TargetChecksum, len(job.Targets))
+
+	forEachConcurrently(len(job.Targets), job.MaxConcurrency, func(i int) {
+		target := job.newTable(job.Targets[i])
+		checksum, err := target.readChecksum()
+		results[i] = TargetChecksum{Target: target.config, Checksum: checksum, Error: err}
+	})
+
+	return results, nil
+}
+
+// readChecksum connects to the table and computes the checksum of all of its rows
+func (t table) readChecksum() (string, error) {
+	// A noop target doesn't have any rows of its own
+	if t.isNoop() {
+		return "", fmt.Errorf("target uses the noop driver, so it has no checksum")
+	}
+
+	if err := t.connect(); err != nil {
+		return "", err
+	}
+	defer t.disconnect()
+
+	data, err := t.readTarget()
+	if err != nil {
+		return "", err
+	}
+
+	if data.spilled != nil {
+		defer data.spilled.close()
+	}
+
+	return t.checksumData(data)
+}
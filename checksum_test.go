@@ -0,0 +1,106 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksums(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:checksums_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	inSyncConfig := TableConfig{
+		Label:  "in sync",
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:checksums_in_sync.db?mode=memory&cache=shared",
+	}
+
+	inSync := table{config: inSyncConfig}
+	inSync.connect()
+	inSync.MustExec(createTable)
+	inSync.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	driftedConfig := TableConfig{
+		Label:  "drifted",
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:checksums_drifted.db?mode=memory&cache=shared",
+	}
+
+	drifted := table{config: driftedConfig}
+	drifted.connect()
+	drifted.MustExec(createTable)
+	drifted.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alicia')")
+
+	noopConfig := TableConfig{Label: "noop", Driver: noopDriver, Table: "users"}
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{inSyncConfig, driftedConfig, noopConfig},
+				DryRun:      true,
+			},
+		},
+	}
+
+	sourceChecksum, err := config.SourceChecksum("users")
+	require.NoError(t, err)
+	assert.NotEmpty(t, sourceChecksum)
+
+	targetChecksums, err := config.TargetChecksums("users")
+	require.NoError(t, err)
+	require.Len(t, targetChecksums, 3)
+
+	require.NoError(t, targetChecksums[0].Error)
+	assert.Equal(t, "in sync", targetChecksums[0].Target.Label)
+	assert.Equal(t, sourceChecksum, targetChecksums[0].Checksum)
+
+	require.NoError(t, targetChecksums[1].Error)
+	assert.NotEqual(t, sourceChecksum, targetChecksums[1].Checksum)
+
+	assert.EqualError(
+		t, targetChecksums[2].Error, "target uses the noop driver, so it has no checksum",
+	)
+
+	// The checksums are the same ones that a sync computes
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	assert.Equal(t, sourceChecksum, results.Checksum)
+	assert.Equal(t, targetChecksums[1].Checksum, results.Results[1].TargetChecksum)
+
+	// The checksums are stable
+	again, err := config.SourceChecksum("users")
+	require.NoError(t, err)
+	assert.Equal(t, sourceChecksum, again)
+
+	targetsAgain, err := config.TargetChecksums("users")
+	require.NoError(t, err)
+	assert.Equal(t, targetChecksums, targetsAgain)
+
+	_, err = config.SourceChecksum("pets")
+	assert.EqualError(t, err, "job 'pets' not found in config")
+
+	_, err = config.TargetChecksums("pets")
+	assert.EqualError(t, err, "job 'pets' not found in config")
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	sync "github.com/NickDubelman/sql-table-sync"
+)
+
+var checkPromFile string
+var checkFormat string
+var checkMaxTableRows int
+
+// Check output formats (see --format)
+const (
+	checkFormatText  = "text"
+	checkFormatTable = "table"
+)
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+
+	checkCmd.Flags().StringVar(
+		&checkPromFile,
+		"prom-file",
+		"",
+		"write drift metrics to this Prometheus textfile (e.g. for node_exporter)",
+	)
+	checkCmd.Flags().StringVar(
+		&checkFormat,
+		"format",
+		checkFormatText,
+		"how drifted targets are printed: text (how many rows differ) or table (their values)",
+	)
+	checkCmd.Flags().IntVar(
+		&checkMaxTableRows,
+		"max-table-rows",
+		20,
+		"with --format table, the most rows that can differ before only counts are printed",
+	)
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check [job]...",
+	Short: "Check the given sync jobs for drift",
+	Long:  "Check the given sync jobs for drift without writing anything. Exits non-zero if any target has drifted by more than its job's maxDriftRows (or errored). If no positional args are provided, checks all jobs.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if checkFormat != checkFormatText && checkFormat != checkFormatTable {
+			fmt.Printf("invalid --format '%s': must be text or table\n", checkFormat)
+			os.Exit(1)
+		}
+
+		// The table is rendered from the values of each row that differs
+		if checkFormat == checkFormatTable {
+			for jobName, job := range config.Jobs {
+				job.Verbose = true
+				config.Jobs[jobName] = job
+			}
+		}
+
+		var failed bool
+
+		checkedAt := time.Now()
+
+		var jobNames []string
+		var results map[string]sync.CheckJobResult
+		var errs map[string]error
+
+		if len(args) == 0 {
+			results, errs = config.CheckAllJobs()
+
+			jobNames = config.JobNames() // In the order the jobs are checked
+		} else {
+			jobNames = args
+			results = make(map[string]sync.CheckJobResult, len(args))
+			errs = make(map[string]error, len(args))
+
+			for _, jobName := range args {
+				results[jobName], errs[jobName] = config.CheckJob(jobName)
+			}
+		}
+
+		for i, jobName := range jobNames {
+			if i != 0 {
+				fmt.Println() // Add a newline between job results
+			}
+
+			if !printCheckOutput(jobName, results[jobName], errs[jobName]) {
+				failed = true
+			}
+		}
+
+		if checkPromFile != "" {
+			err := sync.WriteDriftMetrics(checkPromFile, results, errs, checkedAt)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "failed to write drift metrics:", err)
+				failed = true
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// printCheckOutput prints the result of checking a job and returns whether the check passed
+func printCheckOutput(jobName string, result sync.CheckJobResult, err error) bool {
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+
+	fmt.Println(jobName + ":")
+	fmt.Println("  - source checksum:", result.Checksum)
+
+	var numInSync, numDrifted int
+	var targetErrs []string
+
+	for _, r := range result.Results {
+		if r.Error != nil {
+			errStr := fmt.Sprintf("%s: %s", r.Target.Label, r.Error)
+			targetErrs = append(targetErrs, errStr)
+		} else if r.DriftRows() == 0 {
+			numInSync++
+		} else {
+			numDrifted++
+		}
+	}
+
+	resultStr := fmt.Sprintf("%d in sync, %d drifted", numInSync, numDrifted)
+	if len(targetErrs) > 0 {
+		resultStr += fmt.Sprintf(", %d errored", len(targetErrs))
+	}
+
+	fmt.Println("  - targets:", resultStr)
+
+	for _, r := range result.Results {
+		if r.Error == nil && r.DriftRows() > 0 {
+			fmt.Printf("    - %s: %d rows differ\n", r.Target.Label, r.DriftRows())
+
+			if checkFormat == checkFormatTable {
+				fmt.Print(formatDiffTable(r, checkMaxTableRows))
+			}
+		}
+	}
+
+	for _, err := range targetErrs {
+		fmt.Println("    -", err)
+	}
+
+	if result.Exceeded {
+		fmt.Println("  - drift exceeds maxDriftRows")
+	}
+
+	return checkPassed(result, nil)
+}
+
+// formatDiffTable formats a drifted target's differences for --format table: its numbers of
+// inserts, updates, and deletes, and then the values of each row that differs, with the source's
+// value of each column next to the target's. Inserted rows only have the source's values, deleted
+// rows only have the target's, and updated rows only have their changed columns. If more than
+// maxRows rows differ, only the numbers are formatted
+func formatDiffTable(r sync.SyncResult, maxRows int) string {
+	var b strings.Builder
+
+	fmt.Fprintf(
+		&b,
+		"      %d inserts, %d updates, %d deletes\n",
+		r.NumInserts, r.NumUpdates, r.NumDeletes,
+	)
+
+	if r.DriftRows() > maxRows {
+		fmt.Fprintf(&b, "      (more than %d rows differ, so they aren't shown)\n", maxRows)
+		return b.String()
+	}
+
+	if len(r.Inserts)+len(r.Updates)+len(r.Deletes) == 0 {
+		return b.String()
+	}
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "      KEY\tCHANGE\tCOLUMN\tSOURCE\t|\tTARGET")
+
+	printCell := func(key, change, column, source, target string) {
+		fmt.Fprintf(w, "      %s\t%s\t%s\t%s\t|\t%s\n", key, change, column, source, target)
+	}
+
+	for _, insert := range r.Inserts {
+		key := formatRowKey(insert.KeyColumns, insert.Key)
+		for i, column := range insert.Columns {
+			printCell(key, "insert", column, formatCell(insert.Values[i]), missingCell)
+		}
+	}
+
+	for _, update := range r.Updates {
+		key := formatRowKey(update.KeyColumns, update.Key)
+		for i, column := range update.Columns {
+			source, target := formatCell(update.Source[i]), formatCell(update.Target[i])
+			printCell(key, "update", column, source, target)
+		}
+	}
+
+	for _, delete := range r.Deletes {
+		key := formatRowKey(delete.KeyColumns, delete.Key)
+		for i, column := range delete.Columns {
+			printCell(key, "delete", column, missingCell, formatCell(delete.Values[i]))
+		}
+	}
+
+	w.Flush()
+
+	return b.String()
+}
+
+// missingCell is a table's cell for a row that is missing from the source or the target
+const missingCell = "-"
+
+// formatCell formats a value for a table, so that NULL can be told apart from text
+func formatCell(val any) string {
+	if val == nil {
+		return "NULL"
+	}
+
+	if s, ok := val.(string); ok {
+		return strconv.Quote(s)
+	}
+
+	return fmt.Sprint(val)
+}
+
+// checkPassed returns whether checking a job passed: no target drifted by more than the job's
+// maxDriftRows, and nothing errored
+func checkPassed(result sync.CheckJobResult, err error) bool {
+	if err != nil || result.Exceeded {
+		return false
+	}
+
+	for _, r := range result.Results {
+		if r.Error != nil {
+			return false
+		}
+	}
+
+	return true
+}
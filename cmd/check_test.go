@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	sync "github.com/NickDubelman/sql-table-sync"
+)
+
+func TestFormatDiffTable(t *testing.T) {
+	result := sync.SyncResult{
+		Target:     sync.TableConfig{Label: "replica", Table: "users"},
+		NumInserts: 1,
+		NumUpdates: 2,
+		NumDeletes: 1,
+		Inserts: []sync.RowValues{
+			{
+				KeyColumns: []string{"id"},
+				Key:        []any{int64(3)},
+				Columns:    []string{"id", "name", "age"},
+				Values:     []any{int64(3), "Carol", nil},
+			},
+		},
+		Updates: []sync.RowUpdate{
+			{
+				KeyColumns: []string{"id"},
+				Key:        []any{int64(2)},
+				Columns:    []string{"name", "age"},
+				Source:     []any{"Bob", int64(30)},
+				Target:     []any{"Robert", nil},
+			},
+			{
+				KeyColumns: []string{"id"},
+				Key:        []any{int64(7)},
+				Columns:    []string{"name"},
+				Source:     []any{"Eve"},
+				Target:     []any{""},
+			},
+		},
+		Deletes: []sync.RowValues{
+			{
+				KeyColumns: []string{"id"},
+				Key:        []any{int64(9)},
+				Columns:    []string{"id", "name", "age"},
+				Values:     []any{int64(9), "Zed", int64(50)},
+			},
+		},
+	}
+
+	expected := "" +
+		"      1 inserts, 2 updates, 1 deletes\n" +
+		"      KEY   CHANGE  COLUMN  SOURCE   |  TARGET\n" +
+		"      id=3  insert  id      3        |  -\n" +
+		"      id=3  insert  name    \"Carol\"  |  -\n" +
+		"      id=3  insert  age     NULL     |  -\n" +
+		"      id=2  update  name    \"Bob\"    |  \"Robert\"\n" +
+		"      id=2  update  age     30       |  NULL\n" +
+		"      id=7  update  name    \"Eve\"    |  \"\"\n" +
+		"      id=9  delete  id      -        |  9\n" +
+		"      id=9  delete  name    -        |  \"Zed\"\n" +
+		"      id=9  delete  age     -        |  50\n"
+	assert.Equal(t, expected, formatDiffTable(result, 20))
+
+	// With more differing rows than the threshold, only the counts are formatted
+	expected = "" +
+		"      1 inserts, 2 updates, 1 deletes\n" +
+		"      (more than 3 rows differ, so they aren't shown)\n"
+	assert.Equal(t, expected, formatDiffTable(result, 3))
+
+	// A composite key is labeled with each of its columns
+	result = sync.SyncResult{
+		NumUpdates: 1,
+		Updates: []sync.RowUpdate{
+			{
+				KeyColumns: []string{"org", "id"},
+				Key:        []any{"acme", int64(7)},
+				Columns:    []string{"email"},
+				Source:     []any{"carol@x.com"},
+				Target:     []any{"c@x.com"},
+			},
+		},
+	}
+
+	expected = "" +
+		"      0 inserts, 1 updates, 0 deletes\n" +
+		"      KEY             CHANGE  COLUMN  SOURCE         |  TARGET\n" +
+		"      org=acme, id=7  update  email   \"carol@x.com\"  |  \"c@x.com\"\n"
+	assert.Equal(t, expected, formatDiffTable(result, 20))
+
+	// Without the rows' values (e.g. from a replace-mode sync), only the counts are formatted
+	result = sync.SyncResult{NumDeletes: 1}
+	assert.Equal(t, "      0 inserts, 0 updates, 1 deletes\n", formatDiffTable(result, 20))
+}
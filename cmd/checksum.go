@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	sync "github.com/NickDubelman/sql-table-sync"
+)
+
+func init() {
+	rootCmd.AddCommand(checksumCmd)
+}
+
+var checksumCmd = &cobra.Command{
+	Use:   "checksum [job]...",
+	Short: "Print the checksums of the given sync jobs' tables",
+	Long:  "Print the checksums of the given sync jobs' source and target tables without syncing anything, e.g. to compare them with another environment's. Exits non-zero if any table errored. If no positional args are provided, prints all jobs.",
+	Run: func(cmd *cobra.Command, args []string) {
+		jobNames := args
+		if len(jobNames) == 0 {
+			for jobName := range config.Jobs {
+				jobNames = append(jobNames, jobName)
+			}
+			slices.Sort(jobNames) // Sort the job names so the output is deterministic
+		}
+
+		var failed bool
+
+		for i, jobName := range jobNames {
+			if i != 0 {
+				fmt.Println() // Add a newline between job results
+			}
+
+			sourceChecksum, sourceErr := config.SourceChecksum(jobName)
+			targetChecksums, err := config.TargetChecksums(jobName)
+			if err != nil {
+				fmt.Println(err)
+				failed = true
+				continue
+			}
+
+			if !printChecksumOutput(jobName, sourceChecksum, sourceErr, targetChecksums) {
+				failed = true
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// printChecksumOutput prints a job's checksums and returns whether every table was checksummed
+func printChecksumOutput(
+	jobName string,
+	sourceChecksum string,
+	sourceErr error,
+	targetChecksums []sync.TargetChecksum,
+) bool {
+	ok := sourceErr == nil
+
+	fmt.Println(jobName + ":")
+	if sourceErr != nil {
+		fmt.Println("  - source: error:", sourceErr)
+	} else {
+		fmt.Println("  - source:", sourceChecksum)
+	}
+
+	fmt.Println("  - targets:")
+	for _, r := range targetChecksums {
+		if r.Error != nil {
+			fmt.Printf("    - %s: error: %s\n", r.Target.Label, r.Error)
+			ok = false
+		} else {
+			fmt.Printf("    - %s: %s\n", r.Target.Label, r.Checksum)
+		}
+	}
+
+	return ok
+}
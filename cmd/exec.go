@@ -1,16 +1,119 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	sync "github.com/NickDubelman/sql-table-sync"
 )
 
+var execDryRun bool
+var execRollbackDryRun bool
+var execTimings bool
+var execSince string
+var execFailOnDrift bool
+var execInteractive bool
+var execMaxTargets int
+var execVerbose bool
+var execInsertsOnly bool
+var execUpdatesOnly bool
+var execDeletesOnly bool
+var execResultFile string
+var execColumns []string
+var execPKMin string
+var execPKMax string
+
 func init() {
 	rootCmd.AddCommand(execCmd)
+	execCmd.Flags().BoolVar(
+		&execDryRun, "dry-run", false, "compute the diff for each target without writing anything",
+	)
+	execCmd.Flags().BoolVar(
+		&execRollbackDryRun,
+		"rollback-dry-run",
+		false,
+		"write each target's changes in a transaction that is rolled back, to check them against the target's constraints",
+	)
+	execCmd.Flags().BoolVar(
+		&execTimings, "timings", false, "print how long each phase (fetch, compare, write) took",
+	)
+	execCmd.Flags().StringVar(
+		&execSince,
+		"since",
+		"",
+		"only sync source rows whose incrementalColumn changed since a duration ago (e.g. 1h) or a timestamp (RFC 3339)",
+	)
+	execCmd.Flags().BoolVar(
+		&execFailOnDrift,
+		"fail-on-drift",
+		false,
+		"check for drift first, and refuse to sync (exiting non-zero) if any target drifted by more than its job's maxDriftRows",
+	)
+	execCmd.Flags().BoolVar(
+		&execInteractive,
+		"interactive",
+		false,
+		"prompt for confirmation before writing to each target that has changes",
+	)
+	execCmd.Flags().IntVar(
+		&execMaxTargets,
+		"max-targets",
+		0,
+		"only sync the first N targets (in config order) of each job, e.g. to canary a sync",
+	)
+	execCmd.Flags().BoolVar(
+		&execVerbose, "verbose", false, "print which columns changed in each updated row",
+	)
+	execCmd.Flags().BoolVar(
+		&execInsertsOnly,
+		"inserts-only",
+		false,
+		"only insert missing rows (can be combined with --updates-only and --deletes-only)",
+	)
+	execCmd.Flags().BoolVar(
+		&execUpdatesOnly,
+		"updates-only",
+		false,
+		"only update changed rows (can be combined with --inserts-only and --deletes-only)",
+	)
+	execCmd.Flags().BoolVar(
+		&execDeletesOnly,
+		"deletes-only",
+		false,
+		"only delete extra rows (can be combined with --inserts-only and --updates-only)",
+	)
+	execCmd.Flags().StringVar(
+		&execResultFile,
+		"result-file",
+		"",
+		"also write the results (per job and target) as JSON to the given file, for orchestration",
+	)
+	execCmd.Flags().StringSliceVar(
+		&execColumns,
+		"columns",
+		nil,
+		"only sync the given columns of each job (e.g. id,name), which must include its primary keys",
+	)
+	execCmd.Flags().StringVar(
+		&execPKMin,
+		"pk-min",
+		"",
+		"only sync rows whose primary key is at least this value (e.g. to split a sync across workers)",
+	)
+	execCmd.Flags().StringVar(
+		&execPKMax,
+		"pk-max",
+		"",
+		"only sync rows whose primary key is at most this value (e.g. to split a sync across workers)",
+	)
 }
 
 var execCmd = &cobra.Command{
@@ -18,35 +121,356 @@ var execCmd = &cobra.Command{
 	Short: "Execute the given sync jobs",
 	Long:  `Execute the given sync jobs. If no positional args are provided, executes all jobs.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) == 0 {
-			results, errs := config.ExecAllJobs()
+		if err := requireJobArgs(args, execSince, execPKMin, execPKMax); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 
-			var jobNames []string
-			for jobName := range config.Jobs {
-				jobNames = append(jobNames, jobName)
+		var since time.Time
+		if execSince != "" {
+			var err error
+			since, err = parseSince(execSince, time.Now())
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
 			}
-			slices.Sort(jobNames) // Sort the job names so the output is deterministic
+		}
 
-			for i, jobName := range jobNames {
-				if i != 0 {
-					fmt.Println() // Add a newline between job results
-				}
+		if execMaxTargets < 0 {
+			fmt.Println("--max-targets cannot be negative")
+			os.Exit(1)
+		}
 
-				printExecOutput(jobName, results[jobName], errs[jobName])
+		var approve func(sync.SyncResult) bool
+		if execInteractive {
+			stat, err := os.Stdin.Stat()
+			isTerminal := err == nil && stat.Mode()&os.ModeCharDevice != 0
+			approve = newApprovalPrompt(os.Stdin, os.Stdout, isTerminal)
+		}
+
+		for jobName, job := range config.Jobs {
+			executed := len(args) == 0 || slices.Contains(args, jobName)
+
+			job.DryRun = job.DryRun || execDryRun
+			job.RollbackDryRun = job.RollbackDryRun || execRollbackDryRun
+			job.Approve = approve
+			job.MaxTargets = execMaxTargets
+			job.Verbose = job.Verbose || execVerbose
+			job.Phases = execPhases(execInsertsOnly, execUpdatesOnly, execDeletesOnly)
+
+			// Only the jobs being executed are restricted (see requireJobArgs)
+			if executed {
+				job.Since = since
+				job.PrimaryKeyMin = parseKeyBound(execPKMin)
+				job.PrimaryKeyMax = parseKeyBound(execPKMax)
 			}
-		} else {
-			for i, jobName := range args {
-				if i != 0 {
-					fmt.Println() // Add a newline between job results
-				}
 
-				result, err := config.ExecJob(jobName)
-				printExecOutput(jobName, result, err)
+			// Only the jobs being executed need to have the columns
+			if len(execColumns) > 0 && executed {
+				var err error
+				if job, err = job.WithColumns(execColumns); err != nil {
+					fmt.Printf("job '%s': --columns: %v\n", jobName, err)
+					os.Exit(1)
+				}
 			}
+
+			config.Jobs[jobName] = job
+		}
+
+		if execFailOnDrift && !guardDrift(args) {
+			os.Exit(1)
+		}
+
+		results, errs := execJobs(args)
+		if execErrored(results, errs) {
+			os.Exit(1)
 		}
 	},
 }
 
+// guardDrift checks the given jobs (or all jobs, if none are given) for drift, and returns whether
+// it is safe to sync them. It isn't safe if any target has drifted by more than its job's
+// maxDriftRows (or errored), in which case the check results are printed
+func guardDrift(args []string) bool {
+	jobNames := args
+	if len(jobNames) == 0 {
+		jobNames = config.JobNames() // In the order the jobs are executed
+	}
+
+	results := make(map[string]sync.CheckJobResult, len(jobNames))
+	errs := make(map[string]error, len(jobNames))
+	safe := true
+
+	for _, jobName := range jobNames {
+		results[jobName], errs[jobName] = config.CheckJob(jobName)
+		if !checkPassed(results[jobName], errs[jobName]) {
+			safe = false
+		}
+	}
+
+	if safe {
+		return true
+	}
+
+	for i, jobName := range jobNames {
+		if i != 0 {
+			fmt.Println() // Add a newline between job results
+		}
+
+		printCheckOutput(jobName, results[jobName], errs[jobName])
+	}
+
+	fmt.Println()
+	fmt.Println("refusing to sync because of drift (--fail-on-drift)")
+
+	return false
+}
+
+// execJobs executes the given jobs (or all jobs, if none are given), prints their results (or only
+// their errors, if quiet), and records them to the history table, notification webhook, and result
+// file (if configured)
+func execJobs(args []string) (map[string]sync.ExecJobResult, map[string]error) {
+	var jobNames []string
+	var results map[string]sync.ExecJobResult
+	var errs map[string]error
+
+	ranAt := time.Now()
+
+	if len(args) == 0 {
+		results, errs = config.ExecAllJobs()
+		jobNames = config.JobNames() // In the order the jobs were executed
+	} else {
+		jobNames = args
+		results = make(map[string]sync.ExecJobResult, len(args))
+		errs = make(map[string]error, len(args))
+
+		for _, jobName := range args {
+			results[jobName], errs[jobName] = config.ExecJob(jobName)
+		}
+	}
+
+	if quiet {
+		for _, jobName := range jobNames {
+			printExecErrors(jobName, results[jobName], errs[jobName])
+		}
+	} else {
+		for i, jobName := range jobNames {
+			if i != 0 {
+				fmt.Println() // Add a newline between job results
+			}
+
+			printExecOutput(jobName, results[jobName], errs[jobName])
+		}
+
+		fmt.Println()
+		fmt.Println(summarizeExec(jobNames, results, errs))
+	}
+
+	// Failing to record history or notify shouldn't fail the run, since the sync itself
+	// already happened
+	if err := config.RecordHistory(results, errs, ranAt); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to record history:", err)
+	}
+
+	if err := config.SendNotification(results, errs); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to send notification:", err)
+	}
+
+	if execResultFile != "" {
+		if err := sync.WriteResultFile(execResultFile, results, errs); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to write result file:", err)
+		}
+	}
+
+	return results, errs
+}
+
+// execErrored returns whether any job (or any of its targets) errored
+func execErrored(results map[string]sync.ExecJobResult, errs map[string]error) bool {
+	for _, err := range errs {
+		if err != nil {
+			return true
+		}
+	}
+
+	for _, result := range results {
+		for _, r := range result.Results {
+			if r.Error != nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// newApprovalPrompt returns an approval func that asks the operator to confirm each target's
+// changes before they are written. Since targets are synced in parallel, only one prompt is shown
+// at a time. If the input isn't a terminal, nobody can answer, so every target is declined
+func newApprovalPrompt(in io.Reader, out io.Writer, isTerminal bool) func(sync.SyncResult) bool {
+	reader := bufio.NewReader(in)
+	lock := make(chan struct{}, 1)
+
+	return func(result sync.SyncResult) bool {
+		lock <- struct{}{}
+		defer func() { <-lock }()
+
+		label := result.Target.Label
+		if label == "" {
+			label = result.Target.Table
+		}
+
+		fmt.Fprintf(
+			out,
+			"%s: %d inserts, %d updates, %d deletes. Apply? [y/N] ",
+			label, result.NumInserts, result.NumUpdates, result.NumDeletes,
+		)
+
+		if !isTerminal {
+			fmt.Fprintln(out, "(not a terminal, skipping)")
+			return false
+		}
+
+		answer, err := reader.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Fprintln(out) // There is no answer (e.g. EOF), so end the prompt's line
+			return false
+		}
+
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes"
+	}
+}
+
+// execPhases returns the phases selected by the --*-only flags. If none are given, every phase runs
+func execPhases(insertsOnly, updatesOnly, deletesOnly bool) sync.Phase {
+	var phases sync.Phase
+	if insertsOnly {
+		phases |= sync.PhaseInserts
+	}
+	if updatesOnly {
+		phases |= sync.PhaseUpdates
+	}
+	if deletesOnly {
+		phases |= sync.PhaseDeletes
+	}
+
+	return phases
+}
+
+// requireJobArgs returns an error if --since, --pk-min, or --pk-max is used without naming the
+// jobs to execute. Not every job can use them (e.g. --since needs an incrementalColumn, and a
+// primary key range needs a single primary key), so they aren't applied to every job at once
+func requireJobArgs(args []string, since, pkMin, pkMax string) error {
+	if len(args) > 0 {
+		return nil
+	}
+
+	flags := []struct {
+		name  string
+		value string
+	}{
+		{"--since", since},
+		{"--pk-min", pkMin},
+		{"--pk-max", pkMax},
+	}
+
+	for _, flag := range flags {
+		if flag.value != "" {
+			return fmt.Errorf("%s can only be used when naming the jobs to execute", flag.name)
+		}
+	}
+
+	return nil
+}
+
+// parseKeyBound parses the --pk-min or --pk-max flag. An integer is compared as a number, and
+// anything else as a string. An empty flag leaves that end of the range open
+func parseKeyBound(value string) any {
+	if value == "" {
+		return nil
+	}
+
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n
+	}
+
+	return value
+}
+
+// parseSince parses the --since flag, which is either a duration before now or an absolute
+// RFC 3339 timestamp
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+		return timestamp, nil
+	}
+
+	return time.Time{}, fmt.Errorf(
+		"invalid --since value '%s': must be a duration (e.g. 1h) or an RFC 3339 timestamp", value,
+	)
+}
+
+// summarizeExec formats a summary of executing the given jobs. A job that errored (rather than
+// one of its targets) counts as a single errored target, since its targets weren't synced
+func summarizeExec(
+	jobNames []string,
+	results map[string]sync.ExecJobResult,
+	errs map[string]error,
+) string {
+	var numTargets, numChanged, numErrored int
+
+	for _, jobName := range jobNames {
+		if errs[jobName] != nil {
+			numErrored++
+			continue
+		}
+
+		for _, r := range results[jobName].Results {
+			numTargets++
+
+			if r.Error != nil {
+				numErrored++
+			} else if r.Synced {
+				numChanged++
+			}
+		}
+	}
+
+	return fmt.Sprintf(
+		"%d jobs, %d targets, %d changed, %d errored",
+		len(jobNames), numTargets, numChanged, numErrored,
+	)
+}
+
+// formatStatuses formats each target's status (e.g. "replica: in-sync, backup: synced"), so that a
+// target that was already in sync can be told apart from one whose changes weren't written
+func formatStatuses(results []sync.SyncResult) string {
+	statuses := make([]string, len(results))
+	for i, r := range results {
+		statuses[i] = fmt.Sprintf("%s: %s", r.Target.Label, r.Status)
+	}
+
+	return strings.Join(statuses, ", ")
+}
+
+// printExecErrors prints only the errors from executing a job (to stderr), for --quiet
+func printExecErrors(jobName string, result sync.ExecJobResult, err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", jobName, err)
+		return
+	}
+
+	for _, r := range result.Results {
+		if r.Error != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s: %s\n", jobName, r.Target.Label, r.Error)
+		}
+	}
+}
+
 func printExecOutput(jobName string, result sync.ExecJobResult, err error) {
 	if err != nil {
 		fmt.Println(err)
@@ -55,8 +479,9 @@ func printExecOutput(jobName string, result sync.ExecJobResult, err error) {
 
 	fmt.Println(jobName + ":")
 	fmt.Println("  - source checksum:", result.Checksum)
+	fmt.Println("  - source rows:", result.SourceRowCount)
 
-	var numOk, numChanged int
+	var numOk, numChanged, numSkipped, numUnchanged int
 	var targetErrs []string
 
 	for _, r := range result.Results {
@@ -69,10 +494,24 @@ func printExecOutput(jobName string, result sync.ExecJobResult, err error) {
 			if r.Synced {
 				numChanged++
 			}
+
+			if r.Skipped {
+				numSkipped++
+			}
+
+			if r.Unchanged {
+				numUnchanged++
+			}
 		}
 	}
 
 	resultStr := fmt.Sprintf("%d ok, %d changed", numOk, numChanged)
+	if numSkipped > 0 {
+		resultStr += fmt.Sprintf(", %d skipped", numSkipped)
+	}
+	if numUnchanged > 0 {
+		resultStr += fmt.Sprintf(", %d unchanged", numUnchanged)
+	}
 	if len(targetErrs) > 0 {
 		resultStr += fmt.Sprintf(", %d errored", len(targetErrs))
 	}
@@ -84,4 +523,89 @@ func printExecOutput(jobName string, result sync.ExecJobResult, err error) {
 			fmt.Println("    -", err)
 		}
 	}
+
+	if len(result.Results) > 0 {
+		fmt.Println("  - statuses:", formatStatuses(result.Results))
+	}
+
+	// After a full sync, each target should have as many rows as the source
+	var targetRows []string
+	for _, r := range result.Results {
+		if r.Error == nil && !r.Unchanged && r.Partitions == nil {
+			targetRows = append(targetRows, fmt.Sprintf("%s: %d", r.Target.Label, r.TargetRowCount))
+		}
+	}
+	if len(targetRows) > 0 {
+		fmt.Println("  - target rows:", strings.Join(targetRows, ", "))
+	}
+
+	for _, r := range result.Results {
+		if len(r.SkippedColumns) > 0 {
+			fmt.Printf(
+				"  - %s: skipped missing columns: %s\n",
+				r.Target.Label, strings.Join(r.SkippedColumns, ", "),
+			)
+		}
+	}
+
+	for _, r := range result.Results {
+		if r.Error == nil && r.Partitions != nil {
+			fmt.Printf("  - %s: only read changed partitions %v\n", r.Target.Label, r.Partitions)
+		}
+	}
+
+	if execTimings {
+		fmt.Println("  - timings:")
+		for _, r := range result.Results {
+			fmt.Printf(
+				"    - %s: fetch %s, compare %s, write %s\n",
+				r.Target.Label, r.FetchDuration, r.CompareDuration, r.WriteDuration,
+			)
+		}
+	}
+
+	if execVerbose {
+		fmt.Println("  - updates:")
+		for _, r := range result.Results {
+			for _, update := range r.Updates {
+				fmt.Printf("    - %s: %s\n", r.Target.Label, formatRowUpdate(update))
+			}
+		}
+	}
+
+	if execDryRun || execRollbackDryRun {
+		if execDryRun {
+			fmt.Println("  - dry run (nothing was written):")
+		} else {
+			fmt.Println("  - rollback dry run (changes were written, then rolled back):")
+		}
+
+		for _, r := range result.Results {
+			if r.Error != nil {
+				continue
+			}
+
+			fmt.Printf(
+				"    - %s: %d inserts, %d updates, %d deletes\n",
+				r.Target.Label, r.NumInserts, r.NumUpdates, r.NumDeletes,
+			)
+		}
+	}
+}
+
+// formatRowUpdate formats an updated row's key and changed columns, e.g. "id=2: name, age"
+func formatRowUpdate(update sync.RowUpdate) string {
+	key := formatRowKey(update.KeyColumns, update.Key)
+	return fmt.Sprintf("%s: %s", key, strings.Join(update.Columns, ", "))
+}
+
+// formatRowKey formats a changed row's key, e.g. "org=acme, id=7". The key is labeled with the
+// row's own key columns, which are the target's if it has its own
+func formatRowKey(keyColumns []string, key []any) string {
+	keyParts := make([]string, len(key))
+	for i, val := range key {
+		keyParts[i] = fmt.Sprintf("%s=%v", keyColumns[i], val)
+	}
+
+	return strings.Join(keyParts, ", ")
 }
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sync "github.com/NickDubelman/sql-table-sync"
+)
+
+func TestRequireJobArgs(t *testing.T) {
+	assert.NoError(t, requireJobArgs(nil, "", "", ""))
+	assert.NoError(t, requireJobArgs([]string{"users"}, "1h", "1", "100"))
+
+	err := requireJobArgs(nil, "1h", "", "")
+	assert.EqualError(t, err, "--since can only be used when naming the jobs to execute")
+
+	err = requireJobArgs(nil, "", "1", "")
+	assert.EqualError(t, err, "--pk-min can only be used when naming the jobs to execute")
+
+	err = requireJobArgs(nil, "", "", "100")
+	assert.EqualError(t, err, "--pk-max can only be used when naming the jobs to execute")
+}
+
+func TestParseKeyBound(t *testing.T) {
+	assert.Nil(t, parseKeyBound(""))
+	assert.Equal(t, int64(42), parseKeyBound("42"))
+	assert.Equal(t, int64(-7), parseKeyBound("-7"))
+	assert.Equal(t, "user-42", parseKeyBound("user-42"))
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("duration", func(t *testing.T) {
+		since, err := parseSince("90m", now)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC), since)
+	})
+
+	t.Run("timestamp", func(t *testing.T) {
+		since, err := parseSince("2024-01-01T00:00:00Z", now)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), since)
+
+		since, err = parseSince("2024-01-01T08:00:00+08:00", now)
+		require.NoError(t, err)
+		assert.True(t, since.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseSince("yesterday", now)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "invalid --since value 'yesterday'")
+	})
+}
+
+func TestExecPhases(t *testing.T) {
+	assert.Equal(t, sync.Phase(0), execPhases(false, false, false))
+	assert.Equal(t, sync.PhaseInserts, execPhases(true, false, false))
+	assert.Equal(t, sync.PhaseUpdates|sync.PhaseDeletes, execPhases(false, true, true))
+	assert.Equal(t, sync.AllPhases, execPhases(true, true, true))
+}
+
+func TestGuardDrift(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)"
+
+	sourceDSN := "file:guard_drift_source.db?mode=memory&cache=shared"
+	source := sqlx.MustConnect("sqlite3", sourceDSN)
+	defer source.Close()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	targetDSN := "file:guard_drift_target.db?mode=memory&cache=shared"
+	target := sqlx.MustConnect("sqlite3", targetDSN)
+	defer target.Close()
+	target.MustExec(createTable)
+	target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice')")
+
+	defer func(original sync.Config) { config = original }(config)
+	config = sync.Config{
+		Jobs: map[string]sync.JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      sync.TableConfig{Driver: "sqlite3", DSN: sourceDSN, Table: "users"},
+				Targets: []sync.TableConfig{
+					{Driver: "sqlite3", DSN: targetDSN, Table: "users"},
+				},
+			},
+		},
+	}
+
+	// The target is missing a row, so it isn't safe to sync
+	assert.False(t, guardDrift(nil))
+	assert.False(t, guardDrift([]string{"users"}))
+
+	// Nothing should have been written
+	var count int
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 1, count)
+
+	// Drift within maxDriftRows is allowed
+	job := config.Jobs["users"]
+	job.MaxDriftRows = 1
+	config.Jobs["users"] = job
+	assert.True(t, guardDrift(nil))
+
+	// A job that doesn't exist is never safe
+	assert.False(t, guardDrift([]string{"pets"}))
+}
+
+func TestNewApprovalPrompt(t *testing.T) {
+	result := sync.SyncResult{
+		Target:     sync.TableConfig{Label: "replica", Table: "users"},
+		NumInserts: 1,
+		NumUpdates: 2,
+		NumDeletes: 3,
+	}
+
+	t.Run("terminal", func(t *testing.T) {
+		var out bytes.Buffer
+		approve := newApprovalPrompt(strings.NewReader("y\nno\n YES \n\n"), &out, true)
+
+		assert.True(t, approve(result))
+		assert.False(t, approve(result))
+		assert.True(t, approve(result))
+		assert.False(t, approve(result)) // An empty answer declines
+		assert.False(t, approve(result)) // So does running out of input
+
+		prompt := "replica: 1 inserts, 2 updates, 3 deletes. Apply? [y/N] "
+		assert.Equal(t, 5, strings.Count(out.String(), prompt))
+	})
+
+	t.Run("not a terminal", func(t *testing.T) {
+		var out bytes.Buffer
+		approve := newApprovalPrompt(strings.NewReader("y\n"), &out, false)
+
+		assert.False(t, approve(result))
+		assert.Contains(t, out.String(), "(not a terminal, skipping)")
+	})
+}
+
+func TestSummarizeExec(t *testing.T) {
+	results := map[string]sync.ExecJobResult{
+		"users": {
+			Results: []sync.SyncResult{
+				{Synced: true},
+				{Synced: false},
+				{Error: assert.AnError},
+			},
+		},
+		"pets": {
+			Results: []sync.SyncResult{{Synced: true}, {Synced: true}},
+		},
+	}
+	errs := map[string]error{"posts": assert.AnError}
+
+	summary := summarizeExec([]string{"pets", "posts", "users"}, results, errs)
+	assert.Equal(t, "3 jobs, 5 targets, 3 changed, 2 errored", summary)
+
+	assert.Equal(t, "0 jobs, 0 targets, 0 changed, 0 errored", summarizeExec(nil, nil, nil))
+}
+
+func TestFormatStatuses(t *testing.T) {
+	results := []sync.SyncResult{
+		{Target: sync.TableConfig{Label: "replica"}, Status: sync.SyncStatusInSync},
+		{Target: sync.TableConfig{Label: "backup"}, Status: sync.SyncStatusSynced},
+		{Target: sync.TableConfig{Label: "archive"}, Status: sync.SyncStatusError},
+	}
+	assert.Equal(t, "replica: in-sync, backup: synced, archive: error", formatStatuses(results))
+}
+
+func TestFormatRowUpdate(t *testing.T) {
+	update := sync.RowUpdate{
+		KeyColumns: []string{"id"},
+		Key:        []any{int64(2)},
+		Columns:    []string{"name", "age"},
+	}
+	assert.Equal(t, "id=2: name, age", formatRowUpdate(update))
+
+	update = sync.RowUpdate{
+		KeyColumns: []string{"org", "id"},
+		Key:        []any{"acme", int64(7)},
+		Columns:    []string{"email"},
+	}
+	assert.Equal(t, "org=acme, id=7: email", formatRowUpdate(update))
+}
+
+// captureOutput runs fn, and returns what it printed to stdout and stderr
+func captureOutput(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	require.NoError(t, err)
+	stderrReader, stderrWriter, err := os.Pipe()
+	require.NoError(t, err)
+
+	defer func(stdout, stderr *os.File) {
+		os.Stdout, os.Stderr = stdout, stderr
+	}(os.Stdout, os.Stderr)
+	os.Stdout, os.Stderr = stdoutWriter, stderrWriter
+
+	fn()
+
+	stdoutWriter.Close()
+	stderrWriter.Close()
+
+	stdout, err := io.ReadAll(stdoutReader)
+	require.NoError(t, err)
+	stderr, err := io.ReadAll(stderrReader)
+	require.NoError(t, err)
+
+	return string(stdout), string(stderr)
+}
+
+func TestExecJobs_quiet(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)"
+
+	sourceDSN := "file:exec_quiet_source.db?mode=memory&cache=shared"
+	source := sqlx.MustConnect("sqlite3", sourceDSN)
+	defer source.Close()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	targetDSN := "file:exec_quiet_target.db?mode=memory&cache=shared"
+	target := sqlx.MustConnect("sqlite3", targetDSN)
+	defer target.Close()
+	target.MustExec(createTable)
+
+	// The missing target doesn't have the table
+	missingDSN := "file:exec_quiet_missing.db?mode=memory&cache=shared"
+	missing := sqlx.MustConnect("sqlite3", missingDSN)
+	defer missing.Close()
+
+	newJob := func(targetLabel, targetDSN string) sync.JobConfig {
+		return sync.JobConfig{
+			PrimaryKeys: []string{"id"},
+			Columns:     []string{"id", "name"},
+			Source:      sync.TableConfig{Driver: "sqlite3", DSN: sourceDSN, Table: "users"},
+			Targets: []sync.TableConfig{
+				{Label: targetLabel, Driver: "sqlite3", DSN: targetDSN, Table: "users"},
+			},
+		}
+	}
+
+	defer func(original sync.Config) { config = original }(config)
+	config = sync.Config{
+		Jobs: map[string]sync.JobConfig{
+			"users":  newJob("replica", targetDSN),
+			"broken": newJob("missing", missingDSN),
+		},
+	}
+
+	defer func(original bool) { quiet = original }(quiet)
+	quiet = true
+
+	// A successful run prints nothing
+	var results map[string]sync.ExecJobResult
+	var errs map[string]error
+	stdout, stderr := captureOutput(t, func() {
+		results, errs = execJobs([]string{"users"})
+	})
+	assert.Empty(t, stdout)
+	assert.Empty(t, stderr)
+	assert.False(t, execErrored(results, errs))
+	assert.True(t, results["users"].Results[0].Synced)
+
+	// A failing run only prints its errors, to stderr
+	stdout, stderr = captureOutput(t, func() {
+		results, errs = execJobs([]string{"users", "broken", "pets"})
+	})
+	assert.Empty(t, stdout)
+
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "broken: missing: failed to query table"), lines[0])
+	assert.Equal(t, "pets: job 'pets' not found in config", lines[1])
+	assert.True(t, execErrored(results, errs))
+
+	// Without quiet, the normal output is printed
+	quiet = false
+	stdout, stderr = captureOutput(t, func() {
+		execJobs([]string{"users"})
+	})
+	assert.Contains(t, stdout, "users:")
+	assert.Contains(t, stdout, "1 jobs, 1 targets, 0 changed, 0 errored")
+	assert.Empty(t, stderr)
+}
+
+func TestExecJobs_verbose_target_primary_keys(t *testing.T) {
+	sourceDSN := "file:exec_verbose_pks_source.db?mode=memory&cache=shared"
+	source := sqlx.MustConnect("sqlite3", sourceDSN)
+	defer source.Close()
+	source.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (org INTEGER, id INTEGER PRIMARY KEY, name TEXT)
+	`)
+	source.MustExec("INSERT INTO users (org, id, name) VALUES (1, 1, 'Alice'), (1, 2, 'Bob')")
+
+	// The target is keyed by more columns than the job
+	targetDSN := "file:exec_verbose_pks_target.db?mode=memory&cache=shared"
+	target := sqlx.MustConnect("sqlite3", targetDSN)
+	defer target.Close()
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (org INTEGER, id INTEGER, name TEXT, PRIMARY KEY (org, id))
+	`)
+	target.MustExec("INSERT INTO users (org, id, name) VALUES (1, 1, 'Alice'), (1, 2, 'Robert')")
+
+	defer func(original sync.Config) { config = original }(config)
+	config = sync.Config{
+		Jobs: map[string]sync.JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"org", "id", "name"},
+				Verbose:     true, // Like the command sets it for --verbose
+				Source:      sync.TableConfig{Driver: "sqlite3", DSN: sourceDSN, Table: "users"},
+				Targets: []sync.TableConfig{
+					{
+						Label:       "replica",
+						Driver:      "sqlite3",
+						DSN:         targetDSN,
+						Table:       "users",
+						PrimaryKeys: []string{"org", "id"},
+					},
+				},
+			},
+		},
+	}
+
+	defer func(original bool) { execVerbose = original }(execVerbose)
+	execVerbose = true
+
+	// The updated row's key is labeled with the target's primary keys, not the job's
+	stdout, stderr := captureOutput(t, func() {
+		execJobs([]string{"users"})
+	})
+	assert.Contains(t, stdout, "    - replica: org=1, id=2: name\n")
+	assert.Empty(t, stderr)
+}
+
+func TestExecJobs_result_file(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)"
+
+	sourceDSN := "file:exec_result_file_source.db?mode=memory&cache=shared"
+	source := sqlx.MustConnect("sqlite3", sourceDSN)
+	defer source.Close()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	targetDSN := "file:exec_result_file_target.db?mode=memory&cache=shared"
+	target := sqlx.MustConnect("sqlite3", targetDSN)
+	defer target.Close()
+	target.MustExec(createTable)
+	target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Nick'), (3, 'Charlie')")
+
+	defer func(original sync.Config) { config = original }(config)
+	config = sync.Config{
+		Jobs: map[string]sync.JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      sync.TableConfig{Driver: "sqlite3", DSN: sourceDSN, Table: "users"},
+				Targets: []sync.TableConfig{
+					{Label: "replica", Driver: "sqlite3", DSN: targetDSN, Table: "users"},
+				},
+			},
+		},
+	}
+
+	defer func(original string) { execResultFile = original }(execResultFile)
+	execResultFile = filepath.Join(t.TempDir(), "results.json")
+
+	var results map[string]sync.ExecJobResult
+	captureOutput(t, func() {
+		results, _ = execJobs([]string{"users", "pets"})
+	})
+
+	fileBytes, err := os.ReadFile(execResultFile)
+	require.NoError(t, err)
+
+	type targetResult struct {
+		Label           string
+		Checksum        string
+		Synced          bool
+		Error           string
+		NumInserts      int
+		NumUpdates      int
+		NumDeletes      int
+		FetchDuration   string
+		CompareDuration string
+		WriteDuration   string
+	}
+
+	var written struct {
+		Jobs []struct {
+			Name     string
+			Checksum string
+			Error    string
+			Targets  []targetResult
+		}
+	}
+	require.NoError(t, json.Unmarshal(fileBytes, &written))
+	require.Len(t, written.Jobs, 2)
+
+	// The jobs are sorted by name, and a job that errored has no targets
+	assert.Equal(t, "pets", written.Jobs[0].Name)
+	assert.Equal(t, "job 'pets' not found in config", written.Jobs[0].Error)
+	assert.Empty(t, written.Jobs[0].Targets)
+
+	users := written.Jobs[1]
+	assert.Equal(t, "users", users.Name)
+	assert.Equal(t, results["users"].Checksum, users.Checksum)
+	assert.Empty(t, users.Error)
+	require.Len(t, users.Targets, 1)
+
+	replica := users.Targets[0]
+	assert.Equal(t, "replica", replica.Label)
+	assert.Equal(t, results["users"].Results[0].TargetChecksum, replica.Checksum)
+	assert.True(t, replica.Synced)
+	assert.Empty(t, replica.Error)
+	assert.Equal(t, 1, replica.NumInserts)
+	assert.Equal(t, 1, replica.NumUpdates)
+	assert.Equal(t, 1, replica.NumDeletes)
+	assert.Equal(t, results["users"].Results[0].WriteDuration.String(), replica.WriteDuration)
+	assert.NotEmpty(t, replica.FetchDuration)
+	assert.NotEmpty(t, replica.CompareDuration)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var exportFormat string
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(
+		&exportFormat, "format", "f", "csv", "output format (csv or json)",
+	)
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export job",
+	Short: "Export a job's source data",
+	Long:  "Export a job's source data to stdout as CSV or JSON.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.ExportJob(args[0], os.Stdout, exportFormat); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
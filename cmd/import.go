@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var importFile string
+var importJob string
+var importFormat string
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVar(&importFile, "file", "", "path to the file to import")
+	importCmd.Flags().StringVar(&importJob, "job", "", "the job whose targets are synced")
+	importCmd.Flags().StringVarP(
+		&importFormat, "format", "f", "", "input format (csv or json); defaults to the file extension",
+	)
+	importCmd.MarkFlagRequired("file")
+	importCmd.MarkFlagRequired("job")
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Sync a job's targets from a file",
+	Long:  "Sync a job's targets from the rows in a CSV or JSON file, instead of from the job's source table.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := importFormat
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(importFile), ".")
+		}
+
+		file, err := os.Open(importFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer file.Close()
+
+		result, err := config.ImportJob(importJob, file, format)
+		printExecOutput(importJob, result, err)
+	},
+}
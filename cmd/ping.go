@@ -12,12 +12,19 @@ import (
 )
 
 var pingTimeoutStr string
+var pingAttempts int
 
 func init() {
 	rootCmd.AddCommand(pingCmd)
 	pingCmd.Flags().StringVarP(
 		&pingTimeoutStr, "timeout", "t", "10s", "timeout for pinging each table",
 	)
+	pingCmd.Flags().IntVar(
+		&pingAttempts,
+		"attempts",
+		0,
+		"how many times to ping each table before reporting it as unreachable (overrides pingAttempts)",
+	)
 }
 
 var pingCmd = &cobra.Command{
@@ -31,37 +38,154 @@ var pingCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		if len(args) == 0 {
-			allResults, err := config.PingAllJobs(timeout)
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
+		if pingAttempts > 0 {
+			for jobName, job := range config.Jobs {
+				job.PingAttempts = pingAttempts
+				config.Jobs[jobName] = job
 			}
+		}
 
-			var jobNames []string
-			for jobName := range config.Jobs {
-				jobNames = append(jobNames, jobName)
-			}
-			slices.Sort(jobNames) // Sort the job names so the output is deterministic
+		results, errs := pingJobs(args, timeout)
+		if pingErrored(results, errs) {
+			os.Exit(1)
+		}
+	},
+}
+
+// pingJobs pings the given jobs (or all jobs, if none are given), and prints their results (or only
+// their errors, if quiet)
+func pingJobs(
+	args []string,
+	timeout time.Duration,
+) (map[string][]sync.PingResult, map[string]error) {
+	jobNames, results, errs := collectPings(args, timeout)
+	printPings(jobNames, results, errs)
+
+	return results, errs
+}
+
+// collectPings pings the given jobs (or all jobs, if none are given), without printing anything.
+// It also returns the names of the jobs, in the order that their results are printed
+func collectPings(
+	args []string,
+	timeout time.Duration,
+) ([]string, map[string][]sync.PingResult, map[string]error) {
+	var jobNames []string
+	var allResults map[string][]sync.PingResult
+	errs := map[string]error{}
+
+	if len(args) == 0 {
+		var err error
+		allResults, err = config.PingAllJobs(timeout)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 
-			for i, jobName := range jobNames {
-				if i != 0 {
-					fmt.Println() // Add a newline between job results
-				}
+		for jobName := range config.Jobs {
+			jobNames = append(jobNames, jobName)
+		}
+		slices.Sort(jobNames) // Sort the job names so the output is deterministic
+	} else {
+		jobNames = args
+		allResults = make(map[string][]sync.PingResult, len(args))
+
+		for _, jobName := range args {
+			allResults[jobName], errs[jobName] = config.PingJob(jobName, timeout)
+		}
+	}
+
+	return jobNames, allResults, errs
+}
 
-				printPingOutput(jobName, allResults[jobName], nil)
+// printPings prints the results of pinging the given jobs (or only their errors, if quiet)
+func printPings(
+	jobNames []string,
+	allResults map[string][]sync.PingResult,
+	errs map[string]error,
+) {
+	if quiet {
+		for _, jobName := range jobNames {
+			printPingErrors(jobName, allResults[jobName], errs[jobName])
+		}
+
+		return
+	}
+
+	for i, jobName := range jobNames {
+		if i != 0 {
+			fmt.Println() // Add a newline between job results
+		}
+
+		printPingOutput(jobName, allResults[jobName], errs[jobName])
+	}
+
+	fmt.Println()
+	fmt.Println(summarizePing(jobNames, allResults, errs))
+}
+
+// pingErrored returns whether any job (or any of its tables) errored
+func pingErrored(results map[string][]sync.PingResult, errs map[string]error) bool {
+	for _, err := range errs {
+		if err != nil {
+			return true
+		}
+	}
+
+	for _, jobResults := range results {
+		for _, r := range jobResults {
+			if r.Error != nil {
+				return true
 			}
-		} else {
-			for i, jobName := range args {
-				if i != 0 {
-					fmt.Println() // Add a newline between job results
-				}
+		}
+	}
+
+	return false
+}
+
+// summarizePing formats a summary of pinging the given jobs. A job that errored (rather than one
+// of its tables) counts as a single errored table, since its tables weren't pinged
+func summarizePing(
+	jobNames []string,
+	results map[string][]sync.PingResult,
+	errs map[string]error,
+) string {
+	var numTables, numOk, numErrored int
+
+	for _, jobName := range jobNames {
+		if errs[jobName] != nil {
+			numErrored++
+			continue
+		}
+
+		for _, r := range results[jobName] {
+			numTables++
 
-				results, err := config.PingJob(jobName, timeout)
-				printPingOutput(jobName, results, err)
+			if r.Error != nil {
+				numErrored++
+			} else {
+				numOk++
 			}
 		}
-	},
+	}
+
+	return fmt.Sprintf(
+		"%d jobs, %d tables, %d ok, %d errored", len(jobNames), numTables, numOk, numErrored,
+	)
+}
+
+// printPingErrors prints only the errors from pinging a job (to stderr), for --quiet
+func printPingErrors(jobName string, results []sync.PingResult, err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", jobName, err)
+		return
+	}
+
+	for _, r := range results {
+		if r.Error != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s: %s\n", jobName, r.Config.Label, r.Error)
+		}
+	}
 }
 
 func printPingOutput(jobName string, results []sync.PingResult, err error) {
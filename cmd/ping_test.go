@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sync "github.com/NickDubelman/sql-table-sync"
+)
+
+func TestSummarizePing(t *testing.T) {
+	results := map[string][]sync.PingResult{
+		"users": {{}, {}, {Error: assert.AnError}},
+		"pets":  {{}, {}},
+	}
+	errs := map[string]error{"posts": assert.AnError}
+
+	summary := summarizePing([]string{"pets", "posts", "users"}, results, errs)
+	assert.Equal(t, "3 jobs, 5 tables, 4 ok, 2 errored", summary)
+}
+
+func TestPingJobs_quiet(t *testing.T) {
+	dsn := "file:ping_quiet.db?mode=memory&cache=shared"
+	db := sqlx.MustConnect("sqlite3", dsn)
+	defer db.Close()
+	db.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)")
+	db.MustExec("CREATE TABLE IF NOT EXISTS users_copy (id INTEGER PRIMARY KEY, name TEXT)")
+
+	newJob := func(targetTable string) sync.JobConfig {
+		return sync.JobConfig{
+			PrimaryKeys: []string{"id"},
+			Columns:     []string{"id", "name"},
+			Source:      sync.TableConfig{Label: "source", Driver: "sqlite3", DSN: dsn, Table: "users"},
+			Targets: []sync.TableConfig{
+				{Label: "replica", Driver: "sqlite3", DSN: dsn, Table: targetTable},
+			},
+		}
+	}
+
+	defer func(original sync.Config) { config = original }(config)
+	config = sync.Config{
+		Jobs: map[string]sync.JobConfig{
+			"users":  newJob("users_copy"),
+			"broken": newJob("missing_users"),
+		},
+	}
+
+	defer func(original bool) { quiet = original }(quiet)
+	quiet = true
+
+	// A successful run prints nothing
+	var results map[string][]sync.PingResult
+	var errs map[string]error
+	stdout, stderr := captureOutput(t, func() {
+		results, errs = pingJobs([]string{"users"}, 30*time.Second)
+	})
+	assert.Empty(t, stdout)
+	assert.Empty(t, stderr)
+	assert.False(t, pingErrored(results, errs))
+
+	// A failing run only prints its errors, to stderr
+	stdout, stderr = captureOutput(t, func() {
+		results, errs = pingJobs(nil, 30*time.Second)
+	})
+	assert.Empty(t, stdout)
+
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	require.Len(t, lines, 1)
+	assert.True(t, strings.HasPrefix(lines[0], "broken: replica: failed to query table"), lines[0])
+	assert.True(t, pingErrored(results, errs))
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan job",
+	Short: "Print the SQL that would sync a job",
+	Long:  "Print the SQL statements that would bring each of a job's targets in sync with its source, without executing them.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.GenerateMigration(args[0], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
@@ -10,12 +10,14 @@ import (
 )
 
 var configFilename string
+var configProfile string
 var config sync.Config
+var quiet bool
 
 func init() {
 	cobra.OnInitialize(func() {
 		var err error
-		config, err = sync.LoadConfig(configFilename)
+		config, err = sync.LoadConfigProfile(configFilename, configProfile)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -25,6 +27,12 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(
 		&configFilename, "config", "c", "./sync-config.yaml", "config file",
 	)
+	rootCmd.PersistentFlags().StringVar(
+		&configProfile, "profile", "", "profile to use, if the config file has profiles",
+	)
+	rootCmd.PersistentFlags().BoolVarP(
+		&quiet, "quiet", "q", false, "only print errors (to stderr), e.g. for cron jobs",
+	)
 }
 
 func main() {
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var runTimeoutStr string
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().StringVarP(
+		&runTimeoutStr, "timeout", "t", "10s", "timeout for pinging each table",
+	)
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run [job]...",
+	Short: "Ping, then execute the given sync jobs",
+	Long:  "Pings the given sync jobs, and only executes them if every table is reachable and has the job's columns. If no positional args are provided, runs all jobs.",
+	Run: func(cmd *cobra.Command, args []string) {
+		timeout, err := time.ParseDuration(runTimeoutStr)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if !runJobs(args, timeout) {
+			os.Exit(1)
+		}
+	},
+}
+
+// runJobs pings the given jobs (or all jobs, if none are given), and only executes them if every
+// table is reachable. It returns whether the jobs were executed without any errors
+func runJobs(args []string, timeout time.Duration) bool {
+	if !guardPing(args, timeout) {
+		return false
+	}
+
+	results, errs := execJobs(args)
+	return !execErrored(results, errs)
+}
+
+// guardPing pings the given jobs (or all jobs, if none are given), and returns whether it is safe
+// to sync them. It isn't safe if any table is unreachable or missing columns (or a job errored), in
+// which case the ping results (or only their errors, if quiet) are printed
+func guardPing(args []string, timeout time.Duration) bool {
+	jobNames, results, errs := collectPings(args, timeout)
+	if !pingErrored(results, errs) {
+		return true
+	}
+
+	printPings(jobNames, results, errs)
+
+	const refusal = "refusing to sync because some tables failed to ping"
+	if quiet {
+		fmt.Fprintln(os.Stderr, refusal)
+	} else {
+		fmt.Println()
+		fmt.Println(refusal)
+	}
+
+	return false
+}
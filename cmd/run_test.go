@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sync "github.com/NickDubelman/sql-table-sync"
+)
+
+func TestRunJobs(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)"
+
+	sourceDSN := "file:run_source.db?mode=memory&cache=shared"
+	source := sqlx.MustConnect("sqlite3", sourceDSN)
+	defer source.Close()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	targetDSN := "file:run_target.db?mode=memory&cache=shared"
+	target := sqlx.MustConnect("sqlite3", targetDSN)
+	defer target.Close()
+	target.MustExec(createTable)
+
+	newJob := func(targetTable string) sync.JobConfig {
+		return sync.JobConfig{
+			PrimaryKeys: []string{"id"},
+			Columns:     []string{"id", "name"},
+			Source:      sync.TableConfig{Driver: "sqlite3", DSN: sourceDSN, Table: "users"},
+			Targets: []sync.TableConfig{
+				{Label: "replica", Driver: "sqlite3", DSN: targetDSN, Table: targetTable},
+			},
+		}
+	}
+
+	defer func(original sync.Config) { config = original }(config)
+	config = sync.Config{
+		Jobs: map[string]sync.JobConfig{
+			"users":  newJob("users"),
+			"broken": newJob("missing_users"),
+		},
+	}
+
+	countTargetRows := func() int {
+		var count int
+		require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users"))
+		return count
+	}
+
+	// One of the jobs' targets doesn't exist, so nothing is synced
+	var ok bool
+	stdout, _ := captureOutput(t, func() { ok = runJobs(nil, 30*time.Second) })
+	assert.False(t, ok)
+	assert.Contains(t, stdout, "replica: failed to query table")
+	assert.Contains(t, stdout, "refusing to sync because some tables failed to ping")
+	assert.Equal(t, 0, countTargetRows())
+
+	// A job that doesn't exist is never run
+	stdout, _ = captureOutput(t, func() { ok = runJobs([]string{"pets"}, 30*time.Second) })
+	assert.False(t, ok)
+	assert.Contains(t, stdout, "refusing to sync")
+
+	// When only the healthy job is run, every table pings, so it is synced
+	stdout, _ = captureOutput(t, func() { ok = runJobs([]string{"users"}, 30*time.Second) })
+	assert.True(t, ok)
+	assert.NotContains(t, stdout, "refusing to sync")
+	assert.Equal(t, 2, countTargetRows())
+}
+
+func TestRunJobs_quiet(t *testing.T) {
+	dsn := "file:run_quiet.db?mode=memory&cache=shared"
+	db := sqlx.MustConnect("sqlite3", dsn)
+	defer db.Close()
+	db.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)")
+
+	defer func(original sync.Config) { config = original }(config)
+	config = sync.Config{
+		Jobs: map[string]sync.JobConfig{
+			"broken": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      sync.TableConfig{Driver: "sqlite3", DSN: dsn, Table: "users"},
+				Targets: []sync.TableConfig{
+					{Label: "replica", Driver: "sqlite3", DSN: dsn, Table: "missing_users"},
+				},
+			},
+		},
+	}
+
+	defer func(original bool) { quiet = original }(quiet)
+	quiet = true
+
+	// Only the errors (and the refusal) are printed, to stderr
+	var ok bool
+	stdout, stderr := captureOutput(t, func() { ok = runJobs(nil, 30*time.Second) })
+	assert.False(t, ok)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "broken: replica: failed to query table")
+	assert.Contains(t, stderr, "refusing to sync because some tables failed to ping")
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	sync "github.com/NickDubelman/sql-table-sync"
+)
+
+var schemaApply bool
+
+func init() {
+	rootCmd.AddCommand(ensureSchemaCmd)
+	ensureSchemaCmd.Flags().BoolVar(
+		&schemaApply, "apply", false, "create missing target tables and add missing columns",
+	)
+}
+
+var ensureSchemaCmd = &cobra.Command{
+	Use:   "ensure-schema [job]...",
+	Short: "Check that the given sync jobs' targets have the configured columns",
+	Long:  "Check that each of the given sync jobs' targets exists and has at least the configured columns. With --apply, missing tables are created and missing columns are added, with the source's column types (columns are never dropped or changed). Exits non-zero if anything errored, or (without --apply) if anything is missing. If no positional args are provided, checks all jobs.",
+	Run: func(cmd *cobra.Command, args []string) {
+		jobNames := args
+		if len(jobNames) == 0 {
+			for jobName := range config.Jobs {
+				jobNames = append(jobNames, jobName)
+			}
+			slices.Sort(jobNames) // Sort the job names so the output is deterministic
+		}
+
+		var failed bool
+		for i, jobName := range jobNames {
+			if i != 0 {
+				fmt.Println() // Add a newline between job results
+			}
+
+			results, err := config.EnsureSchema(jobName, schemaApply)
+			if !printSchemaOutput(jobName, results, err) {
+				failed = true
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// printSchemaOutput prints the result of ensuring a job's schema and returns whether it passed:
+// nothing errored, and nothing is missing (unless it was applied)
+func printSchemaOutput(jobName string, results []sync.SchemaResult, err error) bool {
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+
+	fmt.Println(jobName + ":")
+
+	passed := true
+	for _, r := range results {
+		var status string
+		switch {
+		case r.Error != nil:
+			status = r.Error.Error()
+			passed = false
+		case r.Missing:
+			status = "missing table"
+		case len(r.MissingColumns) > 0:
+			status = "missing columns: " + strings.Join(r.MissingColumns, ", ")
+		default:
+			status = "ok"
+		}
+
+		if r.Error == nil && len(r.MissingColumns) > 0 {
+			if r.Applied {
+				status += " (created)"
+			} else {
+				passed = false
+			}
+		}
+
+		fmt.Printf("  - %s: %s\n", r.Target.Label, status)
+	}
+
+	return passed
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	gosync "sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	sync "github.com/NickDubelman/sql-table-sync"
+)
+
+var watchInterval time.Duration
+var watchListen string
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().DurationVar(
+		&watchInterval, "interval", time.Minute, "how long to wait between the start of each run",
+	)
+	watchCmd.Flags().StringVar(
+		&watchListen,
+		"listen",
+		"",
+		"serve /healthz and /metrics (for the last run) on the given address, e.g. :8080",
+	)
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [job]...",
+	Short: "Repeatedly execute the given sync jobs",
+	Long:  "Repeatedly execute the given sync jobs on an interval, printing a status line after each run. If no positional args are provided, executes all jobs.",
+	Run: func(cmd *cobra.Command, args []string) {
+		var runs []watchRun
+
+		var state watchState
+		if watchListen != "" {
+			listener, err := net.Listen("tcp", watchListen)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			go func() {
+				if err := http.Serve(listener, state.handler()); err != nil {
+					fmt.Fprintln(os.Stderr, "failed to serve:", err)
+					os.Exit(1)
+				}
+			}()
+		}
+
+		for {
+			startedAt := time.Now()
+			results, errs := execJobs(args)
+			state.record(results, errs, startedAt)
+
+			runs = append(runs, watchRun{
+				duration:    time.Since(startedAt),
+				rowsChanged: countRowsChanged(results),
+			})
+
+			// If a run takes longer than the interval, the next one starts right away
+			nextRun := startedAt.Add(watchInterval)
+			if now := time.Now(); nextRun.Before(now) {
+				nextRun = now
+			}
+
+			if !quiet {
+				fmt.Println()
+				fmt.Println(formatWatchStatus(runs, nextRun))
+				fmt.Println()
+			}
+
+			time.Sleep(time.Until(nextRun))
+		}
+	},
+}
+
+// watchRun records a single run of the watch loop
+type watchRun struct {
+	duration    time.Duration
+	rowsChanged int
+}
+
+// countRowsChanged counts the rows that were written to every target that was synced
+func countRowsChanged(results map[string]sync.ExecJobResult) int {
+	var rowsChanged int
+	for _, result := range results {
+		for _, r := range result.Results {
+			if r.Synced {
+				rowsChanged += r.DriftRows()
+			}
+		}
+	}
+	return rowsChanged
+}
+
+// formatWatchStatus formats the status line printed after each run of the watch loop
+func formatWatchStatus(runs []watchRun, nextRun time.Time) string {
+	var totalRowsChanged int
+	for _, run := range runs {
+		totalRowsChanged += run.rowsChanged
+	}
+
+	var lastDuration time.Duration
+	if len(runs) > 0 {
+		lastDuration = runs[len(runs)-1].duration
+	}
+
+	return fmt.Sprintf(
+		"[watch] runs: %d, last run took %s, next run at %s, rows changed: %d",
+		len(runs),
+		lastDuration.Round(time.Millisecond),
+		nextRun.Format(time.TimeOnly),
+		totalRowsChanged,
+	)
+}
+
+// watchState is the result of the watch loop's last run, which is served over HTTP (see --listen)
+type watchState struct {
+	mu      gosync.Mutex
+	ranAt   time.Time // When the last run started (zero until the first run finishes)
+	results map[string]sync.ExecJobResult
+	errs    map[string]error
+}
+
+// record replaces the state with the results of a run
+func (s *watchState) record(
+	results map[string]sync.ExecJobResult,
+	errs map[string]error,
+	ranAt time.Time,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.results, s.errs, s.ranAt = results, errs, ranAt
+}
+
+// watchHealth is the /healthz response body
+type watchHealth struct {
+	Status    string           `json:"status"` // "starting", "ok", or "error"
+	LastRunAt *time.Time       `json:"lastRunAt,omitempty"`
+	Jobs      []watchJobHealth `json:"jobs"`
+}
+
+type watchJobHealth struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"` // The job's error, or its first target's error
+}
+
+// handler serves /healthz, which reports whether each job's last run succeeded (and responds with
+// a 503 if any failed), and /metrics, which reports the last run's drift metrics for Prometheus
+func (s *watchState) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		health := s.health()
+
+		w.Header().Set("Content-Type", "application/json")
+		if health.Status == "error" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(health)
+	})
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		results := make(map[string]sync.CheckJobResult, len(s.results))
+		for jobName, result := range s.results {
+			results[jobName] = sync.CheckJobResult{
+				Checksum: result.Checksum,
+				Results:  driftAfterSync(result.Results),
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprint(w, sync.FormatDriftMetrics(results, s.errs, s.ranAt))
+	})
+
+	return mux
+}
+
+// driftAfterSync returns the drift that is left after a run's syncs: a target that was synced is
+// in sync with its source, and any other target (e.g. in a dry run, or one whose changes weren't
+// approved) still differs by the changes that weren't written
+func driftAfterSync(results []sync.SyncResult) []sync.SyncResult {
+	drift := slices.Clone(results)
+	for i, r := range drift {
+		if r.Synced && r.Error == nil {
+			drift[i].NumInserts, drift[i].NumUpdates, drift[i].NumDeletes = 0, 0, 0
+		}
+	}
+
+	return drift
+}
+
+// health summarizes the last run. Until the first run finishes, the status is "starting" (which is
+// healthy), so that a slow first run isn't mistaken for a failure
+func (s *watchState) health() watchHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	health := watchHealth{Status: "starting", Jobs: []watchJobHealth{}}
+	if s.ranAt.IsZero() {
+		return health
+	}
+
+	ranAt := s.ranAt
+	health.Status = "ok"
+	health.LastRunAt = &ranAt
+
+	var jobNames []string
+	for jobName := range s.results {
+		jobNames = append(jobNames, jobName)
+	}
+	slices.Sort(jobNames) // Sort the job names so the response is deterministic
+
+	for _, jobName := range jobNames {
+		job := watchJobHealth{Name: jobName, OK: true}
+
+		if err := s.errs[jobName]; err != nil {
+			job.OK, job.Error = false, err.Error()
+		} else {
+			for _, r := range s.results[jobName].Results {
+				if r.Error != nil {
+					job.OK, job.Error = false, fmt.Sprintf("%s: %s", r.Target.Label, r.Error)
+					break
+				}
+			}
+		}
+
+		if !job.OK {
+			health.Status = "error"
+		}
+		health.Jobs = append(health.Jobs, job)
+	}
+
+	return health
+}
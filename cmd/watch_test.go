@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sync "github.com/NickDubelman/sql-table-sync"
+)
+
+func TestFormatWatchStatus(t *testing.T) {
+	nextRun := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	assert.Equal(
+		t,
+		"[watch] runs: 0, last run took 0s, next run at 12:30:00, rows changed: 0",
+		formatWatchStatus(nil, nextRun),
+	)
+
+	runs := []watchRun{
+		{duration: 2 * time.Second, rowsChanged: 10},
+		{duration: 1500 * time.Millisecond, rowsChanged: 0},
+		{duration: 1234567 * time.Microsecond, rowsChanged: 5},
+	}
+
+	assert.Equal(
+		t,
+		"[watch] runs: 3, last run took 1.235s, next run at 12:30:00, rows changed: 15",
+		formatWatchStatus(runs, nextRun),
+	)
+}
+
+func TestCountRowsChanged(t *testing.T) {
+	results := map[string]sync.ExecJobResult{
+		"users": {
+			Results: []sync.SyncResult{
+				{Synced: true, NumInserts: 2, NumUpdates: 1, NumDeletes: 1},
+				{Synced: false}, // Already in sync
+			},
+		},
+		"pets": {
+			Results: []sync.SyncResult{
+				{Synced: true, NumUpdates: 3},
+				{Synced: false, NumInserts: 5}, // Failed while writing, so nothing changed
+			},
+		},
+	}
+
+	assert.Equal(t, 7, countRowsChanged(results))
+}
+
+func TestWatchState_handler(t *testing.T) {
+	var state watchState
+	server := httptest.NewServer(state.handler())
+	defer server.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	// Before the first run finishes, the daemon is healthy, but has no metrics
+	status, body := get("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"status": "starting", "jobs": []}`, body)
+
+	status, body = get("/metrics")
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotContains(t, body, "job=")
+
+	ranAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	target := sync.TableConfig{Label: "replica"}
+
+	dryRunTarget := sync.TableConfig{Label: "staging"}
+
+	state.record(
+		map[string]sync.ExecJobResult{
+			"users": {
+				Results: []sync.SyncResult{
+					{Target: target, Synced: true, NumInserts: 2},
+					{Target: dryRunTarget, NumUpdates: 3},
+				},
+			},
+		},
+		map[string]error{"users": nil},
+		ranAt,
+	)
+
+	status, body = get("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{
+		"status": "ok",
+		"lastRunAt": "2024-06-01T12:00:00Z",
+		"jobs": [{"name": "users", "ok": true}]
+	}`, body)
+
+	status, body = get("/metrics")
+	assert.Equal(t, http.StatusOK, status)
+
+	// A target that was synced is in sync, and one that wasn't written still differs
+	assert.Contains(t, body, `sql_table_sync_rows_out_of_sync{job="users",target="replica"} 0`)
+	assert.Contains(t, body, `sql_table_sync_in_sync{job="users",target="replica"} 1`)
+	assert.Contains(t, body, `sql_table_sync_rows_out_of_sync{job="users",target="staging"} 3`)
+	assert.Contains(t, body, `sql_table_sync_in_sync{job="users",target="staging"} 0`)
+	assert.Contains(
+		t, body, `sql_table_sync_last_check_timestamp_seconds{job="users",target="replica"} 1717243200`,
+	)
+
+	// A failed job or target makes the daemon unhealthy
+	state.record(
+		map[string]sync.ExecJobResult{
+			"pets": {},
+			"users": {
+				Results: []sync.SyncResult{{Target: target, Error: errors.New("connection refused")}},
+			},
+		},
+		map[string]error{"pets": errors.New("source is empty"), "users": nil},
+		ranAt,
+	)
+
+	status, body = get("/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.JSONEq(t, `{
+		"status": "error",
+		"lastRunAt": "2024-06-01T12:00:00Z",
+		"jobs": [
+			{"name": "pets", "ok": false, "error": "source is empty"},
+			{"name": "users", "ok": false, "error": "replica: connection refused"}
+		]
+	}`, body)
+
+	status, body = get("/metrics")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `sql_table_sync_check_error{job="pets"} 1`)
+	assert.Contains(t, body, `sql_table_sync_check_error{job="users",target="replica"} 1`)
+}
@@ -0,0 +1,148 @@
+package sync
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported type hints, which coerce a column's values to a consistent type
+const (
+	typeHintString = "string"
+	typeHintInt    = "int"
+	typeHintFloat  = "float"
+)
+
+// coerceRow converts the row's values (in place) to the type hinted for their column, so that the
+// source and target represent values the same way even when their column types differ
+func (t table) coerceRow(row []any) error {
+	if len(t.typeHints) == 0 {
+		return nil
+	}
+
+	for i, column := range t.columns {
+		hint, ok := t.typeHints[column]
+		if !ok {
+			continue
+		}
+
+		val, err := coerceValue(hint, row[i])
+		if err != nil {
+			return fmt.Errorf("column '%s': %w", column, err)
+		}
+		row[i] = val
+	}
+
+	return nil
+}
+
+// Supported ways of treating a column's empty strings and NULLs the same
+const (
+	emptyAsNull = "emptyAsNull" // Empty strings are converted to NULL
+	nullAsEmpty = "nullAsEmpty" // NULLs are converted to empty strings
+)
+
+// convertEmpty converts the row's empty strings to NULL, or its NULLs to empty strings (in place),
+// depending on the column. Like type hints, this applies to the values that are written too
+func (t table) convertEmpty(row []any) {
+	if len(t.emptyHandling) == 0 {
+		return
+	}
+
+	for i, column := range t.columns {
+		switch t.emptyHandling[column] {
+		case emptyAsNull:
+			if isEmptyText(row[i]) {
+				row[i] = nil
+			}
+		case nullAsEmpty:
+			// An empty []byte is also converted, so that every empty value is represented the same
+			if row[i] == nil || isEmptyText(row[i]) {
+				row[i] = ""
+			}
+		}
+	}
+}
+
+// isEmptyText returns whether the value is an empty string (or empty bytes)
+func isEmptyText(val any) bool {
+	switch v := val.(type) {
+	case string:
+		return v == ""
+	case []byte:
+		return len(v) == 0
+	}
+
+	return false
+}
+
+// normalizeTimes converts the row's times (in place) to UTC. The same instant can be read in a
+// different location from each table (see TableConfig.Location), but it should still compare,
+// checksum, and be written the same
+func normalizeTimes(row []any) {
+	for i, val := range row {
+		if t, ok := val.(time.Time); ok {
+			row[i] = t.UTC()
+		}
+	}
+}
+
+// coerceValue converts a value to the hinted type. NULL is always left as-is
+func coerceValue(hint string, val any) (any, error) {
+	if val == nil {
+		return nil, nil
+	}
+
+	// Drivers often return text as bytes
+	if bytes, ok := val.([]byte); ok {
+		val = string(bytes)
+	}
+
+	switch hint {
+	case typeHintString:
+		switch v := val.(type) {
+		case string:
+			return v, nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		default:
+			return fmt.Sprint(v), nil
+		}
+
+	case typeHintInt:
+		switch v := val.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			if v == float64(int64(v)) {
+				return int64(v), nil
+			}
+		case string:
+			if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return i, nil
+			}
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+
+	case typeHintFloat:
+		switch v := val.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("cannot coerce %v (%T) to %s", val, val, hint)
+}
@@ -0,0 +1,43 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoerceValue(t *testing.T) {
+	tests := []struct {
+		hint     string
+		value    any
+		expected any
+	}{
+		{"string", nil, nil},
+		{"string", "abc", "abc"},
+		{"string", []byte("abc"), "abc"},
+		{"string", int64(42), "42"},
+		{"string", 1.5, "1.5"},
+		{"int", nil, nil},
+		{"int", int64(42), int64(42)},
+		{"int", "42", int64(42)},
+		{"int", []byte(" 42 "), int64(42)},
+		{"int", 42.0, int64(42)},
+		{"int", true, int64(1)},
+		{"float", int64(2), 2.0},
+		{"float", "2.5", 2.5},
+		{"float", []byte("2.5"), 2.5},
+	}
+
+	for _, tt := range tests {
+		val, err := coerceValue(tt.hint, tt.value)
+		require.NoError(t, err, "%s: %v", tt.hint, tt.value)
+		assert.Equal(t, tt.expected, val, "%s: %v", tt.hint, tt.value)
+	}
+
+	_, err := coerceValue("int", 1.5)
+	assert.ErrorContains(t, err, "cannot coerce 1.5 (float64) to int")
+
+	_, err = coerceValue("float", "abc")
+	assert.ErrorContains(t, err, "cannot coerce abc (string) to float")
+}
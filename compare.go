@@ -0,0 +1,408 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// comparison configures how source and target values are compared (and checksummed)
+type comparison struct {
+	// floatTolerance is the maximum difference for two float values to be considered equal
+	floatTolerance float64
+
+	// floatColumns are columns whose values should be treated as floats, even if the driver
+	// returns them as strings or bytes
+	floatColumns map[string]struct{}
+
+	// trimTextColumns are columns whose trailing whitespace is ignored
+	trimTextColumns map[string]struct{}
+
+	// caseInsensitiveColumns are columns whose text is compared ignoring case
+	caseInsensitiveColumns map[string]struct{}
+
+	// decimalColumns are columns whose values are compared as exact decimals, regardless of how
+	// they are formatted (e.g. "10.50" and "10.5" are equal)
+	decimalColumns map[string]struct{}
+
+	// jsonColumns are columns whose values are compared as JSON, regardless of key order and
+	// whitespace (e.g. `{"a": 1, "b": 2}` and `{"b":2,"a":1}` are equal)
+	jsonColumns map[string]struct{}
+
+	// autoUpdateColumns are columns that the database updates itself (e.g. `ON UPDATE
+	// CURRENT_TIMESTAMP`), so their values are never compared (or updated)
+	autoUpdateColumns map[string]struct{}
+
+	// dataColumns are the only columns that are compared (and updated), other than the primary
+	// keys. If empty, every column is a data column
+	dataColumns map[string]struct{}
+
+	// asStrings compares (and checksums) every value by its string representation
+	asStrings bool
+}
+
+func newComparison(job JobConfig) comparison {
+	c := comparison{
+		floatTolerance:         job.FloatTolerance,
+		floatColumns:           map[string]struct{}{},
+		trimTextColumns:        map[string]struct{}{},
+		caseInsensitiveColumns: map[string]struct{}{},
+		decimalColumns:         map[string]struct{}{},
+		jsonColumns:            map[string]struct{}{},
+		autoUpdateColumns:      map[string]struct{}{},
+		dataColumns:            map[string]struct{}{},
+		asStrings:              job.CompareAsStrings,
+	}
+
+	for _, col := range job.FloatColumns {
+		c.floatColumns[col] = struct{}{}
+	}
+
+	for _, col := range job.TrimTextColumns {
+		c.trimTextColumns[col] = struct{}{}
+	}
+
+	for _, col := range job.CaseInsensitiveColumns {
+		c.caseInsensitiveColumns[col] = struct{}{}
+	}
+
+	for _, col := range job.DecimalColumns {
+		c.decimalColumns[col] = struct{}{}
+	}
+
+	for _, col := range job.JSONColumns {
+		c.jsonColumns[col] = struct{}{}
+	}
+
+	for _, col := range job.AutoUpdateColumns {
+		c.autoUpdateColumns[col] = struct{}{}
+	}
+
+	for _, col := range job.DataColumns {
+		c.dataColumns[col] = struct{}{}
+	}
+
+	return c
+}
+
+// exact returns whether values are compared exactly (i.e. there is no normalization)
+func (c comparison) exact() bool {
+	return c.floatTolerance <= 0 &&
+		len(c.trimTextColumns) == 0 &&
+		len(c.caseInsensitiveColumns) == 0 &&
+		len(c.decimalColumns) == 0 &&
+		len(c.jsonColumns) == 0 &&
+		len(c.autoUpdateColumns) == 0 &&
+		len(c.dataColumns) == 0 &&
+		!c.asStrings
+}
+
+// updates returns whether the column's values are compared and updated. Otherwise, the column is
+// only written when a row is inserted
+func (c comparison) updates(column string) bool {
+	if _, ok := c.autoUpdateColumns[column]; ok {
+		return false // The database manages the column, so it isn't expected to match
+	}
+
+	if len(c.dataColumns) > 0 {
+		_, ok := c.dataColumns[column]
+		return ok
+	}
+
+	return true
+}
+
+// changedColumns returns the columns whose values differ between two rows (with the given columns)
+func (c comparison) changedColumns(columns []string, a, b []any) []string {
+	var changed []string
+	for i, col := range columns {
+		if !c.updates(col) {
+			continue
+		}
+
+		if !c.valuesEqual(col, a[i], b[i]) {
+			changed = append(changed, col)
+		}
+	}
+
+	return changed
+}
+
+// valuesEqual returns whether two values for the given column are considered equal
+func (c comparison) valuesEqual(column string, a, b any) bool {
+	if c.floatTolerance > 0 {
+		x, xIsFloat := c.asFloat(column, a)
+		y, yIsFloat := c.asFloat(column, b)
+		if xIsFloat && yIsFloat {
+			return math.Abs(x-y) <= c.floatTolerance
+		}
+	}
+
+	a, b = c.normalizeDecimal(column, a), c.normalizeDecimal(column, b)
+	a, b = c.normalizeJSON(column, a), c.normalizeJSON(column, b)
+	a, b = c.normalizeText(column, a), c.normalizeText(column, b)
+	return reflect.DeepEqual(c.normalizeString(a), c.normalizeString(b))
+}
+
+// normalizeRow returns the row with each value in its canonical form for checksumming. If there is
+// nothing to normalize, the row is returned as-is
+func (c comparison) normalizeRow(columns []string, row []any) []any {
+	if c.exact() {
+		return row
+	}
+
+	normalized := make([]any, len(row))
+	for i, val := range row {
+		normalized[i] = c.normalizeValue(columns[i], val)
+	}
+
+	return normalized
+}
+
+func (c comparison) normalizeValue(column string, val any) any {
+	// Round floats to a multiple of the tolerance, so near-equal floats checksum the same
+	if c.floatTolerance > 0 {
+		if f, ok := c.asFloat(column, val); ok {
+			return math.Round(f/c.floatTolerance) * c.floatTolerance
+		}
+	}
+
+	val = c.normalizeJSON(column, c.normalizeDecimal(column, val))
+	return c.normalizeString(c.normalizeText(column, val))
+}
+
+// sqlTimeFormat is how times are formatted when values are compared as strings, which is how
+// databases usually format them as text (e.g. mysql's DATETIME, without parseTime)
+const sqlTimeFormat = "2006-01-02 15:04:05.999999999"
+
+// normalizeString converts a value to its string representation, if values are compared as
+// strings, so that values of different types compare and checksum the same when they are written
+// the same (e.g. 42 and "42"). NULL is left as-is, so it still differs from an empty string
+func (c comparison) normalizeString(val any) any {
+	if !c.asStrings {
+		return val
+	}
+
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return v.Format(sqlTimeFormat)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// normalizeText trims and/or lowercases a text value, depending on the column, so that text that
+// is considered equal also checksums the same
+func (c comparison) normalizeText(column string, val any) any {
+	if _, ok := c.trimTextColumns[column]; ok {
+		val = trimTrailingSpace(val)
+	}
+
+	if _, ok := c.caseInsensitiveColumns[column]; ok {
+		val = toLower(val)
+	}
+
+	return val
+}
+
+// normalizeDecimal converts a decimal column's value to its canonical string, so that decimals
+// that are formatted differently (e.g. by different drivers or column scales) compare and checksum
+// the same. Values that aren't decimals are returned as-is
+func (c comparison) normalizeDecimal(column string, val any) any {
+	if _, ok := c.decimalColumns[column]; !ok {
+		return val
+	}
+
+	var str string
+	switch v := val.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	case int64:
+		str = strconv.FormatInt(v, 10)
+	case uint64:
+		str = strconv.FormatUint(v, 10)
+	case float64:
+		str = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return val
+	}
+
+	if canonical, ok := canonicalDecimal(str); ok {
+		return canonical
+	}
+
+	return val
+}
+
+// normalizeJSON converts a JSON column's value to its canonical encoding, so that JSON that only
+// differs in key order or whitespace compares and checksums the same. Values that aren't valid JSON
+// are returned as-is
+func (c comparison) normalizeJSON(column string, val any) any {
+	if _, ok := c.jsonColumns[column]; !ok {
+		return val
+	}
+
+	var str string
+	switch v := val.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	default:
+		return val
+	}
+
+	if canonical, ok := canonicalJSON(str); ok {
+		return canonical
+	}
+
+	return val
+}
+
+// canonicalJSON re-encodes a JSON document with its object keys sorted and without insignificant
+// whitespace. Numbers are kept as they were written (e.g. 1.0 stays 1.0). It returns false if the
+// string isn't valid JSON
+func canonicalJSON(str string) (string, bool) {
+	decoder := json.NewDecoder(strings.NewReader(str))
+	decoder.UseNumber()
+
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return "", false
+	}
+
+	// Anything after the first document means the string isn't a single JSON document
+	if decoder.More() {
+		return "", false
+	}
+
+	// Maps are encoded with their keys sorted
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		return "", false
+	}
+
+	return string(canonical), true
+}
+
+// canonicalDecimal formats a decimal string (e.g. "-0012.3400") without a leading '+', leading
+// zeros, or trailing fractional zeros (e.g. "-12.34"). It returns false if the string isn't a
+// plain decimal
+func canonicalDecimal(str string) (string, bool) {
+	str = strings.TrimSpace(str)
+
+	negative := false
+	if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		negative = str[0] == '-'
+		str = str[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(str, ".")
+	if intPart == "" && fracPart == "" {
+		return "", false
+	}
+
+	isDigits := func(s string) bool {
+		return strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' }) == -1
+	}
+
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return "", false
+	}
+
+	intPart = strings.TrimLeft(intPart, "0")
+	fracPart = strings.TrimRight(fracPart, "0")
+
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	canonical := intPart
+	if fracPart != "" {
+		canonical += "." + fracPart
+	}
+
+	// Zero has no sign
+	if negative && canonical != "0" {
+		canonical = "-" + canonical
+	}
+
+	return canonical, true
+}
+
+// trimTrailingSpace trims the trailing whitespace from a text value. Other values are returned
+// as-is
+func trimTrailingSpace(val any) any {
+	switch v := val.(type) {
+	case string:
+		return strings.TrimRightFunc(v, unicode.IsSpace)
+	case []byte:
+		return bytes.TrimRightFunc(v, unicode.IsSpace)
+	}
+
+	return val
+}
+
+// toLower lowercases a text value. Other values are returned as-is
+func toLower(val any) any {
+	switch v := val.(type) {
+	case string:
+		return strings.ToLower(v)
+	case []byte:
+		return bytes.ToLower(v)
+	}
+
+	return val
+}
+
+// asFloat converts the value to a float64 if it is a float, or if the column is a float column
+func (c comparison) asFloat(column string, val any) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	}
+
+	if _, ok := c.floatColumns[column]; !ok {
+		return 0, false
+	}
+
+	var str string
+	switch v := val.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(str, 64)
+	return f, err == nil
+}
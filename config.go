@@ -1,9 +1,19 @@
 package sync
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
-
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/yaml.v3"
 )
 
@@ -14,6 +24,43 @@ type Config struct {
 
 	// Jobs maps a set of job names to their definitions
 	Jobs map[string]JobConfig
+
+	// Notify configures an optional webhook that is sent a summary after jobs are executed
+	Notify *NotifyConfig
+
+	// History is an optional table that a row is appended to for each target after jobs are
+	// executed. It is created if it doesn't exist
+	History *TableConfig
+
+	// MaxConcurrency is the maximum number of targets that ExecAllJobs syncs at once, across all
+	// jobs. When it is set, jobs are executed concurrently, and each job's targets are limited to
+	// its fair share of the limit (but at least one), so that a job with many targets can't starve
+	// the others. When it is 0, jobs are executed one at a time
+	MaxConcurrency int `yaml:"maxConcurrency"`
+
+	// JobOrder is the order that ExecAllJobs executes jobs in, e.g. so that a table is synced
+	// before the tables with foreign keys to it. The jobs that aren't in it are executed after the
+	// ones that are, in order of their names. It can't be used with MaxConcurrency, since jobs that
+	// are executed concurrently don't finish in order
+	JobOrder []string `yaml:"jobOrder"`
+
+	// AllowedDrivers restricts which drivers the config's tables (sources, targets, and the history
+	// table) may use, so that a misconfigured table can't connect to an unapproved kind of database.
+	// When it is empty, every supported driver is allowed
+	AllowedDrivers []string `yaml:"allowedDrivers"`
+
+	// MaxConnectAttemptsPerHost is the maximum number of connection attempts that are in progress
+	// at once to each database server (by its resolved host:port, or file for sqlite3), across
+	// every source and target of the jobs that are executed, so that many targets on the same
+	// server don't trip its max_connections by all connecting at once. When it is 0, there is no
+	// limit
+	MaxConnectAttemptsPerHost int `yaml:"maxConnectAttemptsPerHost"`
+
+	// Tracer is an optional OpenTelemetry tracer that spans are created with when jobs are
+	// executed: one for each job, with children for syncing its targets, reading its source, and
+	// each target (which has its own children for connecting, fetching, and writing). When it is
+	// nil, no spans are created. This is set at runtime, not in the config file
+	Tracer trace.Tracer `yaml:"-"`
 }
 
 type ConfigDefaults struct {
@@ -23,12 +70,22 @@ type ConfigDefaults struct {
 	// Hosts maps hostnames to corresponding host-specific defaults
 	Hosts map[string]HostDefaults
 
+	// HostsFile is the path to a YAML file (relative to the config file) with more hosts, in the
+	// same format as Hosts. This keeps the hosts' credentials separate from the jobs. When it is
+	// set, every host that a table refers to must be defined
+	HostsFile string `yaml:"hostsFile"`
+
 	// Source is the default source to use if a job does not specify one
 	Source *SourceTargetDefault
 
 	// Targets are the default targets to use if a job does not specify any. This can only be used
 	// if each target has the same table as the source
 	Targets []SourceTargetDefault
+
+	// DSNTemplate is a Go template (e.g. `{{.User}}@tcp({{.Host}}:{{.Port}})/{{.DB}}`) used to
+	// build the DSN for any source or target that does not specify one. It is rendered with the
+	// table's TableConfig
+	DSNTemplate string `yaml:"dsnTemplate"`
 }
 
 // JobConfig contains the configuration for a single sync job
@@ -51,6 +108,268 @@ type JobConfig struct {
 
 	// Targets is a list of configurations for the target tables (tables to sync data to)
 	Targets []TableConfig
+
+	// DryRun connects to the source and targets and computes the real diff, but does not write
+	// anything to the targets. Each target's result has the statements that would have been executed
+	// (see SyncResult.Statements)
+	DryRun bool `yaml:"dryRun"`
+
+	// RollbackDryRun writes each target's changes in a transaction that is always rolled back, so
+	// that the statements are checked against the target's real constraints (e.g. NOT NULL, UNIQUE,
+	// and foreign keys) without changing anything. A constraint violation is reported as the
+	// target's error. Constraints that are only checked on commit (e.g. deferred foreign keys) are
+	// never checked
+	RollbackDryRun bool `yaml:"rollbackDryRun"`
+
+	// Verbose records details about each target's changes in its result, such as which columns
+	// changed in each updated row
+	Verbose bool `yaml:"verbose"`
+
+	// AllowEmptySource allows syncing a source that has no rows, which deletes every target row. By
+	// default, an empty source aborts the sync with an error, since it's almost always a mistake
+	AllowEmptySource bool `yaml:"allowEmptySource"`
+
+	// MaxTargetToSourceRatio is the most rows a target can have, as a multiple of the source's rows,
+	// before its sync errors instead of deleting the rest. A target with far more rows than the
+	// source suggests that the source is a partial (or broken) extract. When it is 0, there is no
+	// limit
+	MaxTargetToSourceRatio float64 `yaml:"maxTargetToSourceRatio"`
+
+	// AttachSQLite enables a faster path for jobs where the source and a target are both sqlite3.
+	// The source database is ATTACHed to the target connection and the sync is performed with
+	// set-based statements, instead of shuttling rows through Go
+	AttachSQLite bool `yaml:"attachSqlite"`
+
+	// CheckpointFile is the path to a file where sync progress is persisted. If a sync is
+	// interrupted, the next run resumes after the last source row that was synced to each target
+	CheckpointFile string `yaml:"checkpointFile"`
+
+	// SkipUnchangedSource skips the whole job, without reading any targets, when the source's
+	// checksum matches the one from the job's last successful sync (persisted in StateFile). This
+	// assumes that the targets aren't modified by anything else, since that drift isn't detected
+	SkipUnchangedSource bool `yaml:"skipUnchangedSource"`
+
+	// StateFile is the path to a file where each job's source checksum is persisted after it is
+	// successfully synced. It is required by SkipUnchangedSource
+	StateFile string `yaml:"stateFile"`
+
+	// PartitionedChecksum also persists the checksum of each partition of the source's rows, which
+	// are bucketed by their (integer) primary key modulo PartitionCount. When the source has changed
+	// since the job's last successful sync, only the partitions whose checksums changed are read from
+	// the targets and diffed, which is much faster for large tables whose changes are localized. Like
+	// SkipUnchangedSource (which it requires), this assumes that nothing else modifies the targets
+	PartitionedChecksum bool `yaml:"partitionedChecksum"`
+
+	// PartitionCount is the number of partitions (see PartitionedChecksum). When it is 0, it is
+	// defaultPartitionCount
+	PartitionCount int `yaml:"partitionCount"`
+
+	// SkipMissingColumns syncs only the columns that each target actually has, instead of failing
+	// when a target is missing one of the job's columns (e.g. during a staged schema migration). The
+	// skipped columns are reported in each target's result. A missing primary key is still an error
+	SkipMissingColumns bool `yaml:"skipMissingColumns"`
+
+	// ReplaceMode syncs each target by deleting all of its rows and inserting all of the source's
+	// rows (in a single transaction), instead of diffing them. This is simpler and faster for small
+	// reference tables. The target's rows are never read, so every sync rewrites the whole target
+	ReplaceMode bool `yaml:"replaceMode"`
+
+	// ReplaceMaxRows is the most source rows that a job in ReplaceMode can sync, so that it isn't
+	// used on a huge table by accident. When it is 0, the limit is 10000 rows
+	ReplaceMaxRows int `yaml:"replaceMaxRows"`
+
+	// TargetTransaction also reads each target in the transaction that its changes are written in
+	// (see SyncError), so that the rows the diff is computed from can't be changed by another writer
+	// before the diff is applied. For mysql, the target's rows are read with SELECT ... FOR UPDATE,
+	// which locks them until the sync commits
+	TargetTransaction bool `yaml:"targetTransaction"`
+
+	// MaxDriftRows is the number of differing rows a target may have before CheckJob considers it
+	// to have drifted. This allows for small, expected drift (e.g. mid-replication)
+	MaxDriftRows int `yaml:"maxDriftRows"`
+
+	// FloatTolerance is the maximum difference for two float values to be considered equal. When
+	// set, floats are also rounded to a multiple of the tolerance before checksumming
+	FloatTolerance float64 `yaml:"floatTolerance"`
+
+	// FloatColumns are columns that should be compared as floats, even if the driver returns them
+	// as strings or bytes (e.g. mysql FLOAT/DOUBLE columns)
+	FloatColumns []string `yaml:"floatColumns"`
+
+	// TrimTextColumns are text columns whose trailing whitespace is ignored when comparing (and
+	// checksumming) rows. Values are still written as-is, untrimmed
+	TrimTextColumns []string `yaml:"trimTextColumns"`
+
+	// CaseInsensitiveColumns are text columns that are compared (and checksummed) ignoring case.
+	// Values are still written as-is, with their original case
+	CaseInsensitiveColumns []string `yaml:"caseInsensitiveColumns"`
+
+	// DecimalColumns are columns that are compared (and checksummed) as exact decimals, regardless
+	// of how their values are formatted (e.g. "10.50" and "10.5" are considered equal)
+	DecimalColumns []string `yaml:"decimalColumns"`
+
+	// JSONColumns are columns that are compared (and checksummed) as JSON, regardless of their key
+	// order and whitespace (e.g. `{"a": 1, "b": 2}` and `{"b":2,"a":1}` are considered equal)
+	JSONColumns []string `yaml:"jsonColumns"`
+
+	// CompareAsStrings compares (and checksums) every value by its string representation, so that
+	// values whose types differ between databases are considered equal when they are written the
+	// same (e.g. 42 and "42"). Values are still written as-is. Values that are written differently
+	// still differ (e.g. 1 and "1.0"), and primary keys are still matched by their values
+	CompareAsStrings bool `yaml:"compareAsStrings"`
+
+	// AutoUpdateColumns are columns that the database updates itself whenever a row changes (e.g.
+	// mysql's `ON UPDATE CURRENT_TIMESTAMP`). They aren't compared or checksummed, and are left out
+	// of UPDATEs (so the database can manage them), but they are still written by INSERTs
+	AutoUpdateColumns []string `yaml:"autoUpdateColumns"`
+
+	// DataColumns are the columns (other than the primary keys) that are compared, checksummed, and
+	// updated. The job's other columns are only written when a row is inserted, so they are never
+	// updated once the row exists in a target. When it is empty, every column is a data column
+	DataColumns []string `yaml:"dataColumns"`
+
+	// ChecksumColumns is a subset of Columns (including the primary keys) that the checksums are
+	// computed from. A target whose checksum matches the source's is considered in sync without
+	// being diffed, so a change that is only in other columns is missed. Once the checksums differ,
+	// every column is diffed and synced. This makes checksumming very wide tables much cheaper
+	ChecksumColumns []string `yaml:"checksumColumns"`
+
+	// SourceIndexHint is a mysql index hint (e.g. `FORCE INDEX (PRIMARY)`) that is added right after
+	// the table name when the source's rows are read, in case mysql picks a bad plan for the query.
+	// It is passed verbatim, so it must be valid SQL
+	SourceIndexHint string `yaml:"sourceIndexHint"`
+
+	// TreatEmptyAsNull are text columns whose empty strings are converted to NULL when they are read
+	// from the source and targets. An empty string and a NULL are then compared, checksummed, and
+	// written the same (as NULL). This avoids perpetual updates when a driver conflates the two
+	TreatEmptyAsNull []string `yaml:"treatEmptyAsNull"`
+
+	// TreatNullAsEmpty is the opposite of TreatEmptyAsNull: the columns' NULLs are converted to empty
+	// strings when they are read, so they are compared, checksummed, and written as empty strings
+	TreatNullAsEmpty []string `yaml:"treatNullAsEmpty"`
+
+	// TypeHints maps columns to the type ("string", "int", or "float") that their values are coerced
+	// to after being read from the source and targets. This keeps values consistent when the source
+	// and target column types differ (e.g. source INT, target VARCHAR)
+	TypeHints map[string]string `yaml:"typeHints"`
+
+	// MaxMemoryBytes is a guardrail on how much memory (estimated) a single table's rows may use
+	// when they are loaded. If a table exceeds it, the sync errors instead of risking an OOM
+	MaxMemoryBytes int64 `yaml:"maxMemoryBytes"`
+
+	// SpillThresholdRows is the number of rows a target may have before its rows are spilled to a
+	// temporary sqlite database on disk (in SpillDir) while it is diffed, instead of being held in
+	// memory. This trades speed for memory, since every source row is then looked up on disk. The
+	// source's rows are always held in memory, once for all of the targets. When it is 0, targets
+	// are never spilled
+	SpillThresholdRows int `yaml:"spillThresholdRows"`
+
+	// SpillDir is the directory that spilled rows are stored in (default: the OS's temp directory)
+	SpillDir string `yaml:"spillDir"`
+
+	// IncrementalColumn is a column (e.g. `updated_at`) that records when each source row last
+	// changed. It allows syncing only the rows that changed since a given time (see Since)
+	IncrementalColumn string `yaml:"incrementalColumn"`
+
+	// ShardColumn is an integer column (e.g. the primary key) that routes each source row to a
+	// single target, for targets that are shards of one logical table. A row is synced to the target
+	// whose index (in config order) is the column's value modulo the number of targets, and rows
+	// that belong to other shards are deleted from each target. When it is empty, every target gets
+	// every row
+	ShardColumn string `yaml:"shardColumn"`
+
+	// EncryptColumns are text columns whose values are encrypted in the tables that are marked as
+	// encrypted (see TableConfig.Encrypted). Values are encrypted deterministically, so the same
+	// value always has the same ciphertext, and an encrypted target's checksums and diffs are stable.
+	// An encrypted source's values are decrypted when they are read, and the source's values are
+	// encrypted before they are compared with (and written to) an encrypted target
+	EncryptColumns []string `yaml:"encryptColumns"`
+
+	// EncryptionKeyEnv is the environment variable that holds the key for EncryptColumns: 32 random
+	// bytes, base64-encoded (e.g. the output of `openssl rand -base64 32`)
+	EncryptionKeyEnv string `yaml:"encryptionKeyEnv"`
+
+	// MaxConcurrency is the maximum number of targets that are synced (or checked, or pinged) at
+	// once. When it is 0, every target is handled at once
+	MaxConcurrency int `yaml:"maxConcurrency"`
+
+	// DependsOn are the jobs that must be executed before this one when all jobs are executed (see
+	// Config.ExecAllJobs), e.g. because this job's table has foreign keys to theirs. If any of them
+	// fails, this job is skipped (with a DependencyError), rather than syncing against a possibly
+	// inconsistent state
+	DependsOn []string `yaml:"dependsOn"`
+
+	// PingAttempts is how many times a table is pinged before it is reported as unreachable, with
+	// an exponential backoff between attempts. When it is 0, a table is only pinged once
+	PingAttempts int `yaml:"pingAttempts"`
+
+	// BatchSize is the number of rows that are inserted per INSERT statement (a row is never split
+	// across statements). It is automatically reduced for wide tables, so that a statement never
+	// exceeds the driver's placeholder limit. When it is 0, the default of 500 rows is used. A batch
+	// size of 1 inserts each row separately, so that a failed insert names the row
+	BatchSize int `yaml:"batchSize"`
+
+	// InsertIgnore skips inserting a row whose primary key is already in the target, instead of
+	// failing the sync, e.g. when another writer inserts the same row between when the target is
+	// read and when it is written. Inserts are rendered as INSERT IGNORE for mysql (which also
+	// ignores some other errors, e.g. invalid values), and as ON CONFLICT (primary keys) DO NOTHING
+	// otherwise. A skipped row is still counted as inserted
+	InsertIgnore bool `yaml:"insertIgnore"`
+
+	// TypedScan scans each column's values into a Go type that is derived from the column's
+	// database type (which is introspected once per query), instead of whatever the driver returns
+	// for it. For example, integers are always int64 (even when mysql returns them as text, or
+	// sqlite returns a BOOLEAN as a bool), except for a BIGINT UNSIGNED that is too big for one,
+	// which is a uint64, and dates and times are always time.Time (even without mysql's
+	// parseTime). This keeps values (and checksums) consistent across drivers, as long as the
+	// source and target columns have equivalent types. Decimals are scanned as strings, so they
+	// can still be formatted differently (see DecimalColumns)
+	TypedScan bool `yaml:"typedScan"`
+
+	// Since restricts the sync to source rows whose IncrementalColumn is at or after this time.
+	// Target rows are never deleted in this mode, since the source is only a window of its rows.
+	// This is set at runtime (e.g. by the CLI's --since flag), not in the config file
+	Since time.Time `yaml:"-"`
+
+	// PrimaryKeyMin and PrimaryKeyMax restrict the sync to the rows whose primary key is in the
+	// inclusive range [PrimaryKeyMin, PrimaryKeyMax], so that a huge table can be synced by several
+	// workers in parallel, each with its own disjoint range. Both the source and the targets are
+	// only read within the range, so the target rows outside of it are untouched. Either bound can
+	// be nil, to leave that end of the range open. The job must have a single primary key whose
+	// values are comparable (e.g. integers), and the bounds should have the same type as its values.
+	// This is set at runtime (e.g. by the CLI's --pk-min and --pk-max flags), not in the config file
+	PrimaryKeyMin any `yaml:"-"`
+	PrimaryKeyMax any `yaml:"-"`
+
+	// Approve is called before writing to each target that has changes, with the changes that
+	// would be made. If it returns false, the target is skipped. This is set at runtime (e.g. by
+	// the CLI's --interactive flag), not in the config file
+	Approve func(result SyncResult) bool `yaml:"-"`
+
+	// Phases limits the sync to the given kinds of statements (e.g. PhaseInserts|PhaseDeletes), so
+	// that a staged migration can e.g. backfill inserts first, then run updates and deletes later.
+	// When it is 0, every phase is run. This is set at runtime (e.g. by the CLI's --inserts-only,
+	// --updates-only, and --deletes-only flags), not in the config file
+	Phases Phase `yaml:"-"`
+
+	// MaxTargets limits the sync to the first MaxTargets targets (in config order), so a sync can be
+	// canaried on a subset of targets before the rest. The other targets are untouched, and have no
+	// result. When it is 0, every target is synced. This is set at runtime (e.g. by the CLI's
+	// --max-targets flag), not in the config file
+	MaxTargets int `yaml:"-"`
+
+	// name is the job's name in the config, which its source state is persisted under (see
+	// SkipUnchangedSource). It is set when the job is executed
+	name string
+
+	// connectLimits limits how many connections the job's tables open to each database server at
+	// once, across every job in the run (see Config.MaxConnectAttemptsPerHost). It is set when the
+	// job is executed
+	connectLimits *connectLimiter
+
+	// tracing creates the spans of the job's execution (see Config.Tracer). It is set when the job
+	// is executed
+	tracing tracer
 }
 
 // HostDefaults contains the host-specific default config values
@@ -62,6 +381,15 @@ type HostDefaults struct {
 	Password string
 	Port     int
 	DB       string
+
+	// OptionFile is the default MySQL option file for the host's tables (see TableConfig)
+	OptionFile string `yaml:"optionFile"`
+
+	// StatementMode is the default statement mode for the host's tables (see TableConfig)
+	StatementMode string `yaml:"statementMode"`
+
+	// InitSQL is the default init SQL for the host's tables (see TableConfig)
+	InitSQL []string `yaml:"initSql"`
 }
 
 // SourceTargetDefault contains the default values for a source or target table
@@ -90,6 +418,40 @@ type TableConfig struct {
 	// DSN overrides any other connection parameters
 	DSN string
 
+	// ReadDSN and WriteDSN split a target's connection in two: its rows are read with ReadDSN (e.g.
+	// a replica), and statements are executed with WriteDSN (e.g. the primary). They must be used
+	// together, instead of any other connection parameters
+	ReadDSN  string `yaml:"readDsn"`
+	WriteDSN string `yaml:"writeDsn"`
+
+	// PrimaryKeys overrides the job's primary keys for a target that uses a different natural key.
+	// The target's rows are matched to the source's rows by these instead. They must be a subset of
+	// the job's columns, and the source's rows must be unique by them
+	PrimaryKeys []string `yaml:"primaryKeys"`
+
+	// Encrypted marks a table whose EncryptColumns (see JobConfig) are stored encrypted
+	Encrypted bool `yaml:"encrypted"`
+
+	// Location is the IANA time zone (e.g. "UTC" or "America/New_York") that the connection uses.
+	// For mysql, it is the session's time_zone, which TIMESTAMP values are read and written in. For
+	// sqlite3, it is the location that DATETIME/TIMESTAMP values are read in
+	Location string
+
+	// OptionFile is the path to a MySQL option file (e.g. "~/.my.cnf") whose [client] section the
+	// user, password, host, and port are read from when the DSN is built, so that credentials don't
+	// need to be in the config. A host or port given below takes precedence over the file's
+	OptionFile string `yaml:"optionFile"`
+
+	// StatementMode is how a target's statements are executed: "prepared" (the default) sends each
+	// statement's values separately from its SQL, which the driver may prepare on the server first.
+	// "direct" inlines the values into the SQL as escaped literals, and executes it as plain text,
+	// for connections (e.g. through a proxy) where prepared statements are expensive or unsupported
+	StatementMode string `yaml:"statementMode"`
+
+	// InitSQL are statements (e.g. `SET SESSION sql_mode = '...'`) that are executed on each of the
+	// table's connections when it is opened, so session settings apply to every pooled connection
+	InitSQL []string `yaml:"initSql"`
+
 	// If DSN is not explicitly provided, it will be inferred from the below parameters
 
 	User     string
@@ -99,14 +461,30 @@ type TableConfig struct {
 	DB       string
 }
 
+// configFile is the contents of a config file: either a single config, or a set of named profiles
+// (e.g. one per environment), each of which is a whole config on its own
+type configFile struct {
+	Config `yaml:",inline"`
+
+	// Profiles maps profile names to their configs
+	Profiles map[string]Config
+}
+
 // LoadConfig reads a config file and makes sure it is valid
 func LoadConfig(filename string) (Config, error) {
+	return LoadConfigProfile(filename, "")
+}
+
+// LoadConfigProfile reads a config file, selects the given profile's config (if the file has
+// profiles), and makes sure it is valid. The profile must be empty if the file doesn't have
+// profiles, and must be given if it does
+func LoadConfigProfile(filename, profile string) (Config, error) {
 	fileBytes, err := os.ReadFile(filename)
 	if err != nil {
 		return Config{}, err
 	}
 
-	config, err := loadConfig(string(fileBytes))
+	config, err := loadConfigFile(string(fileBytes), profile, filepath.Dir(filename))
 	if err != nil {
 		return Config{}, err
 	}
@@ -119,19 +497,62 @@ func LoadConfig(filename string) (Config, error) {
 }
 
 func loadConfig(fileContents string) (Config, error) {
-	// Unmarshal fileContents into a Config struct
-	var config Config
+	return loadConfigProfile(fileContents, "")
+}
+
+func loadConfigProfile(fileContents, profile string) (Config, error) {
+	return loadConfigFile(fileContents, profile, "")
+}
+
+// loadConfigFile parses a config file's contents and imposes defaults. dir is the config file's
+// directory, which relative paths in it are relative to
+func loadConfigFile(fileContents, profile, dir string) (Config, error) {
+	// Decode fileContents into a configFile struct. Unknown fields are rejected so that typos (like
+	// `primarykey` instead of `primaryKey`) are surfaced instead of being silently ignored
+	var file configFile
+
+	decoder := yaml.NewDecoder(strings.NewReader(fileContents))
+	decoder.KnownFields(true)
 
-	if err := yaml.Unmarshal([]byte(fileContents), &config); err != nil {
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
 		return Config{}, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	config, err := file.selectProfile(profile)
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.Defaults, err = config.Defaults.withHostsFile(dir)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if err := config.checkHostsExist(); err != nil {
+		return Config{}, err
+	}
+
+	var dsnTemplate *template.Template
+	if config.Defaults.DSNTemplate != "" {
+		var err error
+		dsnTemplate, err = template.New("dsn").Parse(config.Defaults.DSNTemplate)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to parse dsnTemplate: %w", err)
+		}
+	}
+
+	if config.History != nil {
+		history := imposeTableDefaults(*config.History, config.Defaults)
+		config.History = &history
+	}
+
 	// Impose some default values
 	for jobName := range config.Jobs {
 		job := config.Jobs[jobName]
 
 		// For each job, if PrimaryKey is empty, set it to "id"
-		if job.PrimaryKey == "" && len(job.PrimaryKeys) == 0 {
+		hasPrimaryKey := job.PrimaryKey != "" || len(job.PrimaryKeys) > 0
+		if !hasPrimaryKey {
 			job.PrimaryKey = "id"
 		}
 
@@ -203,9 +624,9 @@ func loadConfig(fileContents string) (Config, error) {
 
 			sourceHasDSN := job.Source.DSN != ""
 			sourceHasHost := job.Source.Host != ""
-			targetHasDSN := job.Targets[j].DSN != ""
+			targetHasDSN := job.Targets[j].primaryDSN() != ""
 			targetHasHost := job.Targets[j].Host != ""
-			hasDifferentDSN := job.Source.DSN != job.Targets[j].DSN
+			hasDifferentDSN := job.Source.DSN != job.Targets[j].primaryDSN()
 			hasDifferentHost := job.Source.Host != job.Targets[j].Host
 
 			if sourceHasDSN && targetHasDSN && hasDifferentDSN {
@@ -223,29 +644,263 @@ func loadConfig(fileContents string) (Config, error) {
 			}
 		}
 
+		// Render the DSN template for the source and any targets that don't specify a DSN
+		if dsnTemplate != nil {
+			var err error
+			job.Source, err = renderDSN(dsnTemplate, job.Source)
+			if err != nil {
+				return Config{}, fmt.Errorf("job '%s': source: %w", jobName, err)
+			}
+
+			for j := range job.Targets {
+				job.Targets[j], err = renderDSN(dsnTemplate, job.Targets[j])
+				if err != nil {
+					return Config{}, fmt.Errorf("job '%s': target[%d]: %w", jobName, j, err)
+				}
+			}
+		}
+
+		// If no primary key was given and there is no "id" column, sqlite tables can fall back to
+		// their implicit rowid (which is only meaningful if every table is sqlite)
+		if !hasPrimaryKey && !slices.Contains(job.Columns, "id") && job.allSQLite() {
+			job.PrimaryKey = rowIDColumn
+			job.PrimaryKeys = []string{rowIDColumn}
+		}
+
 		config.Jobs[jobName] = job // Update the map
 	}
 
 	return config, nil
 }
 
+// selectProfile returns the given profile's config. If the file doesn't have profiles, its config
+// is returned (and the profile must be empty)
+func (f configFile) selectProfile(profile string) (Config, error) {
+	if len(f.Profiles) == 0 {
+		if profile != "" {
+			return Config{}, fmt.Errorf("profile '%s' not found in config (it has no profiles)", profile)
+		}
+
+		return f.Config, nil
+	}
+
+	// Each profile is isolated, so nothing can be shared outside of them
+	if !reflect.ValueOf(f.Config).IsZero() {
+		return Config{}, fmt.Errorf(
+			"config with profiles cannot also have top-level jobs, defaults, or other settings " +
+				"(they must be in the profiles)",
+		)
+	}
+
+	names := sortedKeys(f.Profiles)
+
+	if profile == "" {
+		return Config{}, fmt.Errorf(
+			"config has profiles, so one must be selected: %s", strings.Join(names, ", "),
+		)
+	}
+
+	config, ok := f.Profiles[profile]
+	if !ok {
+		return Config{}, fmt.Errorf(
+			"profile '%s' not found in config (it has: %s)", profile, strings.Join(names, ", "),
+		)
+	}
+
+	return config, nil
+}
+
 func (c Config) validate() error {
 	// Make sure there is at least one job
 	if len(c.Jobs) == 0 {
 		return fmt.Errorf("no jobs found in config")
 	}
 
-	for name, job := range c.Jobs {
+	if c.Notify != nil && c.Notify.URL == "" {
+		return fmt.Errorf("notify does not specify a url")
+	}
+
+	if c.History != nil {
+		if err := c.History.validate(); err != nil {
+			return fmt.Errorf("history: %w", err)
+		}
+	}
+
+	if c.MaxConcurrency < 0 {
+		return fmt.Errorf("maxConcurrency cannot be negative")
+	}
+
+	if c.MaxConnectAttemptsPerHost < 0 {
+		return fmt.Errorf("maxConnectAttemptsPerHost cannot be negative")
+	}
+
+	// Jobs that are executed concurrently would overwrite each other's checkpoints
+	if c.MaxConcurrency > 0 {
+		names := sortedKeys(c.Jobs) // So the error is deterministic
+
+		checkpointJobs := map[string]string{}
+		for _, name := range names {
+			filename := c.Jobs[name].CheckpointFile
+			if filename == "" {
+				continue
+			}
+
+			if other, ok := checkpointJobs[filename]; ok {
+				return fmt.Errorf(
+					"jobs '%s' and '%s' can't share a checkpointFile when maxConcurrency is set",
+					other, name,
+				)
+			}
+			checkpointJobs[filename] = name
+		}
+	}
+
+	// Make sure jobOrder only has jobs that exist, and each of them once
+	seen := map[string]bool{}
+	for _, name := range c.JobOrder {
+		if _, ok := c.Jobs[name]; !ok {
+			return fmt.Errorf("jobOrder has job '%s', which is not in jobs", name)
+		}
+
+		if seen[name] {
+			return fmt.Errorf("jobOrder has job '%s' more than once", name)
+		}
+		seen[name] = true
+	}
+
+	if len(c.JobOrder) > 0 && c.MaxConcurrency > 0 {
+		return fmt.Errorf("jobOrder can't be used with maxConcurrency, since concurrent jobs " +
+			"don't execute in order")
+	}
+
+	if err := c.checkDependencies(); err != nil {
+		return err
+	}
+
+	for _, name := range sortedKeys(c.Jobs) { // So the error is deterministic
 		// Make sure every job has a non-empty name
 		if name == "" {
 			return fmt.Errorf("all jobs must have a name")
 		}
 
-		if err := job.validate(); err != nil {
+		if err := c.Jobs[name].validate(); err != nil {
 			return fmt.Errorf("job '%s': %w", name, err)
 		}
 	}
 
+	if err := c.checkAllowedDrivers(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkDependencies makes sure that every job only depends on jobs that exist, that there are no
+// dependency cycles, and that jobOrder doesn't put a job before one that it depends on
+func (c Config) checkDependencies() error {
+	names := sortedKeys(c.Jobs) // So the error is deterministic
+
+	for _, name := range names {
+		for _, dependency := range c.Jobs[name].DependsOn {
+			if _, ok := c.Jobs[dependency]; !ok {
+				return fmt.Errorf(
+					"job '%s': depends on job '%s', which is not in jobs", name, dependency,
+				)
+			}
+
+			// If both are in jobOrder, it has to be consistent with the dependency
+			order := slices.Index(c.JobOrder, name)
+			if order != -1 && slices.Index(c.JobOrder, dependency) > order {
+				return fmt.Errorf(
+					"jobOrder has job '%s' before job '%s', which it depends on", name, dependency,
+				)
+			}
+		}
+	}
+
+	// Find cycles with a depth-first search, where path is the jobs that are being visited
+	visited := map[string]bool{}
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if i := slices.Index(path, name); i != -1 {
+			cycle := append(slices.Clone(path[i:]), name)
+			return fmt.Errorf("jobs have a dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		if visited[name] {
+			return nil
+		}
+
+		path = append(path, name)
+		for _, dependency := range c.Jobs[name].DependsOn {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+
+		visited[name] = true
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkAllowedDrivers makes sure that every table uses one of the allowed drivers (if any are set)
+func (c Config) checkAllowedDrivers() error {
+	if len(c.AllowedDrivers) == 0 {
+		return nil
+	}
+
+	for _, driver := range c.AllowedDrivers {
+		if driver != "mysql" && driver != "sqlite3" && driver != noopDriver {
+			return fmt.Errorf("allowedDrivers has unsupported driver '%s'", driver)
+		}
+	}
+
+	check := func(context string, table TableConfig) error {
+		if !slices.Contains(c.AllowedDrivers, table.Driver) {
+			return fmt.Errorf(
+				"%s uses driver '%s', which is not in allowedDrivers %v",
+				context, table.Driver, c.AllowedDrivers,
+			)
+		}
+		return nil
+	}
+
+	if c.History != nil {
+		if err := check("history", *c.History); err != nil {
+			return err
+		}
+	}
+
+	// Iterate over the jobs in order, so the error is deterministic
+	for _, name := range sortedKeys(c.Jobs) {
+		job := c.Jobs[name]
+		if err := check(fmt.Sprintf("job '%s': source", name), job.Source); err != nil {
+			return err
+		}
+
+		for i, target := range job.Targets {
+			context := fmt.Sprintf("job '%s': target[%d]", name, i)
+			if target.Label != "" {
+				context = fmt.Sprintf(`job '%s': "%s"`, name, target.Label)
+			}
+
+			if err := check(context, target); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -260,13 +915,89 @@ func (cfg JobConfig) validate() error {
 		return fmt.Errorf("has too many primary keys")
 	}
 
+	if cfg.MaxDriftRows < 0 {
+		return fmt.Errorf("maxDriftRows cannot be negative")
+	}
+
+	if cfg.FloatTolerance < 0 {
+		return fmt.Errorf("floatTolerance cannot be negative")
+	}
+
+	// Floats that are within the tolerance are rounded to the same float, not the same string
+	if cfg.CompareAsStrings && cfg.FloatTolerance > 0 {
+		return fmt.Errorf("cannot use both compareAsStrings and floatTolerance")
+	}
+
+	if cfg.MaxMemoryBytes < 0 {
+		return fmt.Errorf("maxMemoryBytes cannot be negative")
+	}
+
+	if cfg.SpillThresholdRows < 0 {
+		return fmt.Errorf("spillThresholdRows cannot be negative")
+	}
+
+	if cfg.MaxConcurrency < 0 {
+		return fmt.Errorf("maxConcurrency cannot be negative")
+	}
+
+	if cfg.PingAttempts < 0 {
+		return fmt.Errorf("pingAttempts cannot be negative")
+	}
+
+	if cfg.BatchSize < 0 {
+		return fmt.Errorf("batchSize cannot be negative")
+	}
+
+	// A checkpoint records progress that has been written, but a transaction's writes are rolled
+	// back if the sync fails
+	if cfg.TargetTransaction && cfg.CheckpointFile != "" {
+		return fmt.Errorf("cannot use both targetTransaction and checkpointFile")
+	}
+
+	if cfg.SkipUnchangedSource && cfg.StateFile == "" {
+		return fmt.Errorf("skipUnchangedSource requires a stateFile")
+	}
+
+	if cfg.MaxTargetToSourceRatio < 0 {
+		return fmt.Errorf("maxTargetToSourceRatio cannot be negative")
+	}
+
+	if cfg.ReplaceMaxRows < 0 {
+		return fmt.Errorf("replaceMaxRows cannot be negative")
+	}
+
+	// A target is replaced all at once, so there is no progress to checkpoint
+	if cfg.ReplaceMode && cfg.CheckpointFile != "" {
+		return fmt.Errorf("cannot use both replaceMode and checkpointFile")
+	}
+
+	if err := cfg.validatePartitions(); err != nil {
+		return err
+	}
+
 	// Make sure columns is non-empty
 	if len(cfg.Columns) == 0 {
 		return fmt.Errorf("does not specify any columns")
 	}
 
-	// Make sure primaryKeys is a subset of columns
+	// Make sure columns are explicit (we never want to `SELECT *`)
+	for _, column := range cfg.Columns {
+		if column == "" || column == "*" {
+			return fmt.Errorf("has invalid column '%s'", column)
+		}
+	}
+
+	// rowid doesn't need to be in columns, but it isn't portable across databases
+	if cfg.usesRowID() && !cfg.allSQLite() {
+		return fmt.Errorf("can only use rowid as a primary key if every table is sqlite3")
+	}
+
+	// Make sure primaryKeys is a subset of columns (unless we're using sqlite's implicit rowid)
 	for _, key := range cfg.PrimaryKeys {
+		if key == rowIDColumn && cfg.usesRowID() {
+			continue
+		}
+
 		found := false
 		for _, column := range cfg.Columns {
 			if key == column {
@@ -280,6 +1011,226 @@ func (cfg JobConfig) validate() error {
 		}
 	}
 
+	// Make sure floatColumns is a subset of columns
+	for _, floatColumn := range cfg.FloatColumns {
+		if !slices.Contains(cfg.Columns, floatColumn) {
+			return fmt.Errorf("has float column '%s' not in columns", floatColumn)
+		}
+	}
+
+	// Make sure trimTextColumns is a subset of columns. Primary keys can't be trimmed, since rows are
+	// matched by their exact primary key
+	for _, trimColumn := range cfg.TrimTextColumns {
+		if !slices.Contains(cfg.Columns, trimColumn) {
+			return fmt.Errorf("has trim text column '%s' not in columns", trimColumn)
+		}
+
+		if slices.Contains(cfg.PrimaryKeys, trimColumn) {
+			return fmt.Errorf("cannot trim primary key column '%s'", trimColumn)
+		}
+	}
+
+	// Make sure caseInsensitiveColumns is a subset of columns, and doesn't include primary keys
+	for _, column := range cfg.CaseInsensitiveColumns {
+		if !slices.Contains(cfg.Columns, column) {
+			return fmt.Errorf("has case insensitive column '%s' not in columns", column)
+		}
+
+		if slices.Contains(cfg.PrimaryKeys, column) {
+			return fmt.Errorf("primary key column '%s' cannot be case insensitive", column)
+		}
+	}
+
+	// Make sure decimalColumns is a subset of columns, and doesn't include primary keys or float
+	// columns (which are compared approximately)
+	for _, column := range cfg.DecimalColumns {
+		if !slices.Contains(cfg.Columns, column) {
+			return fmt.Errorf("has decimal column '%s' not in columns", column)
+		}
+
+		if slices.Contains(cfg.PrimaryKeys, column) {
+			return fmt.Errorf("primary key column '%s' cannot be a decimal column", column)
+		}
+
+		if slices.Contains(cfg.FloatColumns, column) {
+			return fmt.Errorf("column '%s' cannot be both a float and a decimal column", column)
+		}
+	}
+
+	// Make sure jsonColumns is a subset of columns, and doesn't include primary keys or columns that
+	// are already normalized as numbers
+	for _, column := range cfg.JSONColumns {
+		if !slices.Contains(cfg.Columns, column) {
+			return fmt.Errorf("has json column '%s' not in columns", column)
+		}
+
+		if slices.Contains(cfg.PrimaryKeys, column) {
+			return fmt.Errorf("primary key column '%s' cannot be a json column", column)
+		}
+
+		if slices.Contains(cfg.FloatColumns, column) || slices.Contains(cfg.DecimalColumns, column) {
+			return fmt.Errorf("column '%s' cannot be both a number and a json column", column)
+		}
+	}
+
+	// Make sure autoUpdateColumns is a subset of columns, and doesn't include primary keys or
+	// checksum columns (since they are never compared)
+	for _, column := range cfg.AutoUpdateColumns {
+		if !slices.Contains(cfg.Columns, column) {
+			return fmt.Errorf("has auto-update column '%s' not in columns", column)
+		}
+
+		if slices.Contains(cfg.PrimaryKeys, column) {
+			return fmt.Errorf("primary key column '%s' cannot be an auto-update column", column)
+		}
+
+		if slices.Contains(cfg.ChecksumColumns, column) {
+			return fmt.Errorf("column '%s' cannot be both a checksum and an auto-update column", column)
+		}
+	}
+
+	// Make sure dataColumns is a subset of columns, and doesn't include primary keys (which are
+	// matched, not updated) or auto-update columns (which the database updates itself)
+	for _, column := range cfg.DataColumns {
+		if !slices.Contains(cfg.Columns, column) {
+			return fmt.Errorf("has data column '%s' not in columns", column)
+		}
+
+		if slices.Contains(cfg.PrimaryKeys, column) {
+			return fmt.Errorf("primary key column '%s' cannot be a data column", column)
+		}
+
+		for _, target := range cfg.Targets {
+			if slices.Contains(target.PrimaryKeys, column) {
+				return fmt.Errorf("primary key column '%s' cannot be a data column", column)
+			}
+		}
+
+		if slices.Contains(cfg.AutoUpdateColumns, column) {
+			return fmt.Errorf("column '%s' cannot be both a data and an auto-update column", column)
+		}
+	}
+
+	// Columns other than the data columns are never compared, so they can't be checksummed
+	if len(cfg.DataColumns) > 0 {
+		for _, column := range cfg.ChecksumColumns {
+			if !slices.Contains(cfg.DataColumns, column) && !slices.Contains(cfg.PrimaryKeys, column) {
+				return fmt.Errorf("checksum column '%s' must be a data column", column)
+			}
+		}
+	}
+
+	// Make sure checksumColumns is a subset of columns, and includes the primary keys (so that rows
+	// with different keys never checksum the same)
+	for _, column := range cfg.ChecksumColumns {
+		if !slices.Contains(cfg.Columns, column) {
+			return fmt.Errorf("has checksum column '%s' not in columns", column)
+		}
+	}
+
+	if len(cfg.ChecksumColumns) > 0 {
+		for _, key := range cfg.PrimaryKeys {
+			if key == rowIDColumn && cfg.usesRowID() {
+				continue // The rowid is always checksummed
+			}
+
+			if !slices.Contains(cfg.ChecksumColumns, key) {
+				return fmt.Errorf("checksumColumns must include primary key '%s'", key)
+			}
+		}
+	}
+
+	// Make sure treatEmptyAsNull and treatNullAsEmpty are subsets of columns, and don't include
+	// primary keys (which are matched exactly) or each other's columns
+	for _, column := range cfg.TreatEmptyAsNull {
+		if !slices.Contains(cfg.Columns, column) {
+			return fmt.Errorf("has treatEmptyAsNull column '%s' not in columns", column)
+		}
+
+		if slices.Contains(cfg.TreatNullAsEmpty, column) {
+			return fmt.Errorf(
+				"column '%s' cannot be in both treatEmptyAsNull and treatNullAsEmpty", column,
+			)
+		}
+	}
+
+	for _, column := range cfg.TreatNullAsEmpty {
+		if !slices.Contains(cfg.Columns, column) {
+			return fmt.Errorf("has treatNullAsEmpty column '%s' not in columns", column)
+		}
+	}
+
+	for _, column := range slices.Concat(cfg.TreatEmptyAsNull, cfg.TreatNullAsEmpty) {
+		if slices.Contains(cfg.PrimaryKeys, column) {
+			return fmt.Errorf("primary key column '%s' cannot convert empty strings or NULLs", column)
+		}
+	}
+
+	// Make sure each type hint is for a column, and is a supported type
+	for column, hint := range cfg.TypeHints {
+		if !slices.Contains(cfg.Columns, column) {
+			return fmt.Errorf("has type hint for column '%s' not in columns", column)
+		}
+
+		if hint != typeHintString && hint != typeHintInt && hint != typeHintFloat {
+			return fmt.Errorf("has invalid type hint '%s' for column '%s'", hint, column)
+		}
+	}
+
+	// Rows are routed by their shard column's value, so it must be read
+	if cfg.ShardColumn != "" && !slices.Contains(cfg.Columns, cfg.ShardColumn) {
+		return fmt.Errorf("has shard column '%s' not in columns", cfg.ShardColumn)
+	}
+
+	// Make sure encryptColumns is a subset of columns, and doesn't include columns that are matched
+	// or normalized by their values, since only their encrypted values are compared
+	for _, column := range cfg.EncryptColumns {
+		if !slices.Contains(cfg.Columns, column) {
+			return fmt.Errorf("has encrypt column '%s' not in columns", column)
+		}
+
+		if slices.Contains(cfg.PrimaryKeys, column) {
+			return fmt.Errorf("primary key column '%s' cannot be encrypted", column)
+		}
+
+		for _, target := range cfg.Targets {
+			if slices.Contains(target.PrimaryKeys, column) {
+				return fmt.Errorf("primary key column '%s' cannot be encrypted", column)
+			}
+		}
+
+		_, hasTypeHint := cfg.TypeHints[column]
+		if slices.Contains(cfg.FloatColumns, column) ||
+			slices.Contains(cfg.TrimTextColumns, column) ||
+			slices.Contains(cfg.CaseInsensitiveColumns, column) ||
+			slices.Contains(cfg.DecimalColumns, column) ||
+			slices.Contains(cfg.JSONColumns, column) || hasTypeHint {
+			return fmt.Errorf("column '%s' cannot be both normalized and encrypted", column)
+		}
+
+		if column == cfg.ShardColumn || column == cfg.IncrementalColumn {
+			return fmt.Errorf(
+				"column '%s' cannot be encrypted, since rows are filtered or routed by it", column,
+			)
+		}
+	}
+
+	if len(cfg.EncryptColumns) > 0 && cfg.EncryptionKeyEnv == "" {
+		return fmt.Errorf("encryptColumns requires encryptionKeyEnv")
+	}
+
+	if len(cfg.EncryptColumns) == 0 {
+		for i, table := range slices.Concat([]TableConfig{cfg.Source}, cfg.Targets) {
+			if table.Encrypted {
+				label := "source"
+				if i > 0 {
+					label = fmt.Sprintf("target[%d]", i-1)
+				}
+				return fmt.Errorf("%s is encrypted, but the job has no encryptColumns", label)
+			}
+		}
+	}
+
 	// Make sure every job has a non-empty source table
 	if err := cfg.Source.validate(); err != nil {
 		label := "source"
@@ -289,25 +1240,211 @@ func (cfg JobConfig) validate() error {
 		return fmt.Errorf("%s: %w", label, err)
 	}
 
+	// The noop driver only makes sense for targets, since it has no data
+	if cfg.Source.Driver == noopDriver {
+		return fmt.Errorf("source cannot use the noop driver")
+	}
+
+	// The source is only ever read from, so there is nothing to split
+	if cfg.Source.ReadDSN != "" || cfg.Source.WriteDSN != "" {
+		return fmt.Errorf("source cannot use readDsn/writeDsn")
+	}
+
+	// Index hints are mysql syntax
+	if cfg.SourceIndexHint != "" && cfg.Source.Driver != "mysql" {
+		return fmt.Errorf("sourceIndexHint can only be used with a mysql source")
+	}
+
+	// The source's rows are keyed by the job's primary keys
+	if len(cfg.Source.PrimaryKeys) > 0 {
+		return fmt.Errorf("source cannot override primaryKeys")
+	}
+
 	// Make sure every job has at least one target
 	if len(cfg.Targets) == 0 {
 		return fmt.Errorf("has no targets")
 	}
 
+	labels := make(map[string]int, len(cfg.Targets))
+
 	for i, target := range cfg.Targets {
 		label := fmt.Sprintf("target[%d]", i)
 		if target.Label != "" {
 			label = fmt.Sprintf(`"%s"`, target.Label)
+
+			// Results (and the CLI's output) identify targets by their labels
+			if j, ok := labels[target.Label]; ok {
+				return fmt.Errorf(
+					"target[%d] and target[%d] have the same label %s (give them unique labels)",
+					j, i, label,
+				)
+			}
+			labels[target.Label] = i
 		}
 
 		if err := target.validate(); err != nil {
 			return fmt.Errorf("%s: %w", label, err)
 		}
+
+		// Syncing a table to itself is at best pointless, so it's most likely a misconfiguration
+		if target.sameTable(cfg.Source) {
+			return fmt.Errorf("%s: is the same table as the source", label)
+		}
+
+		if err := cfg.validateTargetPrimaryKeys(target.PrimaryKeys); err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+	}
+
+	return nil
+}
+
+// validatePartitions makes sure that a job with a partitioned checksum can read only some of its
+// targets' partitions. Each partition is selected by the primary key, so there must be exactly one,
+// and the targets can't have their own. A target that is replaced, sharded, or checkpointed needs
+// all of the source's rows
+func (cfg JobConfig) validatePartitions() error {
+	if cfg.PartitionCount < 0 {
+		return fmt.Errorf("partitionCount cannot be negative")
+	}
+
+	if !cfg.PartitionedChecksum {
+		if cfg.PartitionCount > 0 {
+			return fmt.Errorf("partitionCount requires partitionedChecksum")
+		}
+		return nil
+	}
+
+	if !cfg.SkipUnchangedSource {
+		return fmt.Errorf("partitionedChecksum requires skipUnchangedSource")
+	}
+
+	if len(cfg.PrimaryKeys) != 1 {
+		return fmt.Errorf("partitionedChecksum requires a single primary key")
+	}
+
+	for i, target := range cfg.Targets {
+		if len(target.PrimaryKeys) > 0 {
+			return fmt.Errorf(
+				"target[%d] has its own primaryKeys, so the job can't use partitionedChecksum", i,
+			)
+		}
+	}
+
+	if cfg.ReplaceMode {
+		return fmt.Errorf("cannot use both partitionedChecksum and replaceMode")
+	}
+
+	if cfg.ShardColumn != "" {
+		return fmt.Errorf("cannot use both partitionedChecksum and shardColumn")
+	}
+
+	if cfg.CheckpointFile != "" {
+		return fmt.Errorf("cannot use both partitionedChecksum and checkpointFile")
+	}
+
+	return nil
+}
+
+// validateTargetPrimaryKeys makes sure a target's own primary keys (if it has them) can be used to
+// match rows, just like the job's primary keys
+func (cfg JobConfig) validateTargetPrimaryKeys(primaryKeys []string) error {
+	if len(primaryKeys) > 3 {
+		return fmt.Errorf("has too many primary keys")
+	}
+
+	for _, key := range primaryKeys {
+		if !slices.Contains(cfg.Columns, key) {
+			return fmt.Errorf("has primary key '%s' not in columns", key)
+		}
+
+		// Rows are matched by their exact primary key, so it can't be normalized
+		if slices.Contains(cfg.TrimTextColumns, key) ||
+			slices.Contains(cfg.CaseInsensitiveColumns, key) ||
+			slices.Contains(cfg.DecimalColumns, key) ||
+			slices.Contains(cfg.JSONColumns, key) ||
+			slices.Contains(cfg.AutoUpdateColumns, key) {
+			return fmt.Errorf("primary key column '%s' cannot be normalized for comparison", key)
+		}
 	}
 
 	return nil
 }
 
+// WithColumns returns a copy of the job that only syncs the given columns (in the job's column
+// order), e.g. to temporarily exclude a problematic column from an ad-hoc sync. Each column must be
+// one of the job's columns, and every primary key (including the targets') must be included.
+// Column-specific settings (e.g. floatColumns) are dropped for the excluded columns
+func (cfg JobConfig) WithColumns(columns []string) (JobConfig, error) {
+	if len(columns) == 0 {
+		return JobConfig{}, fmt.Errorf("must include at least one column")
+	}
+
+	for _, column := range columns {
+		if !slices.Contains(cfg.Columns, column) {
+			return JobConfig{}, fmt.Errorf("column '%s' is not one of the job's columns", column)
+		}
+	}
+
+	primaryKeys := slices.Clone(cfg.PrimaryKeys)
+	if cfg.PrimaryKey != "" {
+		primaryKeys = append(primaryKeys, cfg.PrimaryKey)
+	}
+	for _, target := range cfg.Targets {
+		primaryKeys = append(primaryKeys, target.PrimaryKeys...)
+	}
+
+	for _, key := range primaryKeys {
+		if key == rowIDColumn && cfg.usesRowID() {
+			continue
+		}
+
+		if !slices.Contains(columns, key) {
+			return JobConfig{}, fmt.Errorf("columns must include primary key '%s'", key)
+		}
+	}
+
+	keep := func(names []string) []string {
+		var kept []string
+		for _, name := range names {
+			if slices.Contains(columns, name) {
+				kept = append(kept, name)
+			}
+		}
+		return kept
+	}
+
+	// Without any data columns, every column would be updated instead of none
+	if len(cfg.DataColumns) > 0 && len(keep(cfg.DataColumns)) == 0 {
+		return JobConfig{}, fmt.Errorf("columns must include at least one of the job's dataColumns")
+	}
+
+	cfg.Columns = keep(cfg.Columns)
+	cfg.FloatColumns = keep(cfg.FloatColumns)
+	cfg.TrimTextColumns = keep(cfg.TrimTextColumns)
+	cfg.CaseInsensitiveColumns = keep(cfg.CaseInsensitiveColumns)
+	cfg.DecimalColumns = keep(cfg.DecimalColumns)
+	cfg.JSONColumns = keep(cfg.JSONColumns)
+	cfg.AutoUpdateColumns = keep(cfg.AutoUpdateColumns)
+	cfg.ChecksumColumns = keep(cfg.ChecksumColumns)
+	cfg.DataColumns = keep(cfg.DataColumns)
+	cfg.EncryptColumns = keep(cfg.EncryptColumns)
+	cfg.TreatEmptyAsNull = keep(cfg.TreatEmptyAsNull)
+	cfg.TreatNullAsEmpty = keep(cfg.TreatNullAsEmpty)
+
+	if cfg.TypeHints != nil {
+		typeHints := make(map[string]string, len(cfg.TypeHints))
+		for column, hint := range cfg.TypeHints {
+			if slices.Contains(columns, column) {
+				typeHints[column] = hint
+			}
+		}
+		cfg.TypeHints = typeHints
+	}
+
+	return cfg, nil
+}
+
 func (cfg TableConfig) validate() error {
 	if cfg.Table == "" {
 		return fmt.Errorf("table name is empty")
@@ -318,6 +1455,13 @@ func (cfg TableConfig) validate() error {
 		return fmt.Errorf("table does not specify a driver")
 	}
 
+	// For the noop driver, the DSN selects its mode
+	if cfg.Driver == noopDriver {
+		if cfg.DSN != "" && cfg.DSN != noopModeInSync && cfg.DSN != noopModeEmpty {
+			return fmt.Errorf("noop table has invalid mode '%s'", cfg.DSN)
+		}
+	}
+
 	// If DSN is given, make sure it is the only connection parameter
 	if cfg.DSN != "" {
 		if cfg.User != "" || cfg.Password != "" || cfg.Host != "" || cfg.Port != 0 || cfg.DB != "" {
@@ -325,9 +1469,170 @@ func (cfg TableConfig) validate() error {
 		}
 	}
 
+	// If readDsn and writeDsn are given, make sure they are the only connection parameters
+	if cfg.ReadDSN != "" || cfg.WriteDSN != "" {
+		if cfg.ReadDSN == "" || cfg.WriteDSN == "" {
+			return fmt.Errorf("table must specify both readDsn and writeDsn")
+		}
+
+		if cfg.DSN != "" || cfg.User != "" || cfg.Password != "" || cfg.Host != "" ||
+			cfg.Port != 0 || cfg.DB != "" {
+			return fmt.Errorf("table cannot specify readDsn/writeDsn and other connection parameters")
+		}
+	}
+
+	// Catch a malformed DSN now, rather than when the table is connected. Only mysql's DSNs can be
+	// parsed, and the DSN itself isn't in the error, since it may contain a password
+	if cfg.Driver == "mysql" {
+		dsns := []struct{ field, dsn string }{
+			{"dsn", cfg.DSN},
+			{"readDsn", cfg.ReadDSN},
+			{"writeDsn", cfg.WriteDSN},
+		}
+
+		for _, d := range dsns {
+			if d.dsn == "" {
+				continue
+			}
+
+			if _, err := mysql.ParseDSN(d.dsn); err != nil {
+				return fmt.Errorf("table has malformed %s: %w", d.field, err)
+			}
+		}
+	}
+
+	// If optionFile is given, make sure the credentials only come from it
+	if cfg.OptionFile != "" {
+		if cfg.Driver != "mysql" {
+			return fmt.Errorf("only mysql tables can use an optionFile")
+		}
+
+		if cfg.DSN != "" || cfg.ReadDSN != "" || cfg.WriteDSN != "" || cfg.User != "" ||
+			cfg.Password != "" {
+			return fmt.Errorf("table cannot specify optionFile and a DSN or inline credentials")
+		}
+	}
+
+	for _, statement := range cfg.InitSQL {
+		if strings.TrimSpace(statement) == "" {
+			return fmt.Errorf("table has an empty initSql statement")
+		}
+	}
+
+	if cfg.StatementMode != "" && cfg.StatementMode != statementModePrepared &&
+		cfg.StatementMode != statementModeDirect {
+		return fmt.Errorf(
+			"table has invalid statementMode '%s' (must be '%s' or '%s')",
+			cfg.StatementMode, statementModePrepared, statementModeDirect,
+		)
+	}
+
+	if cfg.Location != "" {
+		if _, err := time.LoadLocation(cfg.Location); err != nil {
+			return fmt.Errorf("table has invalid location '%s'", cfg.Location)
+		}
+	}
+
 	return nil
 }
 
+// renderDSN builds the table's DSN from the template (if the table doesn't already have one). The
+// connection parameters used to render the DSN are cleared, since the DSN replaces them
+func renderDSN(dsnTemplate *template.Template, table TableConfig) (TableConfig, error) {
+	if table.DSN != "" || table.ReadDSN != "" || table.WriteDSN != "" {
+		return table, nil
+	}
+
+	// The DSN is built from the option file's credentials when the table is connected
+	if table.OptionFile != "" {
+		return table, nil
+	}
+
+	var dsn strings.Builder
+	if err := dsnTemplate.Execute(&dsn, table); err != nil {
+		return TableConfig{}, fmt.Errorf("failed to render dsnTemplate: %w", err)
+	}
+
+	if strings.TrimSpace(dsn.String()) == "" {
+		return TableConfig{}, fmt.Errorf("dsnTemplate rendered an empty DSN")
+	}
+
+	table.DSN = dsn.String()
+	table.User = ""
+	table.Password = ""
+	table.Host = ""
+	table.Port = 0
+	table.DB = ""
+
+	return table, nil
+}
+
+// emptyHandling maps each of the job's TreatEmptyAsNull and TreatNullAsEmpty columns to how its
+// empty strings and NULLs are treated (see convertEmpty)
+func (cfg JobConfig) emptyHandling() map[string]string {
+	handling := map[string]string{}
+
+	for _, column := range cfg.TreatEmptyAsNull {
+		handling[column] = emptyAsNull
+	}
+
+	for _, column := range cfg.TreatNullAsEmpty {
+		handling[column] = nullAsEmpty
+	}
+
+	return handling
+}
+
+// allSQLite returns whether the job's source and targets are all sqlite3
+func (cfg JobConfig) allSQLite() bool {
+	if cfg.Source.Driver != "sqlite3" {
+		return false
+	}
+
+	for _, target := range cfg.Targets {
+		if target.Driver != "sqlite3" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// id uniquely identifies the table by its connection parameters and name
+func (cfg TableConfig) id() string {
+	if dsn := cfg.primaryDSN(); dsn != "" {
+		return fmt.Sprintf("%s|%s|%s", cfg.Driver, dsn, cfg.Table)
+	}
+
+	return fmt.Sprintf(
+		"%s|%s@%s:%d/%s|%s", cfg.Driver, cfg.User, cfg.Host, cfg.Port, cfg.DB, cfg.Table,
+	)
+}
+
+// primaryDSN returns the DSN of the database that is written to. For a table with a read/write
+// split, this is the WriteDSN
+func (cfg TableConfig) primaryDSN() string {
+	if cfg.WriteDSN != "" {
+		return cfg.WriteDSN
+	}
+
+	return cfg.DSN
+}
+
+// sameTable returns whether the two configs refer to the same table in the same database. Unlike
+// id, the credentials used to connect are ignored
+func (cfg TableConfig) sameTable(other TableConfig) bool {
+	if cfg.Driver != other.Driver || cfg.Table != other.Table {
+		return false
+	}
+
+	if cfg.primaryDSN() != "" || other.primaryDSN() != "" {
+		return cfg.primaryDSN() == other.primaryDSN()
+	}
+
+	return cfg.Host == other.Host && cfg.Port == other.Port && cfg.DB == other.DB
+}
+
 func imposeTableDefaults(table TableConfig, defaults ConfigDefaults) TableConfig {
 	var hostDefaults HostDefaults
 	if table.Host != "" {
@@ -348,6 +1653,14 @@ func imposeTableDefaults(table TableConfig, defaults ConfigDefaults) TableConfig
 		table.DSN = hostDefaults.DSN
 	}
 
+	// If OptionFile is empty, set it to the host's default (unless the table has its own DSN or
+	// credentials, which can't be combined with one)
+	hasCredentials := table.DSN != "" || table.ReadDSN != "" || table.User != "" ||
+		table.Password != ""
+	if table.OptionFile == "" && !hasCredentials {
+		table.OptionFile = hostDefaults.OptionFile
+	}
+
 	// If User is empty, set it to the host's default
 	if table.User == "" {
 		table.User = hostDefaults.User
@@ -368,6 +1681,16 @@ func imposeTableDefaults(table TableConfig, defaults ConfigDefaults) TableConfig
 		table.DB = hostDefaults.DB
 	}
 
+	// If StatementMode is empty, set it to the host's default
+	if table.StatementMode == "" {
+		table.StatementMode = hostDefaults.StatementMode
+	}
+
+	// If InitSQL is empty, set it to the host's default
+	if len(table.InitSQL) == 0 {
+		table.InitSQL = hostDefaults.InitSQL
+	}
+
 	// If Label is empty, set it to the host's default
 	if table.Label == "" {
 		table.Label = hostDefaults.Label
@@ -377,6 +1700,8 @@ func imposeTableDefaults(table TableConfig, defaults ConfigDefaults) TableConfig
 	if table.Label == "" {
 		if table.DSN != "" {
 			table.Label = table.DSN
+		} else if table.WriteDSN != "" {
+			table.Label = table.WriteDSN
 		} else if table.Host != "" && table.Port != 0 {
 			table.Label = fmt.Sprintf("%s:%d", table.Host, table.Port)
 		} else if table.Host != "" {
@@ -1,8 +1,11 @@
 package sync
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -16,6 +19,25 @@ func TestLoadConfig(t *testing.T) {
 		assert.ErrorAs(t, err, &typeErr)
 	})
 
+	t.Run("load config with unknown field", func(t *testing.T) {
+		_, err := loadConfig(`
+            jobs:
+              users:
+                columns: [id, name, age]
+                primarykey: id
+                source:
+                  driver: sqlite3
+                  dsn: "my_fake_dsn"
+                  table: users
+                targets:
+                  - dsn: "my_other_fake_dsn"
+        `)
+		require.Error(t, err)
+		var typeErr *yaml.TypeError
+		assert.ErrorAs(t, err, &typeErr)
+		assert.ErrorContains(t, err, "field primarykey not found")
+	})
+
 	t.Run("load valid config", func(t *testing.T) {
 		cfg, err := loadConfig(`
             jobs:
@@ -199,6 +221,124 @@ func TestLoadConfig(t *testing.T) {
 		assert.Empty(t, postsJob.Source.Host)
 	})
 
+	t.Run("dsn template", func(t *testing.T) {
+		cfg, err := loadConfig(`
+            defaults:
+              driver: mysql
+              dsnTemplate: "{{.User}}@tcp({{.Host}}:{{.Port}})/{{.DB}}"
+
+              hosts:
+                host1:
+                  user: user1
+                  port: 3306
+                  db: app
+
+            jobs:
+              users:
+                columns: [id, name, age]
+                source:
+                  host: host1
+                  table: users
+                targets:
+                  - host: host2
+                    user: user2
+                    port: 3307
+                    db: app2
+                  - host: host3
+                    user: user3
+                    port: 3308
+                    db: app3
+                  - dsn: explicit@tcp(host4:3309)/app4
+                    table: users
+        `)
+		require.NoError(t, err)
+
+		job := cfg.Jobs["users"]
+		assert.Equal(t, "user1@tcp(host1:3306)/app", job.Source.DSN)
+		assert.Equal(t, "host1:3306", job.Source.Label)
+		assert.Empty(t, job.Source.Host)
+
+		require.Len(t, job.Targets, 3)
+		assert.Equal(t, "user2@tcp(host2:3307)/app2", job.Targets[0].DSN)
+		assert.Equal(t, "host2:3307", job.Targets[0].Label)
+		assert.Equal(t, "users", job.Targets[0].Table)
+		assert.Equal(t, "user3@tcp(host3:3308)/app3", job.Targets[1].DSN)
+		assert.Equal(t, "host3:3308", job.Targets[1].Label)
+		assert.Equal(t, "users", job.Targets[1].Table)
+
+		// An explicit DSN is left alone
+		assert.Equal(t, "explicit@tcp(host4:3309)/app4", job.Targets[2].DSN)
+
+		// The rendered DSNs should pass validation
+		require.NoError(t, cfg.validate())
+	})
+
+	t.Run("load config with targets that default to the same label", func(t *testing.T) {
+		cfg, err := loadConfig(`
+            defaults:
+              hosts:
+                db1:
+                  driver: mysql
+                  port: 3306
+            jobs:
+              users:
+                columns: [id, name]
+                source:
+                  host: db1
+                  table: users
+                targets:
+                  - host: db1
+                    table: users_copy1
+                  - host: db1
+                    table: users_copy2
+        `)
+		require.NoError(t, err)
+
+		// Both targets' labels default to the host's address
+		job := cfg.Jobs["users"]
+		assert.Equal(t, "db1:3306", job.Targets[0].Label)
+		assert.Equal(t, "db1:3306", job.Targets[1].Label)
+
+		err = cfg.validate()
+		assert.ErrorContains(
+			t, err, `job 'users': target[0] and target[1] have the same label "db1:3306"`,
+		)
+	})
+
+	t.Run("dsn template renders empty", func(t *testing.T) {
+		_, err := loadConfig(`
+            defaults:
+              dsnTemplate: "{{.DB}}"
+
+            jobs:
+              users:
+                columns: [id, name, age]
+                source:
+                  dsn: source_dsn
+                  table: users
+                targets:
+                  - host: host2
+        `)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "job 'users': target[0]: dsnTemplate rendered an empty DSN")
+	})
+
+	t.Run("invalid dsn template", func(t *testing.T) {
+		_, err := loadConfig(`
+            defaults:
+              dsnTemplate: "{{.Nope}}"
+
+            jobs:
+              users:
+                columns: [id, name, age]
+                source:
+                  host: host1
+                  table: users
+        `)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "failed to render dsnTemplate")
+	})
+
 	t.Run("default source and targets", func(t *testing.T) {
 		cfg, err := loadConfig(`
             defaults:              
@@ -439,6 +579,99 @@ func TestLoadConfig(t *testing.T) {
 	})
 }
 
+func TestLoadConfig_profiles(t *testing.T) {
+	profiles := `
+        profiles:
+          staging:
+            defaults:
+              driver: sqlite3
+              hosts:
+                db.staging:
+                  label: staging
+            jobs:
+              users:
+                columns: [id, name]
+                source:
+                  host: db.staging
+                  table: users
+                targets:
+                  - host: db.staging
+                    table: users_copy
+          prod:
+            jobs:
+              pets:
+                columns: [id, name]
+                source:
+                  driver: sqlite3
+                  dsn: prod_source_dsn
+                  table: pets
+                targets:
+                  - driver: sqlite3
+                    dsn: prod_target_dsn
+                    table: pets
+    `
+
+	t.Run("each profile is isolated", func(t *testing.T) {
+		staging, err := loadConfigProfile(profiles, "staging")
+		require.NoError(t, err)
+		require.Len(t, staging.Jobs, 1)
+		assert.Equal(t, "staging", staging.Jobs["users"].Source.Label)
+		assert.Equal(t, "sqlite3", staging.Jobs["users"].Targets[0].Driver)
+
+		prod, err := loadConfigProfile(profiles, "prod")
+		require.NoError(t, err)
+		require.Len(t, prod.Jobs, 1)
+		assert.Equal(t, "prod_target_dsn", prod.Jobs["pets"].Targets[0].DSN)
+
+		// staging's defaults don't apply to prod
+		assert.Empty(t, prod.Defaults.Hosts)
+	})
+
+	t.Run("from a file", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(filename, []byte(profiles), 0o644))
+
+		cfg, err := LoadConfigProfile(filename, "prod")
+		require.NoError(t, err)
+		assert.Contains(t, cfg.Jobs, "pets")
+		assert.NotContains(t, cfg.Jobs, "users")
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		_, err := loadConfigProfile(profiles, "dev")
+		assert.ErrorContains(t, err, "profile 'dev' not found in config (it has: prod, staging)")
+	})
+
+	t.Run("no profile selected", func(t *testing.T) {
+		_, err := loadConfig(profiles)
+		assert.ErrorContains(t, err, "config has profiles, so one must be selected: prod, staging")
+	})
+
+	t.Run("profile selected but config has no profiles", func(t *testing.T) {
+		_, err := loadConfigProfile(`
+            jobs:
+              users:
+                columns: [id, name]
+                source:
+                  driver: sqlite3
+                  dsn: source_dsn
+                  table: users
+                targets:
+                  - dsn: target_dsn
+        `, "prod")
+		assert.ErrorContains(t, err, "profile 'prod' not found in config (it has no profiles)")
+	})
+
+	t.Run("profiles with top-level jobs", func(t *testing.T) {
+		_, err := loadConfigProfile(profiles+`
+        jobs:
+          pets:
+            columns: [id, name]
+        `, "prod")
+		assert.ErrorContains(t, err, "cannot also have top-level jobs")
+	})
+}
+
 func TestValidateConfig(t *testing.T) {
 	validConfig := func() Config {
 		return Config{
@@ -481,6 +714,164 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectedErr: "no jobs found in config",
 		},
+		{
+			description: "job order",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.JobOrder = []string{"users"}
+				return cfg
+			},
+		},
+		{
+			description: "job order has unknown job",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.JobOrder = []string{"users", "teams"}
+				return cfg
+			},
+			expectedErr: "jobOrder has job 'teams', which is not in jobs",
+		},
+		{
+			description: "job order has duplicate job",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.JobOrder = []string{"users", "users"}
+				return cfg
+			},
+			expectedErr: "jobOrder has job 'users' more than once",
+		},
+		{
+			description: "job order with max concurrency",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.JobOrder = []string{"users"}
+				cfg.MaxConcurrency = 2
+				return cfg
+			},
+			expectedErr: "jobOrder can't be used with maxConcurrency",
+		},
+		{
+			description: "job dependencies",
+			config: func() Config {
+				cfg := validConfig()
+				teams := cfg.Jobs["users"]
+				cfg.Jobs["teams"] = teams
+
+				users := cfg.Jobs["users"]
+				users.DependsOn = []string{"teams"}
+				cfg.Jobs["users"] = users
+				cfg.JobOrder = []string{"teams", "users"}
+				return cfg
+			},
+		},
+		{
+			description: "job depends on unknown job",
+			config: func() Config {
+				cfg := validConfig()
+				users := cfg.Jobs["users"]
+				users.DependsOn = []string{"teams"}
+				cfg.Jobs["users"] = users
+				return cfg
+			},
+			expectedErr: "job 'users': depends on job 'teams', which is not in jobs",
+		},
+		{
+			description: "job depends on itself",
+			config: func() Config {
+				cfg := validConfig()
+				users := cfg.Jobs["users"]
+				users.DependsOn = []string{"users"}
+				cfg.Jobs["users"] = users
+				return cfg
+			},
+			expectedErr: "jobs have a dependency cycle: users -> users",
+		},
+		{
+			description: "job dependency cycle",
+			config: func() Config {
+				cfg := validConfig()
+				for name, dependency := range map[string]string{
+					"accounts": "users",
+					"teams":    "accounts",
+					"users":    "teams",
+				} {
+					job := validConfig().Jobs["users"]
+					job.DependsOn = []string{dependency}
+					cfg.Jobs[name] = job
+				}
+				return cfg
+			},
+			expectedErr: "jobs have a dependency cycle: accounts -> users -> teams -> accounts",
+		},
+		{
+			description: "job order before dependency",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.Jobs["teams"] = cfg.Jobs["users"]
+
+				users := cfg.Jobs["users"]
+				users.DependsOn = []string{"teams"}
+				cfg.Jobs["users"] = users
+				cfg.JobOrder = []string{"users", "teams"}
+				return cfg
+			},
+			expectedErr: "jobOrder has job 'users' before job 'teams', which it depends on",
+		},
+		{
+			description: "allowed drivers",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.AllowedDrivers = []string{"sqlite3"}
+				return cfg
+			},
+		},
+		{
+			description: "disallowed target driver",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.AllowedDrivers = []string{"sqlite3"}
+
+				job := cfg.Jobs["users"]
+				job.Targets = append(job.Targets, TableConfig{
+					Label:  "replica",
+					Table:  "users",
+					Driver: "mysql",
+					DSN:    "root@tcp(localhost:3306)/app",
+				})
+				cfg.Jobs["users"] = job
+				return cfg
+			},
+			expectedErr: `job 'users': "replica" uses driver 'mysql', ` +
+				"which is not in allowedDrivers [sqlite3]",
+		},
+		{
+			description: "disallowed history driver",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.AllowedDrivers = []string{"mysql"}
+				cfg.History = &TableConfig{Table: "history", Driver: "sqlite3", DSN: "history.db"}
+				return cfg
+			},
+			expectedErr: "history uses driver 'sqlite3', which is not in allowedDrivers [mysql]",
+		},
+		{
+			description: "unsupported allowed driver",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.AllowedDrivers = []string{"sqlite3", "postgres"}
+				return cfg
+			},
+			expectedErr: "allowedDrivers has unsupported driver 'postgres'",
+		},
+		{
+			description: "notify without url",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.Notify = &NotifyConfig{AuthHeader: "Bearer secret"}
+				return cfg
+			},
+			expectedErr: "notify does not specify a url",
+		},
 		{
 			description: "empty job name",
 			config: func() Config {
@@ -490,6 +881,43 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectedErr: "all jobs must have a name",
 		},
+		{
+			description: "negative maxConcurrency",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.MaxConcurrency = -1
+				return cfg
+			},
+			expectedErr: "maxConcurrency cannot be negative",
+		},
+		{
+			description: "negative maxConnectAttemptsPerHost",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.MaxConnectAttemptsPerHost = -1
+				return cfg
+			},
+			expectedErr: "maxConnectAttemptsPerHost cannot be negative",
+		},
+		{
+			description: "concurrent jobs sharing a checkpoint file",
+			config: func() Config {
+				cfg := validConfig()
+				cfg.MaxConcurrency = 4
+
+				users := cfg.Jobs["users"]
+				users.CheckpointFile = "checkpoints.json"
+				cfg.Jobs["users"] = users
+
+				pets := users
+				pets.Source.Table = "pets"
+				pets.Targets = []TableConfig{{Table: "pets2", Driver: "sqlite3"}}
+				cfg.Jobs["pets"] = pets
+
+				return cfg
+			},
+			expectedErr: "jobs 'pets' and 'users' can't share a checkpointFile when maxConcurrency is set",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -543,6 +971,15 @@ func TestValidateJobConfig(t *testing.T) {
 			},
 			expectedErr: "does not specify any columns",
 		},
+		{
+			description: "wildcard column",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Columns = []string{"id", "*"}
+				return cfg
+			},
+			expectedErr: "has invalid column '*'",
+		},
 		{
 			description: "missing primary keys",
 			job: func() JobConfig {
@@ -571,73 +1008,566 @@ func TestValidateJobConfig(t *testing.T) {
 			expectedErr: "has primary key 'favoriteColor' not in columns",
 		},
 		{
-			description: "missing source table",
+			description: "negative max drift rows",
 			job: func() JobConfig {
 				cfg := validJob()
-				cfg.Source.Table = ""
+				cfg.MaxDriftRows = -1
 				return cfg
 			},
-			expectedErr: "source: table name is empty",
+			expectedErr: "maxDriftRows cannot be negative",
 		},
 		{
-			description: "missing source table (with label)",
+			description: "negative max concurrency",
 			job: func() JobConfig {
 				cfg := validJob()
-				cfg.Source.Table = ""
-				cfg.Source.Label = "foobarbaz"
+				cfg.MaxConcurrency = -1
 				return cfg
 			},
-			expectedErr: `"foobarbaz": table name is empty`,
+			expectedErr: "maxConcurrency cannot be negative",
 		},
 		{
-			description: "missing source driver",
+			description: "negative ping attempts",
 			job: func() JobConfig {
 				cfg := validJob()
-				cfg.Source.Driver = ""
+				cfg.PingAttempts = -1
 				return cfg
 			},
-			expectedErr: "source: table does not specify a driver",
+			expectedErr: "pingAttempts cannot be negative",
 		},
 		{
-			description: "missing source driver (with label)",
+			description: "negative batch size",
 			job: func() JobConfig {
 				cfg := validJob()
-				cfg.Source.Driver = ""
-				cfg.Source.Label = "foobarbaz"
+				cfg.BatchSize = -1
 				return cfg
 			},
-			expectedErr: `"foobarbaz": table does not specify a driver`,
+			expectedErr: "batchSize cannot be negative",
 		},
 		{
-			description: "missing targets",
+			description: "target transaction with checkpoint file",
 			job: func() JobConfig {
 				cfg := validJob()
-				cfg.Targets = nil
+				cfg.TargetTransaction = true
+				cfg.CheckpointFile = "checkpoints.json"
 				return cfg
 			},
-			expectedErr: "has no targets",
+			expectedErr: "cannot use both targetTransaction and checkpointFile",
 		},
 		{
-			description: "missing target table",
+			description: "compare as strings",
 			job: func() JobConfig {
 				cfg := validJob()
-				cfg.Targets[0].Table = ""
+				cfg.CompareAsStrings = true
 				return cfg
 			},
-			expectedErr: "target[0]: table name is empty",
 		},
 		{
-			description: "missing target table (with label)",
+			description: "compare as strings with float tolerance",
 			job: func() JobConfig {
 				cfg := validJob()
-				cfg.Targets[0].Table = ""
-				cfg.Targets[0].Label = "foobarbaz"
+				cfg.CompareAsStrings = true
+				cfg.FloatTolerance = 0.01
 				return cfg
 			},
-			expectedErr: `"foobarbaz": table name is empty`,
+			expectedErr: "cannot use both compareAsStrings and floatTolerance",
 		},
 		{
-			description: "missing target driver",
+			description: "skip unchanged source without state file",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.SkipUnchangedSource = true
+				return cfg
+			},
+			expectedErr: "skipUnchangedSource requires a stateFile",
+		},
+		{
+			description: "partitioned checksum",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.SkipUnchangedSource = true
+				cfg.StateFile = "state.json"
+				cfg.PartitionedChecksum = true
+				cfg.PartitionCount = 8
+				return cfg
+			},
+		},
+		{
+			description: "partition count without partitioned checksum",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.PartitionCount = 8
+				return cfg
+			},
+			expectedErr: "partitionCount requires partitionedChecksum",
+		},
+		{
+			description: "negative partition count",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.SkipUnchangedSource = true
+				cfg.StateFile = "state.json"
+				cfg.PartitionedChecksum = true
+				cfg.PartitionCount = -1
+				return cfg
+			},
+			expectedErr: "partitionCount cannot be negative",
+		},
+		{
+			description: "partitioned checksum without skip unchanged source",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.SkipUnchangedSource = true
+				cfg.StateFile = "state.json"
+				cfg.PartitionedChecksum = true
+				cfg.SkipUnchangedSource = false
+				return cfg
+			},
+			expectedErr: "partitionedChecksum requires skipUnchangedSource",
+		},
+		{
+			description: "partitioned checksum with composite primary key",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.SkipUnchangedSource = true
+				cfg.StateFile = "state.json"
+				cfg.PartitionedChecksum = true
+				cfg.PrimaryKeys = []string{"id", "name"}
+				return cfg
+			},
+			expectedErr: "partitionedChecksum requires a single primary key",
+		},
+		{
+			description: "partitioned checksum with target primary keys",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.SkipUnchangedSource = true
+				cfg.StateFile = "state.json"
+				cfg.PartitionedChecksum = true
+				cfg.Targets[0].PrimaryKeys = []string{"name"}
+				return cfg
+			},
+			expectedErr: "target[0] has its own primaryKeys, so the job can't use partitionedChecksum",
+		},
+		{
+			description: "partitioned checksum with replace mode",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.SkipUnchangedSource = true
+				cfg.StateFile = "state.json"
+				cfg.PartitionedChecksum = true
+				cfg.ReplaceMode = true
+				return cfg
+			},
+			expectedErr: "cannot use both partitionedChecksum and replaceMode",
+		},
+		{
+			description: "partitioned checksum with shard column",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.SkipUnchangedSource = true
+				cfg.StateFile = "state.json"
+				cfg.PartitionedChecksum = true
+				cfg.ShardColumn = "id"
+				return cfg
+			},
+			expectedErr: "cannot use both partitionedChecksum and shardColumn",
+		},
+		{
+			description: "negative max target to source ratio",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.MaxTargetToSourceRatio = -1
+				return cfg
+			},
+			expectedErr: "maxTargetToSourceRatio cannot be negative",
+		},
+		{
+			description: "negative replace max rows",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.ReplaceMaxRows = -1
+				return cfg
+			},
+			expectedErr: "replaceMaxRows cannot be negative",
+		},
+		{
+			description: "replace mode with checkpoint file",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.ReplaceMode = true
+				cfg.CheckpointFile = "checkpoints.json"
+				return cfg
+			},
+			expectedErr: "cannot use both replaceMode and checkpointFile",
+		},
+		{
+			description: "float column not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.FloatColumns = []string{"height"}
+				return cfg
+			},
+			expectedErr: "has float column 'height' not in columns",
+		},
+		{
+			description: "trim text column not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.TrimTextColumns = []string{"nickname"}
+				return cfg
+			},
+			expectedErr: "has trim text column 'nickname' not in columns",
+		},
+		{
+			description: "trim text column is a primary key",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.TrimTextColumns = []string{"name", "id"}
+				return cfg
+			},
+			expectedErr: "cannot trim primary key column 'id'",
+		},
+		{
+			description: "case insensitive column not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.CaseInsensitiveColumns = []string{"email"}
+				return cfg
+			},
+			expectedErr: "has case insensitive column 'email' not in columns",
+		},
+		{
+			description: "case insensitive column is a primary key",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.CaseInsensitiveColumns = []string{"id"}
+				return cfg
+			},
+			expectedErr: "primary key column 'id' cannot be case insensitive",
+		},
+		{
+			description: "decimal column not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.DecimalColumns = []string{"price"}
+				return cfg
+			},
+			expectedErr: "has decimal column 'price' not in columns",
+		},
+		{
+			description: "decimal column is a primary key",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.DecimalColumns = []string{"id"}
+				return cfg
+			},
+			expectedErr: "primary key column 'id' cannot be a decimal column",
+		},
+		{
+			description: "decimal column is also a float column",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.FloatColumns = []string{"name"}
+				cfg.DecimalColumns = []string{"name"}
+				return cfg
+			},
+			expectedErr: "column 'name' cannot be both a float and a decimal column",
+		},
+		{
+			description: "json column not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.JSONColumns = []string{"settings"}
+				return cfg
+			},
+			expectedErr: "has json column 'settings' not in columns",
+		},
+		{
+			description: "json column is a primary key",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.JSONColumns = []string{"id"}
+				return cfg
+			},
+			expectedErr: "primary key column 'id' cannot be a json column",
+		},
+		{
+			description: "json column is also a decimal column",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.DecimalColumns = []string{"name"}
+				cfg.JSONColumns = []string{"name"}
+				return cfg
+			},
+			expectedErr: "column 'name' cannot be both a number and a json column",
+		},
+		{
+			description: "auto-update column not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.AutoUpdateColumns = []string{"updated_at"}
+				return cfg
+			},
+			expectedErr: "has auto-update column 'updated_at' not in columns",
+		},
+		{
+			description: "auto-update column is a primary key",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.AutoUpdateColumns = []string{"id"}
+				return cfg
+			},
+			expectedErr: "primary key column 'id' cannot be an auto-update column",
+		},
+		{
+			description: "auto-update column is a checksum column",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.ChecksumColumns = []string{"id", "name"}
+				cfg.AutoUpdateColumns = []string{"name"}
+				return cfg
+			},
+			expectedErr: "column 'name' cannot be both a checksum and an auto-update column",
+		},
+		{
+			description: "checksum column not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.ChecksumColumns = []string{"id", "zip"}
+				return cfg
+			},
+			expectedErr: "has checksum column 'zip' not in columns",
+		},
+		{
+			description: "checksum columns without primary key",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.ChecksumColumns = []string{"name"}
+				return cfg
+			},
+			expectedErr: "checksumColumns must include primary key 'id'",
+		},
+		{
+			description: "source index hint with sqlite3 source",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.SourceIndexHint = "FORCE INDEX (PRIMARY)"
+				return cfg
+			},
+			expectedErr: "sourceIndexHint can only be used with a mysql source",
+		},
+		{
+			description: "treat empty as null column not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.TreatEmptyAsNull = []string{"nickname"}
+				return cfg
+			},
+			expectedErr: "has treatEmptyAsNull column 'nickname' not in columns",
+		},
+		{
+			description: "column treats empty as null and null as empty",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.TreatEmptyAsNull = []string{"name"}
+				cfg.TreatNullAsEmpty = []string{"name"}
+				return cfg
+			},
+			expectedErr: "column 'name' cannot be in both treatEmptyAsNull and treatNullAsEmpty",
+		},
+		{
+			description: "treat null as empty primary key",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.TreatNullAsEmpty = []string{"id"}
+				return cfg
+			},
+			expectedErr: "primary key column 'id' cannot convert empty strings or NULLs",
+		},
+		{
+			description: "type hint for column not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.TypeHints = map[string]string{"zip": "string"}
+				return cfg
+			},
+			expectedErr: "has type hint for column 'zip' not in columns",
+		},
+		{
+			description: "invalid type hint",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.TypeHints = map[string]string{"age": "decimal"}
+				return cfg
+			},
+			expectedErr: "has invalid type hint 'decimal' for column 'age'",
+		},
+		{
+			description: "target is the same table as the source",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Source.DSN = "file:users.db"
+				cfg.Targets = append(cfg.Targets, TableConfig{
+					Label:  "oops",
+					Table:  "users",
+					Driver: "sqlite3",
+					DSN:    "file:users.db",
+				})
+				return cfg
+			},
+			expectedErr: `"oops": is the same table as the source`,
+		},
+		{
+			description: "target is the same table as the source with different credentials",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Source = TableConfig{
+					Table:  "users",
+					Driver: "mysql",
+					User:   "reader",
+					Host:   "db1",
+					Port:   3306,
+					DB:     "app",
+				}
+				cfg.Targets = []TableConfig{cfg.Source}
+				cfg.Targets[0].User = "writer"
+				return cfg
+			},
+			expectedErr: "target[0]: is the same table as the source",
+		},
+		{
+			description: "missing source table",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Source.Table = ""
+				return cfg
+			},
+			expectedErr: "source: table name is empty",
+		},
+		{
+			description: "missing source table (with label)",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Source.Table = ""
+				cfg.Source.Label = "foobarbaz"
+				return cfg
+			},
+			expectedErr: `"foobarbaz": table name is empty`,
+		},
+		{
+			description: "missing source driver",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Source.Driver = ""
+				return cfg
+			},
+			expectedErr: "source: table does not specify a driver",
+		},
+		{
+			description: "missing source driver (with label)",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Source.Driver = ""
+				cfg.Source.Label = "foobarbaz"
+				return cfg
+			},
+			expectedErr: `"foobarbaz": table does not specify a driver`,
+		},
+		{
+			description: "noop source",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Source.Driver = "noop"
+				return cfg
+			},
+			expectedErr: "source cannot use the noop driver",
+		},
+		{
+			description: "target with its own primary keys",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Targets[0].PrimaryKeys = []string{"name", "age"}
+				return cfg
+			},
+		},
+		{
+			description: "target primary key not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Targets[0].PrimaryKeys = []string{"email"}
+				return cfg
+			},
+			expectedErr: "target[0]: has primary key 'email' not in columns",
+		},
+		{
+			description: "target has too many primary keys",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Columns = []string{"id", "name", "age", "email"}
+				cfg.Targets[0].PrimaryKeys = []string{"id", "name", "age", "email"}
+				return cfg
+			},
+			expectedErr: "target[0]: has too many primary keys",
+		},
+		{
+			description: "target primary key is normalized",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.CaseInsensitiveColumns = []string{"name"}
+				cfg.Targets[0].PrimaryKeys = []string{"name"}
+				return cfg
+			},
+			expectedErr: "primary key column 'name' cannot be normalized for comparison",
+		},
+		{
+			description: "source with its own primary keys",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Source.PrimaryKeys = []string{"name"}
+				return cfg
+			},
+			expectedErr: "source cannot override primaryKeys",
+		},
+		{
+			description: "source with read/write split",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Source.ReadDSN = "replica.db"
+				cfg.Source.WriteDSN = "primary.db"
+				return cfg
+			},
+			expectedErr: "source cannot use readDsn/writeDsn",
+		},
+		{
+			description: "missing targets",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Targets = nil
+				return cfg
+			},
+			expectedErr: "has no targets",
+		},
+		{
+			description: "missing target table",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Targets[0].Table = ""
+				return cfg
+			},
+			expectedErr: "target[0]: table name is empty",
+		},
+		{
+			description: "missing target table (with label)",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Targets[0].Table = ""
+				cfg.Targets[0].Label = "foobarbaz"
+				return cfg
+			},
+			expectedErr: `"foobarbaz": table name is empty`,
+		},
+		{
+			description: "missing target driver",
 			job: func() JobConfig {
 				cfg := validJob()
 				cfg.Targets[0].Driver = ""
@@ -655,6 +1585,124 @@ func TestValidateJobConfig(t *testing.T) {
 			},
 			expectedErr: `"foobarbaz": table does not specify a driver`,
 		},
+		{
+			description: "data column not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.DataColumns = []string{"email"}
+				return cfg
+			},
+			expectedErr: "has data column 'email' not in columns",
+		},
+		{
+			description: "primary key data column",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.DataColumns = []string{"id", "name"}
+				return cfg
+			},
+			expectedErr: "primary key column 'id' cannot be a data column",
+		},
+		{
+			description: "target primary key data column",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.DataColumns = []string{"name"}
+				cfg.Targets[0].PrimaryKeys = []string{"name"}
+				return cfg
+			},
+			expectedErr: "primary key column 'name' cannot be a data column",
+		},
+		{
+			description: "data column is also an auto-update column",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.DataColumns = []string{"name"}
+				cfg.AutoUpdateColumns = []string{"name"}
+				return cfg
+			},
+			expectedErr: "column 'name' cannot be both a data and an auto-update column",
+		},
+		{
+			description: "checksum column isn't a data column",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.DataColumns = []string{"name"}
+				cfg.ChecksumColumns = []string{"id", "age"}
+				return cfg
+			},
+			expectedErr: "checksum column 'age' must be a data column",
+		},
+		{
+			description: "encrypt column not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.EncryptColumns = []string{"email"}
+				cfg.EncryptionKeyEnv = "KEY"
+				return cfg
+			},
+			expectedErr: "has encrypt column 'email' not in columns",
+		},
+		{
+			description: "primary key encrypt column",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.EncryptColumns = []string{"id"}
+				cfg.EncryptionKeyEnv = "KEY"
+				return cfg
+			},
+			expectedErr: "primary key column 'id' cannot be encrypted",
+		},
+		{
+			description: "normalized encrypt column",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.EncryptColumns = []string{"name"}
+				cfg.EncryptionKeyEnv = "KEY"
+				cfg.TrimTextColumns = []string{"name"}
+				return cfg
+			},
+			expectedErr: "column 'name' cannot be both normalized and encrypted",
+		},
+		{
+			description: "encrypt columns without a key",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.EncryptColumns = []string{"name"}
+				return cfg
+			},
+			expectedErr: "encryptColumns requires encryptionKeyEnv",
+		},
+		{
+			description: "encrypted target without encrypt columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Targets[0].Encrypted = true
+				return cfg
+			},
+			expectedErr: "target[0] is encrypted, but the job has no encryptColumns",
+		},
+		{
+			description: "shard column not in columns",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.ShardColumn = "tenant_id"
+				return cfg
+			},
+			expectedErr: "has shard column 'tenant_id' not in columns",
+		},
+		{
+			description: "duplicate target labels",
+			job: func() JobConfig {
+				cfg := validJob()
+				cfg.Targets[0].Label = "replica"
+				cfg.Targets = append(cfg.Targets, TableConfig{
+					Table: "users3", Driver: "sqlite3", Label: "replica",
+				})
+				return cfg
+			},
+			expectedErr: `target[0] and target[1] have the same label "replica"`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -670,6 +1718,67 @@ func TestValidateJobConfig(t *testing.T) {
 	}
 }
 
+func TestJobConfig_WithColumns(t *testing.T) {
+	job := JobConfig{
+		Columns:         []string{"id", "name", "email", "score"},
+		PrimaryKeys:     []string{"id"},
+		FloatColumns:    []string{"score"},
+		TrimTextColumns: []string{"name"},
+		ChecksumColumns: []string{"id", "email"},
+		TypeHints:       map[string]string{"score": "float", "name": "string"},
+		Source:          TableConfig{Table: "users", Driver: "sqlite3"},
+		Targets: []TableConfig{
+			{Table: "users2", Driver: "sqlite3"},
+			{Table: "users3", Driver: "sqlite3", PrimaryKeys: []string{"email"}},
+		},
+	}
+
+	t.Run("narrows the columns", func(t *testing.T) {
+		// The job's column order is kept, regardless of the order they are given in
+		narrowed, err := job.WithColumns([]string{"email", "id", "name"})
+		require.NoError(t, err)
+		require.NoError(t, narrowed.validate())
+
+		assert.Equal(t, []string{"id", "name", "email"}, narrowed.Columns)
+		assert.Empty(t, narrowed.FloatColumns)
+		assert.Equal(t, []string{"name"}, narrowed.TrimTextColumns)
+		assert.Equal(t, []string{"id", "email"}, narrowed.ChecksumColumns)
+		assert.Equal(t, map[string]string{"name": "string"}, narrowed.TypeHints)
+
+		// The original job is unchanged
+		assert.Equal(t, []string{"id", "name", "email", "score"}, job.Columns)
+		assert.Len(t, job.TypeHints, 2)
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		_, err := job.WithColumns([]string{"id", "email", "age"})
+		assert.EqualError(t, err, "column 'age' is not one of the job's columns")
+	})
+
+	t.Run("missing primary key", func(t *testing.T) {
+		_, err := job.WithColumns([]string{"name", "email"})
+		assert.EqualError(t, err, "columns must include primary key 'id'")
+	})
+
+	t.Run("missing target primary key", func(t *testing.T) {
+		_, err := job.WithColumns([]string{"id", "name"})
+		assert.EqualError(t, err, "columns must include primary key 'email'")
+	})
+
+	t.Run("no data columns", func(t *testing.T) {
+		withDataColumns := job
+		withDataColumns.DataColumns = []string{"score"}
+
+		_, err := withDataColumns.WithColumns([]string{"id", "email"})
+		assert.EqualError(t, err, "columns must include at least one of the job's dataColumns")
+	})
+
+	t.Run("no columns", func(t *testing.T) {
+		_, err := job.WithColumns(nil)
+		assert.EqualError(t, err, "must include at least one column")
+	})
+}
+
 func TestValidateTableConfig(t *testing.T) {
 	validTable := func() TableConfig {
 		return TableConfig{
@@ -707,6 +1816,16 @@ func TestValidateTableConfig(t *testing.T) {
 			},
 			expectedErr: "table does not specify a driver",
 		},
+		{
+			description: "noop table with invalid mode",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.Driver = "noop"
+				cfg.DSN = "sometimes"
+				return cfg
+			},
+			expectedErr: "noop table has invalid mode 'sometimes'",
+		},
 		{
 			description: "DSN and other connection parameters",
 			table: func() TableConfig {
@@ -717,6 +1836,146 @@ func TestValidateTableConfig(t *testing.T) {
 			},
 			expectedErr: "table cannot specify DSN and other connection parameters",
 		},
+		{
+			description: "read/write split",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.ReadDSN = "replica.db"
+				cfg.WriteDSN = "primary.db"
+				return cfg
+			},
+		},
+		{
+			description: "readDsn without writeDsn",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.ReadDSN = "replica.db"
+				return cfg
+			},
+			expectedErr: "table must specify both readDsn and writeDsn",
+		},
+		{
+			description: "readDsn/writeDsn and DSN",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.DSN = "primary.db"
+				cfg.ReadDSN = "replica.db"
+				cfg.WriteDSN = "primary.db"
+				return cfg
+			},
+			expectedErr: "table cannot specify readDsn/writeDsn and other connection parameters",
+		},
+		{
+			description: "option file",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.Driver = "mysql"
+				cfg.OptionFile = "~/.my.cnf"
+				cfg.Host = "db1"
+				return cfg
+			},
+		},
+		{
+			description: "option file for sqlite3",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.OptionFile = "~/.my.cnf"
+				return cfg
+			},
+			expectedErr: "only mysql tables can use an optionFile",
+		},
+		{
+			description: "option file and inline credentials",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.Driver = "mysql"
+				cfg.OptionFile = "~/.my.cnf"
+				cfg.Password = "hunter2"
+				return cfg
+			},
+			expectedErr: "table cannot specify optionFile and a DSN or inline credentials",
+		},
+		{
+			description: "valid location",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.Location = "America/New_York"
+				return cfg
+			},
+		},
+		{
+			description: "invalid location",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.Location = "Mars/Olympus_Mons"
+				return cfg
+			},
+			expectedErr: "table has invalid location 'Mars/Olympus_Mons'",
+		},
+		{
+			description: "valid mysql DSN",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.Driver = "mysql"
+				cfg.DSN = "root:secret@tcp(localhost:3306)/app?parseTime=true"
+				return cfg
+			},
+		},
+		{
+			description: "malformed mysql DSN",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.Driver = "mysql"
+				cfg.DSN = "root:secret@tcp(localhost:3306/app"
+				return cfg
+			},
+			expectedErr: "table has malformed dsn: invalid DSN: network address not terminated",
+		},
+		{
+			description: "malformed mysql writeDsn",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.Driver = "mysql"
+				cfg.ReadDSN = "root@tcp(replica:3306)/app"
+				cfg.WriteDSN = "root@tcp(primary:3306)"
+				return cfg
+			},
+			expectedErr: "table has malformed writeDsn",
+		},
+		{
+			description: "unparsed sqlite3 DSN",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.DSN = "not a mysql dsn"
+				return cfg
+			},
+		},
+		{
+			description: "empty init SQL statement",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.InitSQL = []string{"SET SESSION sql_mode = ''", " "}
+				return cfg
+			},
+			expectedErr: "table has an empty initSql statement",
+		},
+		{
+			description: "direct statement mode",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.StatementMode = "direct"
+				return cfg
+			},
+		},
+		{
+			description: "invalid statement mode",
+			table: func() TableConfig {
+				cfg := validTable()
+				cfg.StatementMode = "inline"
+				return cfg
+			},
+			expectedErr: "table has invalid statementMode 'inline' (must be 'prepared' or 'direct')",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -731,3 +1990,188 @@ func TestValidateTableConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestLocationDSN(t *testing.T) {
+	// Without a location, the DSN is unchanged
+	dsn, err := TableConfig{Driver: "mysql"}.locationDSN("root@tcp(localhost:3306)/app")
+	require.NoError(t, err)
+	assert.Equal(t, "root@tcp(localhost:3306)/app", dsn)
+
+	// For mysql, the session's time_zone is set (overriding one that is already in the DSN)
+	cfg := TableConfig{Driver: "mysql", Location: "America/New_York"}
+	dsn, err = cfg.locationDSN("root@tcp(localhost:3306)/app?time_zone=%27%2B09%3A00%27")
+	require.NoError(t, err)
+
+	mysqlConfig, err := mysql.ParseDSN(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "'America/New_York'", mysqlConfig.Params["time_zone"])
+	assert.Equal(t, "America/New_York", mysqlConfig.Loc.String())
+	assert.Equal(t, "app", mysqlConfig.DBName)
+
+	// For sqlite3, the location that times are read in is set
+	cfg = TableConfig{Driver: "sqlite3", Location: "Asia/Tokyo"}
+	dsn, err = cfg.locationDSN("file:app.db?mode=memory")
+	require.NoError(t, err)
+	assert.Equal(t, "file:app.db?mode=memory&_loc=Asia%2FTokyo", dsn)
+
+	dsn, err = cfg.locationDSN("app.db")
+	require.NoError(t, err)
+	assert.Equal(t, "app.db?_loc=Asia%2FTokyo", dsn)
+
+	cfg.Location = "Mars/Olympus_Mons"
+	_, err = cfg.locationDSN("app.db")
+	assert.ErrorContains(t, err, "invalid location 'Mars/Olympus_Mons'")
+}
+
+func TestResolveDSN_option_file(t *testing.T) {
+	optionFile := filepath.Join(t.TempDir(), "my.cnf")
+	require.NoError(t, os.WriteFile(optionFile, []byte(`
+# Credentials for the app
+[mysql]
+user = wrong
+
+[client]
+user = app_user
+password = "p#ss word"
+host = db1.internal # The primary
+Port=3307
+ssl
+!includedir /etc/mysql/conf.d/
+`), 0600))
+
+	cfg := TableConfig{Driver: "mysql", DB: "app", OptionFile: optionFile}
+	dsn, err := cfg.resolveDSN()
+	require.NoError(t, err)
+
+	mysqlConfig, err := mysql.ParseDSN(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "app_user", mysqlConfig.User)
+	assert.Equal(t, "p#ss word", mysqlConfig.Passwd)
+	assert.Equal(t, "db1.internal:3307", mysqlConfig.Addr)
+	assert.Equal(t, "app", mysqlConfig.DBName)
+
+	// The table's own host and port take precedence over the option file's
+	cfg.Host = "db2.internal"
+	cfg.Port = 3306
+	dsn, err = cfg.resolveDSN()
+	require.NoError(t, err)
+
+	mysqlConfig, err = mysql.ParseDSN(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "app_user", mysqlConfig.User)
+	assert.Equal(t, "db2.internal:3306", mysqlConfig.Addr)
+
+	cfg.OptionFile = filepath.Join(t.TempDir(), "missing.cnf")
+	_, err = cfg.resolveDSN()
+	assert.ErrorContains(t, err, "failed to read optionFile")
+}
+
+func TestLoadConfig_hosts_file(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "inventory.yaml"), []byte(`
+        db1:
+          label: primary
+          driver: mysql
+          user: syncer
+          password: secret
+          port: 3306
+        db2:
+          label: replica
+          driver: mysql
+          user: syncer
+          password: other_secret
+          port: 3307
+    `), 0o644))
+
+	writeConfig := func(contents string) string {
+		filename := filepath.Join(dir, "config.yaml")
+		require.NoError(t, os.WriteFile(filename, []byte(contents), 0o644))
+		return filename
+	}
+
+	t.Run("hosts are loaded from the hosts file", func(t *testing.T) {
+		cfg, err := LoadConfig(writeConfig(`
+            defaults:
+              hostsFile: inventory.yaml
+              hosts:
+                db3:
+                  label: analytics
+                  driver: mysql
+            jobs:
+              users:
+                columns: [id, name]
+                source:
+                  host: db1
+                  table: users
+                targets:
+                  - host: db2
+                  - host: db3
+        `))
+		require.NoError(t, err)
+
+		assert.Len(t, cfg.Defaults.Hosts, 3)
+
+		job := cfg.Jobs["users"]
+		assert.Equal(t, "primary", job.Source.Label)
+		assert.Equal(t, "syncer", job.Source.User)
+		assert.Equal(t, "secret", job.Source.Password)
+		assert.Equal(t, "replica", job.Targets[0].Label)
+		assert.Equal(t, 3307, job.Targets[0].Port)
+		assert.Equal(t, "analytics", job.Targets[1].Label)
+	})
+
+	t.Run("referenced host doesn't exist", func(t *testing.T) {
+		_, err := LoadConfig(writeConfig(`
+            defaults:
+              hostsFile: inventory.yaml
+            jobs:
+              users:
+                columns: [id, name]
+                source:
+                  host: db1
+                  table: users
+                targets:
+                  - host: db9
+        `))
+		assert.EqualError(
+			t, err, "job 'users': target[0]: host 'db9' is not defined in defaults.hosts or hostsFile",
+		)
+	})
+
+	t.Run("host defined in both places", func(t *testing.T) {
+		_, err := LoadConfig(writeConfig(`
+            defaults:
+              hostsFile: inventory.yaml
+              hosts:
+                db1:
+                  label: primary
+            jobs:
+              users:
+                columns: [id, name]
+                source:
+                  host: db1
+                  table: users
+                targets:
+                  - host: db2
+        `))
+		assert.EqualError(t, err, "host 'db1' is defined in both defaults.hosts and hostsFile")
+	})
+
+	t.Run("missing hosts file", func(t *testing.T) {
+		_, err := LoadConfig(writeConfig(`
+            defaults:
+              hostsFile: missing.yaml
+            jobs:
+              users:
+                columns: [id, name]
+                source:
+                  host: db1
+                  table: users
+                targets:
+                  - host: db2
+        `))
+		assert.ErrorContains(t, err, "failed to read hostsFile 'missing.yaml'")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
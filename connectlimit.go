@@ -0,0 +1,78 @@
+package sync
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// connectLimiter limits how many connection attempts are in progress at once to each database
+// server (endpoint), across every table that connects to it, so that many targets on the same
+// server don't all open their connection pools at once. A nil connectLimiter has no limit
+type connectLimiter struct {
+	limit int
+
+	mu   sync.Mutex
+	sems map[string]semaphore // By endpoint (see dsnEndpoint)
+}
+
+// newConnectLimiter creates a connectLimiter that allows up to limit connection attempts to each
+// endpoint at once. If limit is not positive, there is no limit
+func newConnectLimiter(limit int) *connectLimiter {
+	if limit <= 0 {
+		return nil
+	}
+
+	return &connectLimiter{limit: limit, sems: map[string]semaphore{}}
+}
+
+// endpointSemaphore returns the semaphore for the given endpoint, creating it if this is the first
+// connection attempt to it
+func (l *connectLimiter) endpointSemaphore(endpoint string) semaphore {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	sem, ok := l.sems[endpoint]
+	if !ok {
+		sem = newSemaphore(l.limit)
+		l.sems[endpoint] = sem
+	}
+
+	return sem
+}
+
+// open calls open once there is room for another connection attempt to the DSN's endpoint
+func (l *connectLimiter) open(
+	driver, dsn string,
+	open func() (*sqlx.DB, error),
+) (*sqlx.DB, error) {
+	sem := l.endpointSemaphore(dsnEndpoint(driver, dsn))
+	sem.acquire()
+	defer sem.release()
+
+	return open()
+}
+
+// dsnEndpoint returns the database server that the DSN connects to: for mysql, its resolved
+// host:port (or unix socket), and for sqlite3, its database file. For other drivers (or a DSN that
+// can't be parsed), the whole DSN is the endpoint
+func dsnEndpoint(driver, dsn string) string {
+	switch driver {
+	case "mysql":
+		// Parsing fills in the default host and port (e.g. 127.0.0.1:3306) if they are omitted
+		if mysqlConfig, err := mysql.ParseDSN(dsn); err == nil {
+			return mysqlConfig.Addr
+		}
+	case "sqlite3":
+		path, _, _ := strings.Cut(dsn, "?")
+		return strings.TrimPrefix(path, "file:")
+	}
+
+	return dsn
+}
@@ -0,0 +1,157 @@
+package sync
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectLimiter(t *testing.T) {
+	limiter := newConnectLimiter(2)
+
+	// Tracks the most connection attempts that were in progress at once, per endpoint
+	var mu sync.Mutex
+	inProgress := map[string]int{}
+	maxInProgress := map[string]int{}
+
+	attempt := func(endpoint string) func() (*sqlx.DB, error) {
+		return func() (*sqlx.DB, error) {
+			mu.Lock()
+			inProgress[endpoint]++
+			maxInProgress[endpoint] = max(maxInProgress[endpoint], inProgress[endpoint])
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			inProgress[endpoint]--
+			mu.Unlock()
+
+			return nil, nil
+		}
+	}
+
+	// Many tables on two servers (with different users and databases) connect at once
+	var wg sync.WaitGroup
+	for i := range 12 {
+		host := []string{"db1", "db2"}[i%2]
+		dsn := fmt.Sprintf("user%d:password@tcp(%s:3306)/db%d", i, host, i)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := limiter.open("mysql", dsn, attempt(host))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Each server has its own limit, so both of them had attempts in progress at once
+	assert.Equal(t, map[string]int{"db1": 2, "db2": 2}, maxInProgress)
+
+	// Without a limit, every attempt is made at once
+	maxInProgress = map[string]int{}
+	var unlimited *connectLimiter
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := unlimited.open("mysql", "tcp(db1:3306)/db", attempt("db1"))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Greater(t, maxInProgress["db1"], 2)
+}
+
+func TestDSNEndpoint(t *testing.T) {
+	tests := []struct {
+		driver   string
+		dsn      string
+		expected string
+	}{
+		{"mysql", "user:password@tcp(db1:3306)/app", "db1:3306"},
+		{"mysql", "other:secret@tcp(db1:3306)/other?parseTime=true", "db1:3306"},
+		{"mysql", "user:password@tcp(db1)/app", "db1:3306"}, // The default port
+		{"mysql", "user:password@/app", "127.0.0.1:3306"},   // The default host
+		{"mysql", "user@unix(/tmp/mysql.sock)/app", "/tmp/mysql.sock"},
+		{"sqlite3", "file:users.db?mode=memory&cache=shared", "users.db"},
+		{"sqlite3", "/var/lib/app.db", "/var/lib/app.db"},
+		{"noop", "in-sync", "in-sync"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, dsnEndpoint(test.driver, test.dsn), test.dsn)
+	}
+}
+
+func TestExecJob_max_connect_attempts_per_host(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)"
+
+	sourceDSN := "file:connect_limit_source.db?mode=memory&cache=shared"
+	source := sqlx.MustConnect("sqlite3", sourceDSN)
+	defer source.Close()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	// Every target is in the same database, so they share an endpoint
+	targetDSN := "file:connect_limit_target.db?mode=memory&cache=shared"
+	target := sqlx.MustConnect("sqlite3", targetDSN)
+	defer target.Close()
+
+	var targets []TableConfig
+	for i := range 4 {
+		table := fmt.Sprintf("users%d", i)
+		target.MustExec(fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s (id INTEGER PRIMARY KEY, name TEXT)", table,
+		))
+		targets = append(targets, TableConfig{Driver: "sqlite3", DSN: targetDSN, Table: table})
+	}
+
+	job := JobConfig{
+		PrimaryKeys:    []string{"id"},
+		Columns:        []string{"id", "name"},
+		Source:         TableConfig{Driver: "sqlite3", DSN: sourceDSN, Table: "users"},
+		Targets:        targets,
+		MaxConcurrency: 4,
+		connectLimits:  newConnectLimiter(1),
+	}
+
+	// While something else is connecting to the targets' database, none of them can connect
+	sem := job.connectLimits.endpointSemaphore("connect_limit_target.db")
+	sem.acquire()
+
+	var done atomic.Bool
+	var result ExecJobResult
+	var err error
+	go func() {
+		result, err = job.syncTargets(nil)
+		done.Store(true)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, done.Load(), "targets connected while their endpoint was at its limit")
+
+	var count int
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users0"))
+	assert.Equal(t, 0, count)
+
+	// Once it is done, the targets connect (one at a time) and are synced
+	sem.release()
+	require.Eventually(t, done.Load, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, result.Results, 4)
+
+	for i, r := range result.Results {
+		require.NoError(t, r.Error)
+		require.NoError(t, target.Get(&count, fmt.Sprintf("SELECT COUNT(*) FROM users%d", i)))
+		assert.Equal(t, 2, count)
+	}
+}
@@ -1,9 +1,15 @@
 package sync
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	sq "github.com/Masterminds/squirrel"
 	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
@@ -13,46 +19,380 @@ type table struct {
 	*sqlx.DB
 	config TableConfig
 
+	writeDB *sqlx.DB // If the table has a read/write split, the connection that is written to
+	tx      *sqlx.Tx // If set, the transaction that the table is read and written in
+
+	peakInUse *connectionPeak // If set, records the most connections that were in use at once
+
 	primaryKeys       []string
 	primaryKeyIndices []int // Indices of the primary keys in the Columns slice
 	columns           []string
+	checksumIndices   []int // Indices of the columns that are checksummed (nil means all of them)
+	comparison        comparison
+	maxMemoryBytes    int64 // If positive, the estimated memory limit for the table's entries
+
+	spillThresholdRows int    // If positive, the number of rows after which a target's are spilled
+	spillDir           string // The directory that spilled rows are stored in (see spilledRows)
+
+	typeHints map[string]string // Types to coerce column values to (see coerceRow)
+	batchSize int               // The number of rows to insert per statement (see insertBatchSize)
+
+	insertIgnore bool // Whether inserts skip rows whose key is already in the table (see insert)
+	typedScan    bool // Whether rows are scanned with their columns' types (see typedScanner)
+
+	emptyHandling map[string]string // How to treat empty strings and NULLs (see convertEmpty)
+
+	encryption *tableEncryption // If set, how the table's encrypted columns are handled
+
+	shared *sharedConnections // If set, the table's connection pool is shared with other tables
+
+	// If set, limits how many connections are being opened to the table's database server at once
+	connectLimits *connectLimiter
+
+	tracing tracer // Creates the spans of the table's sync (see Config.Tracer)
+
+	where     sq.Sqlizer // Optional predicate that restricts which rows are read
+	indexHint string     // Optional index hint (e.g. `FORCE INDEX (PRIMARY)`) for reading the rows
 }
 
+// noopDriver is a fake driver for testing pipelines (job wiring, CLI output, etc.) without
+// provisioning a real target database. A noop table never executes any SQL. Its DSN selects its
+// mode: "in-sync" (the default) always matches the source, and "empty" always needs every row
+const noopDriver = "noop"
+
+const (
+	noopModeInSync = "in-sync"
+	noopModeEmpty  = "empty"
+)
+
 func (t *table) connect() error {
 	if t.DB != nil {
 		return nil // Already connected
 	}
 
-	dsn := t.config.DSN
+	if t.isNoop() {
+		return nil // There is nothing to connect to
+	}
 
-	if dsn == "" {
-		// If DSN is not directly provided, construct it from the other fields
-		if t.config.Driver == "mysql" {
-			cfg := mysql.NewConfig()
+	if err := t.open(); err != nil {
+		return &ConnectError{Target: t.config, Err: err}
+	}
 
-			cfg.User = t.config.User
-			cfg.Passwd = t.config.Password
-			cfg.Addr = fmt.Sprintf("%s:%d", t.config.Host, t.config.Port)
-			cfg.DBName = t.config.DB
-			cfg.Net = "tcp"
+	return nil
+}
+
+// open opens the table's connection pool(s)
+func (t *table) open() error {
+	// Reads use the embedded connection, and writes use a separate one
+	if t.config.ReadDSN != "" {
+		readDSN, err := t.config.locationDSN(t.config.ReadDSN)
+		if err != nil {
+			return err
+		}
+
+		writeDSN, err := t.config.locationDSN(t.config.WriteDSN)
+		if err != nil {
+			return err
+		}
+
+		db, err := t.openDB(readDSN)
+		if err != nil {
+			return err
+		}
 
-			dsn = cfg.FormatDSN()
-		} else if t.config.Driver == "sqlite3" {
-			return fmt.Errorf("for sqlite3, DSN must be provided directly")
-		} else {
-			return fmt.Errorf("unsupported driver: %s", t.config.Driver)
+		t.writeDB, err = t.openDB(writeDSN)
+		if err != nil {
+			db.Close()
+			return err
 		}
+
+		t.DB = db
+		return nil
 	}
 
-	var err error
-	t.DB, err = sqlx.Connect(t.config.Driver, dsn)
+	dsn, err := t.config.resolveDSN()
 	if err != nil {
 		return err
 	}
 
-	t.DB.SetMaxOpenConns(5)
-	t.DB.SetMaxIdleConns(5)
-	t.DB.SetConnMaxLifetime(5 * time.Minute)
+	dsn, err = t.config.locationDSN(dsn)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	// Reuse a connection pool that is shared with other tables in the same database
+	if t.shared != nil {
+		t.DB, err = t.connectLimits.open(t.config.Driver, dsn, func() (*sqlx.DB, error) {
+			return t.shared.connect(t.config.Driver, dsn, t.config.InitSQL)
+		})
+		return err
+	}
+
+	t.DB, err = t.openDB(dsn)
+	return err
+}
+
+// openDB opens a new connection pool to the DSN with the table's init SQL, once there is room for
+// another connection attempt to its database server (see Config.MaxConnectAttemptsPerHost)
+func (t table) openDB(dsn string) (*sqlx.DB, error) {
+	return t.connectLimits.open(t.config.Driver, dsn, func() (*sqlx.DB, error) {
+		return openDB(t.config.Driver, dsn, t.config.InitSQL)
+	})
+}
+
+// resolveDSN returns the table's DSN, constructing it from the other connection parameters if it
+// isn't provided directly
+func (cfg TableConfig) resolveDSN() (string, error) {
+	if cfg.DSN != "" {
+		return cfg.DSN, nil
+	}
+
+	if cfg.Driver == "mysql" {
+		if cfg.OptionFile != "" {
+			var err error
+			cfg, err = cfg.withOptionFile()
+			if err != nil {
+				return "", err
+			}
+		}
+
+		mysqlConfig := mysql.NewConfig()
+
+		mysqlConfig.User = cfg.User
+		mysqlConfig.Passwd = cfg.Password
+		mysqlConfig.Addr = fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+		mysqlConfig.DBName = cfg.DB
+		mysqlConfig.Net = "tcp"
+
+		return mysqlConfig.FormatDSN(), nil
+	} else if cfg.Driver == "sqlite3" {
+		return "", fmt.Errorf("for sqlite3, DSN must be provided directly")
+	}
+
+	return "", fmt.Errorf("unsupported driver: %s", cfg.Driver)
+}
+
+// locationDSN adds the table's Location (if it has one) to the DSN. For mysql, it sets the
+// session's time_zone, and the location that time.Time arguments are converted to. For sqlite3, it
+// sets the location that times are read in
+func (cfg TableConfig) locationDSN(dsn string) (string, error) {
+	if cfg.Location == "" {
+		return dsn, nil
+	}
+
+	loc, err := time.LoadLocation(cfg.Location)
+	if err != nil {
+		return "", fmt.Errorf("invalid location '%s': %w", cfg.Location, err)
+	}
+
+	switch cfg.Driver {
+	case "mysql":
+		mysqlConfig, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return "", err
+		}
+
+		if mysqlConfig.Params == nil {
+			mysqlConfig.Params = map[string]string{}
+		}
+
+		// Session variables are set as-is, so the time zone has to be quoted
+		mysqlConfig.Params["time_zone"] = fmt.Sprintf("'%s'", cfg.Location)
+		mysqlConfig.Loc = loc
+
+		return mysqlConfig.FormatDSN(), nil
+	case "sqlite3":
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+
+		return dsn + separator + "_loc=" + url.QueryEscape(cfg.Location), nil
+	}
+
+	return dsn, nil
+}
+
+// openDB opens a new connection pool. If there is init SQL, each of the pool's connections
+// executes it when it is opened
+func openDB(driver, dsn string, initSQL []string) (*sqlx.DB, error) {
+	var db *sqlx.DB
+	if len(initSQL) == 0 {
+		var err error
+		if db, err = sqlx.Connect(driver, dsn); err != nil {
+			return nil, err
+		}
+	} else {
+		sqlDB, err := openInitDB(driver, dsn, initSQL)
+		if err != nil {
+			return nil, err
+		}
+
+		db = sqlx.NewDb(sqlDB, driver)
+		if err := db.Ping(); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	db.SetMaxOpenConns(5)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	return db, nil
+}
+
+// disconnect closes the table's connection pool (if it has one). A shared connection pool is left
+// open for the other tables that use it
+func (t table) disconnect() error {
+	if t.DB == nil || t.shared != nil {
+		return nil
+	}
+
+	if t.writeDB != nil {
+		if err := t.writeDB.Close(); err != nil {
+			t.Close()
+			return err
+		}
+	}
+
+	return t.Close()
+}
+
+// writer returns the connection that statements are executed with
+func (t table) writer() *sqlx.DB {
+	if t.writeDB != nil {
+		return t.writeDB
+	}
+
+	return t.DB
+}
+
+// context returns the context that the table's queries and statements are executed with (see
+// ExecJobContext)
+func (t table) context() context.Context {
+	return t.tracing.context()
+}
+
+// reader returns what the table's rows are read with: its transaction, if it has one
+func (t table) reader() sqlx.QueryerContext {
+	if t.tx != nil {
+		return t.tx
+	}
+
+	return t.DB
+}
+
+// runner returns what statements are executed with: the table's transaction, if it has one
+func (t table) runner() sqlx.ExecerContext {
+	if t.tx != nil {
+		return t.tx
+	}
+
+	return t.writer()
+}
+
+// connectionPeak is the most of a table's connections that were in use at once (see
+// SyncResult.PeakInUseConnections)
+type connectionPeak struct {
+	mu    sync.Mutex
+	inUse int
+}
+
+// get returns the peak. A nil peak is 0
+func (p *connectionPeak) get() int {
+	if p == nil {
+		return 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.inUse
+}
+
+// samplePool records how many of the table's connections are in use (across both of its pools,
+// if it has a read/write split), if it's the most so far. It's sampled while the table's rows are
+// being read and its statements are being executed, when their connections are in use
+func (t table) samplePool() {
+	if t.peakInUse == nil || t.DB == nil {
+		return
+	}
+
+	inUse := t.Stats().InUse
+	if t.writeDB != nil {
+		inUse += t.writeDB.Stats().InUse
+	}
+
+	t.peakInUse.mu.Lock()
+	defer t.peakInUse.mu.Unlock()
+	t.peakInUse.inUse = max(t.peakInUse.inUse, inUse)
+}
+
+// Statement modes (see TableConfig.StatementMode)
+const (
+	statementModePrepared = "prepared"
+	statementModeDirect   = "direct"
+)
+
+// exec executes the statement with the table's runner. In direct mode, the statement's values are
+// inlined into its SQL as escaped literals, so it is executed without any placeholders
+func (t table) exec(statement sq.Sqlizer) (sql.Result, error) {
+	defer t.samplePool() // A transaction's connection is still in use once a statement is executed
+
+	if t.config.StatementMode != statementModeDirect {
+		query, args, err := statement.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		return t.runner().ExecContext(t.context(), query, args...)
+	}
+
+	rendered, err := renderStatement(t.config.Driver, statement)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.runner().ExecContext(t.context(), rendered)
+}
+
+// columnNames returns the names of the table's columns, as reported by the database
+func (t table) columnNames() ([]string, error) {
+	// This doesn't read any rows, so unlike syncing, it's fine to select every column
+	query := fmt.Sprintf("SELECT * FROM %s LIMIT 0", t.config.Table)
+	rows, err := t.reader().QueryxContext(t.context(), query)
+	if err != nil {
+		return nil, &SchemaError{Target: t.config, Err: err}
+	}
+	defer rows.Close()
+
+	return rows.Columns()
+}
+
+func (t table) isNoop() bool {
+	return t.config.Driver == noopDriver
+}
+
+// quoteIdentifier quotes a table or column name so it can be safely embedded in a SQL statement.
+// sqlite also uses backticks, since it treats a double-quoted identifier that doesn't exist as a
+// string literal (rather than an error)
+func quoteIdentifier(driver, name string) string {
+	if driver == "mysql" || driver == "sqlite3" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteColumns quotes each of the column names for the table's driver
+func (t table) quoteColumns(columns []string) []string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdentifier(t.config.Driver, col)
+	}
+	return quoted
+}
+
+// quoteString quotes a string literal so it can be safely embedded in a SQL statement
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
 }
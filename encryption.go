@@ -0,0 +1,199 @@
+package sync
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// columnCipher deterministically encrypts and decrypts column values. Each value is encrypted with
+// AES-GCM, using a nonce that is derived from an HMAC of the column and the value (like AES-SIV),
+// so the same value always encrypts to the same ciphertext. That keeps the diffs and checksums of
+// encrypted targets stable, so syncing them is idempotent. The column is also authenticated, so a
+// value can't be moved to another column. Encrypted values are base64 text
+type columnCipher struct {
+	aead   cipher.AEAD
+	macKey []byte
+}
+
+// encryptionKeySize is the size (in bytes) of the key that the encryption keys are derived from
+const encryptionKeySize = 32
+
+// newColumnCipher creates a cipher with the base64-encoded key in the given environment variable
+func newColumnCipher(keyEnv string) (*columnCipher, error) {
+	encoded := os.Getenv(keyEnv)
+	if encoded == "" {
+		return nil, fmt.Errorf("encryption key environment variable '%s' is not set", keyEnv)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != encryptionKeySize {
+		return nil, fmt.Errorf(
+			"encryption key in '%s' must be %d base64-encoded bytes", keyEnv, encryptionKeySize,
+		)
+	}
+
+	// Separate keys are derived for encrypting and for deriving nonces
+	block, err := aes.NewCipher(deriveKey(key, "encrypt"))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &columnCipher{aead: aead, macKey: deriveKey(key, "nonce")}, nil
+}
+
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// encrypt encrypts a column's value. NULL is left as-is, and other values are encrypted as text
+func (c *columnCipher) encrypt(column string, val any) any {
+	if val == nil {
+		return nil
+	}
+
+	plaintext := []byte(stringOf(val))
+
+	mac := hmac.New(sha256.New, c.macKey)
+	mac.Write([]byte(column))
+	mac.Write([]byte{0}) // Separates the column from the value
+	mac.Write(plaintext)
+	nonce := mac.Sum(nil)[:c.aead.NonceSize()]
+
+	sealed := c.aead.Seal(slices.Clone(nonce), nonce, plaintext, []byte(column))
+	return base64.StdEncoding.EncodeToString(sealed)
+}
+
+// decrypt decrypts a column's value that was encrypted by encrypt. NULL is left as-is
+func (c *columnCipher) decrypt(column string, val any) (any, error) {
+	if val == nil {
+		return nil, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(stringOf(val))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("column '%s' has a value that isn't encrypted", column)
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, []byte(column))
+	if err != nil {
+		return nil, fmt.Errorf("column '%s' has a value that can't be decrypted (wrong key?)", column)
+	}
+
+	return string(plaintext), nil
+}
+
+// tableEncryption is how a table's encrypted columns (see TableConfig.Encrypted) are handled
+type tableEncryption struct {
+	keyEnv  string
+	columns []string
+
+	// decrypt is true if the columns are decrypted when they are read (i.e. the table is a source).
+	// Otherwise, they are read as-is, and the source's values are encrypted to compare with them
+	decrypt bool
+}
+
+// newTableEncryption returns how the table's encrypted columns are handled, or nil if it doesn't
+// have any
+func (job JobConfig) newTableEncryption(config TableConfig, decrypt bool) *tableEncryption {
+	if !config.Encrypted || len(job.EncryptColumns) == 0 {
+		return nil
+	}
+
+	return &tableEncryption{
+		keyEnv:  job.EncryptionKeyEnv,
+		columns: job.EncryptColumns,
+		decrypt: decrypt,
+	}
+}
+
+// readRows returns a function that converts the table's rows (in place) as they are read. An
+// encrypted source's values are decrypted, and an encrypted target's values are read as text
+func (t table) readRows() (func(row []any) error, error) {
+	if t.encryption == nil {
+		return func([]any) error { return nil }, nil
+	}
+
+	indices := t.encryptedIndices()
+
+	if !t.encryption.decrypt {
+		return func(row []any) error {
+			for _, i := range indices {
+				if b, ok := row[i].([]byte); ok {
+					row[i] = string(b)
+				}
+			}
+			return nil
+		}, nil
+	}
+
+	c, err := newColumnCipher(t.encryption.keyEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(row []any) error {
+		for _, i := range indices {
+			val, err := c.decrypt(t.columns[i], row[i])
+			if err != nil {
+				return fmt.Errorf("table '%s': %w", t.config.Table, err)
+			}
+			row[i] = val
+		}
+		return nil
+	}, nil
+}
+
+// encryptSource returns the source's rows with the target's encrypted columns encrypted, so they
+// can be compared with (and written to) the target as-is
+func (t table) encryptSource(source tableData) (tableData, error) {
+	c, err := newColumnCipher(t.encryption.keyEnv)
+	if err != nil {
+		return tableData{}, err
+	}
+
+	indices := t.encryptedIndices()
+
+	entries := make([][]any, len(source.entries))
+	entryMap := make(map[primaryKeyTuple][]any, len(source.entries))
+	for i, row := range source.entries {
+		encrypted := slices.Clone(row)
+		for _, idx := range indices {
+			encrypted[idx] = c.encrypt(t.columns[idx], row[idx])
+		}
+
+		entries[i] = encrypted
+		entryMap[t.keyOf(encrypted)] = encrypted
+	}
+
+	checksum, err := t.checksum(entries)
+	if err != nil {
+		return tableData{}, err
+	}
+
+	return tableData{checksum: checksum, entries: entries, entryMap: entryMap}, nil
+}
+
+// encryptedIndices returns the indices of the table's encrypted columns
+func (t table) encryptedIndices() []int {
+	var indices []int
+	for i, column := range t.columns {
+		if slices.Contains(t.encryption.columns, column) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
@@ -0,0 +1,177 @@
+package sync
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEncryptionKey returns a base64-encoded encryption key that repeats the given character
+func testEncryptionKey(char string) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(char, encryptionKeySize)))
+}
+
+func TestColumnCipher(t *testing.T) {
+	t.Setenv("COLUMN_CIPHER_KEY", testEncryptionKey("k"))
+	t.Setenv("COLUMN_CIPHER_OTHER_KEY", testEncryptionKey("o"))
+	t.Setenv("COLUMN_CIPHER_SHORT_KEY", base64.StdEncoding.EncodeToString([]byte("short")))
+
+	c, err := newColumnCipher("COLUMN_CIPHER_KEY")
+	require.NoError(t, err)
+
+	// The same value always encrypts to the same ciphertext, but not in other columns
+	encrypted := c.encrypt("email", "alice@example.com")
+	assert.Equal(t, encrypted, c.encrypt("email", []byte("alice@example.com")))
+	assert.NotEqual(t, encrypted, c.encrypt("email", "bob@example.com"))
+	assert.NotEqual(t, encrypted, c.encrypt("name", "alice@example.com"))
+	assert.NotContains(t, encrypted, "alice")
+
+	decrypted, err := c.decrypt("email", encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", decrypted)
+
+	// Non-text values are encrypted as text, and NULLs aren't encrypted
+	decrypted, err = c.decrypt("age", c.encrypt("age", int64(42)))
+	require.NoError(t, err)
+	assert.Equal(t, "42", decrypted)
+	assert.Nil(t, c.encrypt("email", nil))
+
+	// A value can't be decrypted in another column, with another key, or if it isn't encrypted
+	_, err = c.decrypt("name", encrypted)
+	assert.ErrorContains(t, err, "can't be decrypted")
+
+	other, err := newColumnCipher("COLUMN_CIPHER_OTHER_KEY")
+	require.NoError(t, err)
+	_, err = other.decrypt("email", encrypted)
+	assert.ErrorContains(t, err, "can't be decrypted")
+
+	_, err = c.decrypt("email", "alice@example.com")
+	assert.ErrorContains(t, err, "isn't encrypted")
+
+	_, err = newColumnCipher("COLUMN_CIPHER_MISSING_KEY")
+	assert.ErrorContains(t, err, "is not set")
+
+	_, err = newColumnCipher("COLUMN_CIPHER_SHORT_KEY")
+	assert.ErrorContains(t, err, "must be 32 base64-encoded bytes")
+}
+
+func TestExecJob_encrypted(t *testing.T) {
+	t.Setenv("EXEC_JOB_ENCRYPTION_KEY", testEncryptionKey("k"))
+
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			email TEXT
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_encrypted_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO users (id, name, email)
+		VALUES (1, 'Alice', 'alice@example.com'), (2, 'Bob', NULL), (3, 'Charlie', 'c@example.com')
+	`)
+
+	encryptedConfig := TableConfig{
+		Driver:    "sqlite3",
+		Table:     "users",
+		DSN:       "file:exec_job_encrypted_target.db?mode=memory&cache=shared",
+		Encrypted: true,
+	}
+
+	encrypted := table{config: encryptedConfig}
+	encrypted.connect()
+	encrypted.MustExec(createTable)
+
+	job := JobConfig{
+		PrimaryKeys:      []string{"id"},
+		Columns:          []string{"id", "name", "email"},
+		EncryptColumns:   []string{"email"},
+		EncryptionKeyEnv: "EXEC_JOB_ENCRYPTION_KEY",
+		Source:           sourceConfig,
+		Targets:          []TableConfig{encryptedConfig},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 3, results.Results[0].NumInserts)
+
+	// The target only stores the encrypted emails, which decrypt to the source's
+	var rows []struct {
+		Name  string
+		Email *string
+	}
+	require.NoError(t, encrypted.Select(&rows, "SELECT name, email FROM users ORDER BY id"))
+	require.Len(t, rows, 3)
+	assert.Equal(t, "Alice", rows[0].Name)
+	assert.Nil(t, rows[1].Email)
+
+	c, err := newColumnCipher("EXEC_JOB_ENCRYPTION_KEY")
+	require.NoError(t, err)
+
+	require.NotNil(t, rows[0].Email)
+	assert.NotContains(t, *rows[0].Email, "alice")
+	decrypted, err := c.decrypt("email", *rows[0].Email)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", decrypted)
+
+	// Since the encryption is deterministic, syncing again doesn't change anything
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.False(t, results.Results[0].Synced)
+
+	// An update is encrypted too
+	source.MustExec(`UPDATE users SET email = 'bob@example.com' WHERE id = 2`)
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 1, results.Results[0].NumUpdates)
+
+	var email string
+	require.NoError(t, encrypted.Get(&email, "SELECT email FROM users WHERE id = 2"))
+	assert.Equal(t, c.encrypt("email", "bob@example.com"), email)
+
+	// An encrypted source is decrypted when it is read
+	plaintextConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_encrypted_plaintext.db?mode=memory&cache=shared",
+	}
+
+	plaintext := table{config: plaintextConfig}
+	plaintext.connect()
+	plaintext.MustExec(createTable)
+
+	job.Source = encryptedConfig
+	job.Targets = []TableConfig{plaintextConfig}
+	config = Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 3, results.Results[0].NumInserts)
+
+	var emails []string
+	require.NoError(t, plaintext.Select(&emails, "SELECT email FROM users ORDER BY id"))
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com", "c@example.com"}, emails)
+}
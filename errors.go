@@ -0,0 +1,59 @@
+package sync
+
+import "fmt"
+
+// ConnectError is returned when a table can't be connected to (e.g. it is unreachable, or its
+// credentials are wrong)
+type ConnectError struct {
+	Target TableConfig // The table that couldn't be connected to (which may be a job's source)
+	Err    error
+}
+
+func (e *ConnectError) Error() string {
+	return fmt.Sprintf("failed to connect: %s", e.Err)
+}
+
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaError is returned when a table can't be queried (e.g. it doesn't exist, or it is missing
+// one of the job's columns)
+type SchemaError struct {
+	Target TableConfig // The table that couldn't be queried (which may be a job's source)
+	Err    error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("failed to query table '%s': %s", e.Target.Table, e.Err)
+}
+
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// SyncError is returned when a statement that syncs a target (an INSERT, UPDATE, or DELETE) fails.
+// A target's statements are executed in a single transaction, so the ones before it are rolled back
+// (unless the job has a CheckpointFile)
+type SyncError struct {
+	Target TableConfig // The target that couldn't be written to
+	Err    error
+}
+
+func (e *SyncError) Error() string {
+	return fmt.Sprintf("failed to write to table '%s': %s", e.Target.Table, e.Err)
+}
+
+func (e *SyncError) Unwrap() error {
+	return e.Err
+}
+
+// DependencyError is the error of a job that was skipped (without being executed), because a job
+// that it depends on failed (see JobConfig.DependsOn)
+type DependencyError struct {
+	Dependency string // The job that failed (or was skipped itself)
+}
+
+func (e *DependencyError) Error() string {
+	return fmt.Sprintf("skipped, since job '%s' (which it depends on) failed", e.Dependency)
+}
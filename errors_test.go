@@ -0,0 +1,178 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectError(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:connect_error_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT)")
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice')")
+
+	// Nothing is listening on this port
+	targetConfig := TableConfig{
+		Label:  "unreachable",
+		Driver: "mysql",
+		Table:  "users",
+		Host:   "127.0.0.1",
+		Port:   1,
+		User:   "root",
+	}
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+			},
+		},
+	}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	var connectErr *ConnectError
+	require.ErrorAs(t, results.Results[0].Error, &connectErr)
+	assert.Equal(t, "unreachable", connectErr.Target.Label)
+	assert.ErrorContains(t, connectErr, "failed to connect")
+
+	// It isn't any other kind of error
+	var schemaErr *SchemaError
+	assert.False(t, errors.As(results.Results[0].Error, &schemaErr))
+
+	// Pinging the target reports the same kind of error
+	pingResults, err := config.PingJob("users", 30*time.Second)
+	require.NoError(t, err)
+	require.Len(t, pingResults, 2)
+	require.NoError(t, pingResults[0].Error)
+	require.ErrorAs(t, pingResults[1].Error, &connectErr)
+	assert.Equal(t, "unreachable", connectErr.Target.Label)
+}
+
+func TestSchemaError(t *testing.T) {
+	sourceConfig := TableConfig{
+		Label:  "primary",
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:schema_error_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT)")
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice')")
+
+	// The target doesn't have the table
+	targetConfig := TableConfig{
+		Label:  "empty",
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:schema_error_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	var schemaErr *SchemaError
+	require.ErrorAs(t, results.Results[0].Error, &schemaErr)
+	assert.Equal(t, "empty", schemaErr.Target.Label)
+	assert.ErrorContains(t, schemaErr, "no such table")
+
+	// The source is missing a column, so the whole job errors with the source as the target
+	job.Columns = []string{"id", "name", "age"}
+	config.Jobs["users"] = job
+
+	_, err = config.ExecJob("users")
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Equal(t, "primary", schemaErr.Target.Label)
+	assert.ErrorContains(t, schemaErr, "no such column")
+
+	// Pinging reports the same kind of error
+	pingResults, err := config.PingJob("users", 30*time.Second)
+	require.NoError(t, err)
+	for _, result := range pingResults {
+		require.ErrorAs(t, result.Error, &schemaErr)
+		assert.Equal(t, result.Config.Label, schemaErr.Target.Label)
+	}
+}
+
+func TestSyncError(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:sync_error_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT)")
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice')")
+
+	// The target only allows short names, so the insert fails
+	targetConfig := TableConfig{
+		Label:  "strict",
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:sync_error_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT CHECK (length(name) < 4)
+		)
+	`)
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+			},
+		},
+	}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	assert.False(t, result.Synced)
+
+	var syncErr *SyncError
+	require.ErrorAs(t, result.Error, &syncErr)
+	assert.Equal(t, "strict", syncErr.Target.Label)
+	assert.ErrorContains(t, syncErr, "CHECK constraint failed")
+}
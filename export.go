@@ -0,0 +1,104 @@
+package sync
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Supported formats for exporting a job's source data
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+)
+
+// ExportJob reads a single job's source rows and writes them to w in the given format (either
+// "csv" or "json"). CSV output has a header row of the job's columns. JSON output is an array of
+// objects keyed by column name. Rows are written in primary key order
+func (c Config) ExportJob(jobName string, w io.Writer, format string) error {
+	// Find the job with the given name
+	job, ok := c.Jobs[jobName]
+	if !ok {
+		return fmt.Errorf("job '%s' not found in config", jobName)
+	}
+
+	if format != ExportFormatCSV && format != ExportFormatJSON {
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+
+	source := job.newSourceTable()
+	if err := source.connect(); err != nil {
+		return err
+	}
+	defer source.disconnect()
+
+	entries, _, err := source.getEntries()
+	if err != nil {
+		return err
+	}
+
+	if format == ExportFormatCSV {
+		return writeCSV(w, source.columns, entries)
+	}
+
+	return writeJSON(w, source.columns, entries)
+}
+
+func writeCSV(w io.Writer, columns []string, entries [][]any) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for _, row := range entries {
+		for i, val := range row {
+			record[i] = formatExportValue(val)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeJSON(w io.Writer, columns []string, entries [][]any) error {
+	rows := make([]map[string]any, len(entries))
+	for i, row := range entries {
+		rows[i] = make(map[string]any, len(columns))
+		for j, val := range row {
+			// []byte would otherwise be base64-encoded
+			if bytes, ok := val.([]byte); ok {
+				val = string(bytes)
+			}
+
+			rows[i][columns[j]] = val
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+// formatExportValue formats a value for CSV output. NULL is written as an empty string
+func formatExportValue(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,71 @@
+package sync
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportJob(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:export_job_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			nickname TEXT
+		)
+	`)
+	source.MustExec(`INSERT INTO users VALUES (2, 'Bob', NULL), (1, 'Alice, "Al"', 'al')`)
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name", "nickname"},
+				Source:      sourceConfig,
+			},
+		},
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, config.ExportJob("users", &buf, "csv"))
+
+		expected := "id,name,nickname\n" +
+			"1,\"Alice, \"\"Al\"\"\",al\n" +
+			"2,Bob,\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, config.ExportJob("users", &buf, "json"))
+
+		expected := `[
+			{"id": 1, "name": "Alice, \"Al\"", "nickname": "al"},
+			{"id": 2, "name": "Bob", "nickname": null}
+		]`
+		assert.JSONEq(t, expected, buf.String())
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := config.ExportJob("users", &buf, "xml")
+		assert.ErrorContains(t, err, "unsupported export format: xml")
+	})
+
+	t.Run("job not found", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := config.ExportJob("pets", &buf, "csv")
+		assert.ErrorContains(t, err, "job 'pets' not found in config")
+	})
+}
@@ -0,0 +1,100 @@
+package sync
+
+import (
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// RecordHistory appends a row per target (or per job, if the job errored) to the configured
+// history table, so runs can be analyzed over time. The history table is created if it doesn't
+// exist. It is a no-op if no history table is configured
+func (c Config) RecordHistory(
+	results map[string]ExecJobResult,
+	errs map[string]error,
+	ranAt time.Time,
+) error {
+	if c.History == nil {
+		return nil
+	}
+
+	history := table{config: *c.History}
+	if err := history.connect(); err != nil {
+		return err
+	}
+	defer history.Close()
+
+	if _, err := history.Exec(history.createHistoryTable()); err != nil {
+		return err
+	}
+
+	jobNames := sortedKeys(results) // So the rows are inserted deterministically
+
+	// The table is quoted like it is when it's created, in case its name needs quoting
+	insert := sq.
+		Insert(quoteIdentifier(c.History.Driver, c.History.Table)).
+		Columns(
+			"job",
+			"ran_at",
+			"source_checksum",
+			"target",
+			"target_checksum",
+			"synced",
+			"num_changed",
+			"error",
+		)
+
+	var hasRows bool
+	for _, jobName := range jobNames {
+		result := results[jobName]
+
+		if err := errs[jobName]; err != nil {
+			insert = insert.Values(jobName, ranAt, result.Checksum, nil, nil, false, 0, err.Error())
+			hasRows = true
+			continue
+		}
+
+		for _, r := range result.Results {
+			var errStr *string
+			if r.Error != nil {
+				str := r.Error.Error()
+				errStr = &str
+			}
+
+			insert = insert.Values(
+				jobName,
+				ranAt,
+				result.Checksum,
+				r.Target.Label,
+				r.TargetChecksum,
+				r.Synced,
+				r.DriftRows(),
+				errStr,
+			)
+			hasRows = true
+		}
+	}
+
+	if !hasRows {
+		return nil
+	}
+
+	_, err := insert.RunWith(history.DB).Exec()
+	return err
+}
+
+func (t table) createHistoryTable() string {
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			job VARCHAR(255) NOT NULL,
+			ran_at DATETIME NOT NULL,
+			source_checksum VARCHAR(32) NOT NULL,
+			target VARCHAR(255),
+			target_checksum VARCHAR(32),
+			synced BOOLEAN NOT NULL,
+			num_changed INT NOT NULL,
+			error TEXT
+		)
+	`, quoteIdentifier(t.config.Driver, t.config.Table))
+}
@@ -0,0 +1,127 @@
+package sync
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordHistory(t *testing.T) {
+	historyConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "sync_history",
+		DSN:    "file:record_history.db?mode=memory&cache=shared",
+	}
+
+	// Keep a connection open so the in-memory database outlives RecordHistory's connection
+	history := table{config: historyConfig}
+	require.NoError(t, history.connect())
+	defer history.Close()
+
+	config := Config{History: &historyConfig}
+
+	results := map[string]ExecJobResult{
+		"users": {
+			Checksum: "source_checksum",
+			Results: []SyncResult{
+				{
+					Target:         TableConfig{Label: "target1"},
+					TargetChecksum: "target1_checksum",
+					Synced:         true,
+					NumInserts:     1,
+					NumUpdates:     2,
+				},
+				{
+					Target: TableConfig{Label: "target2"},
+					Error:  fmt.Errorf("connection refused"),
+				},
+			},
+		},
+		"pets": {},
+	}
+
+	errs := map[string]error{"pets": fmt.Errorf("job failed")}
+
+	ranAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, config.RecordHistory(results, errs, ranAt))
+
+	type historyRow struct {
+		Job            string
+		RanAt          time.Time `db:"ran_at"`
+		SourceChecksum string    `db:"source_checksum"`
+		Target         *string
+		TargetChecksum *string `db:"target_checksum"`
+		Synced         bool
+		NumChanged     int `db:"num_changed"`
+		Error          *string
+	}
+
+	var rows []historyRow
+	err := history.Select(&rows, "SELECT * FROM sync_history ORDER BY job, target")
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	assert.Equal(t, "pets", rows[0].Job)
+	assert.True(t, ranAt.Equal(rows[0].RanAt))
+	assert.Nil(t, rows[0].Target)
+	assert.False(t, rows[0].Synced)
+	require.NotNil(t, rows[0].Error)
+	assert.Equal(t, "job failed", *rows[0].Error)
+
+	assert.Equal(t, "users", rows[1].Job)
+	assert.Equal(t, "source_checksum", rows[1].SourceChecksum)
+	require.NotNil(t, rows[1].Target)
+	assert.Equal(t, "target1", *rows[1].Target)
+	require.NotNil(t, rows[1].TargetChecksum)
+	assert.Equal(t, "target1_checksum", *rows[1].TargetChecksum)
+	assert.True(t, rows[1].Synced)
+	assert.Equal(t, 3, rows[1].NumChanged)
+	assert.Nil(t, rows[1].Error)
+
+	assert.Equal(t, "users", rows[2].Job)
+	require.NotNil(t, rows[2].Target)
+	assert.Equal(t, "target2", *rows[2].Target)
+	assert.False(t, rows[2].Synced)
+	require.NotNil(t, rows[2].Error)
+	assert.Equal(t, "connection refused", *rows[2].Error)
+
+	// Recording another run appends to the existing table
+	require.NoError(t, config.RecordHistory(results, errs, ranAt.Add(time.Hour)))
+
+	var count int
+	require.NoError(t, history.Get(&count, "SELECT COUNT(*) FROM sync_history"))
+	assert.Equal(t, 6, count)
+}
+
+func TestRecordHistory_quoted_table(t *testing.T) {
+	historyConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "sync-history", // Needs quoting
+		DSN:    "file:record_history_quoted.db?mode=memory&cache=shared",
+	}
+
+	history := table{config: historyConfig}
+	require.NoError(t, history.connect())
+	defer history.Close()
+
+	config := Config{History: &historyConfig}
+
+	results := map[string]ExecJobResult{
+		"users": {
+			Checksum: "source_checksum",
+			Results: []SyncResult{
+				{Target: TableConfig{Label: "target1"}, TargetChecksum: "source_checksum"},
+			},
+		},
+	}
+
+	ranAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, config.RecordHistory(results, nil, ranAt))
+
+	var count int
+	require.NoError(t, history.Get(&count, "SELECT COUNT(*) FROM `sync-history`"))
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// withHostsFile returns the defaults with the hosts from their hosts file (if they have one)
+// merged into their hosts. A relative hosts file path is relative to dir (the config file's
+// directory). A host can't be defined in both places
+func (d ConfigDefaults) withHostsFile(dir string) (ConfigDefaults, error) {
+	if d.HostsFile == "" {
+		return d, nil
+	}
+
+	filename, err := expandHome(d.HostsFile)
+	if err != nil {
+		return ConfigDefaults{}, err
+	}
+
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(dir, filename)
+	}
+
+	hosts, err := readHostsFile(filename)
+	if err != nil {
+		return ConfigDefaults{}, fmt.Errorf("failed to read hostsFile '%s': %w", d.HostsFile, err)
+	}
+
+	merged := make(map[string]HostDefaults, len(d.Hosts)+len(hosts))
+	for host, defaults := range d.Hosts {
+		merged[host] = defaults
+	}
+
+	for host, defaults := range hosts {
+		if _, ok := merged[host]; ok {
+			return ConfigDefaults{}, fmt.Errorf(
+				"host '%s' is defined in both defaults.hosts and hostsFile", host,
+			)
+		}
+		merged[host] = defaults
+	}
+
+	d.Hosts = merged
+	return d, nil
+}
+
+// readHostsFile reads a hosts file, which maps hostnames to host-specific defaults (just like
+// defaults.hosts)
+func readHostsFile(filename string) (map[string]HostDefaults, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	var hosts map[string]HostDefaults
+	if err := decoder.Decode(&hosts); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return hosts, nil
+}
+
+// checkHostsExist makes sure that every host that the config's tables refer to is defined in its
+// defaults. This is only required when the config has a hosts file, since the hosts are then
+// managed separately from the jobs, so a missing one is most likely a mistake
+func (c Config) checkHostsExist() error {
+	if c.Defaults.HostsFile == "" {
+		return nil
+	}
+
+	check := func(host string) error {
+		if _, ok := c.Defaults.Hosts[host]; host != "" && !ok {
+			return fmt.Errorf("host '%s' is not defined in defaults.hosts or hostsFile", host)
+		}
+		return nil
+	}
+
+	if c.Defaults.Source != nil {
+		if err := check(c.Defaults.Source.Host); err != nil {
+			return fmt.Errorf("defaults.source: %w", err)
+		}
+	}
+
+	for i, target := range c.Defaults.Targets {
+		if err := check(target.Host); err != nil {
+			return fmt.Errorf("defaults.targets[%d]: %w", i, err)
+		}
+	}
+
+	if c.History != nil {
+		if err := check(c.History.Host); err != nil {
+			return fmt.Errorf("history: %w", err)
+		}
+	}
+
+	for _, jobName := range sortedKeys(c.Jobs) { // So the error is deterministic
+		job := c.Jobs[jobName]
+		if err := check(job.Source.Host); err != nil {
+			return fmt.Errorf("job '%s': source: %w", jobName, err)
+		}
+
+		for i, target := range job.Targets {
+			if err := check(target.Host); err != nil {
+				return fmt.Errorf("job '%s': target[%d]: %w", jobName, i, err)
+			}
+		}
+	}
+
+	return nil
+}
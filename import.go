@@ -0,0 +1,272 @@
+package sync
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ImportJob syncs a single job's targets from rows read from r in the given format (either "csv"
+// or "json"), instead of from the job's source table. CSV input must have a header row naming
+// every column, and its fields are read as strings (see parseCSVValue), which are converted to
+// numbers for the columns that are numbers in the targets (see csvTypeHints). JSON input must be
+// an array of objects keyed by column name
+func (c Config) ImportJob(jobName string, r io.Reader, format string) (ExecJobResult, error) {
+	// Find the job with the given name
+	job, ok := c.Jobs[jobName]
+	if !ok {
+		return ExecJobResult{}, fmt.Errorf("job '%s' not found in config", jobName)
+	}
+
+	if format != ExportFormatCSV && format != ExportFormatJSON {
+		return ExecJobResult{}, fmt.Errorf("unsupported import format: %s", format)
+	}
+
+	// Attaching would read from the source table rather than the file
+	job.AttachSQLite = false
+
+	// The persisted state is of the source table, not the file
+	job.SkipUnchangedSource = false
+
+	source := fileSource{
+		table:  job.newTable(job.Source),
+		reader: r,
+		format: format,
+	}
+
+	if format == ExportFormatCSV {
+		var err error
+		if source.table.typeHints, err = job.csvTypeHints(); err != nil {
+			return ExecJobResult{}, err
+		}
+	}
+
+	return job.syncTargetsFrom(source)
+}
+
+// csvTypeHints returns the type hints that a CSV file's fields are converted with: the job's own
+// TypeHints, plus a hint for each other column that is an integer (or a float) in every target
+// that can be read. Otherwise, a numeric column's fields would never match the targets' values,
+// and e.g. every row would be deleted and reinserted because of its primary key. A primary key
+// whose type differs between the targets can't match all of them, so it needs its own hint
+func (job JobConfig) csvTypeHints() (map[string]string, error) {
+	targetHints := map[string]string{} // The hint for each column, or "" if it isn't numeric
+	differs := map[string]bool{}       // Whether the column's hint differs between targets
+
+	for _, targetConfig := range job.Targets {
+		target := job.newTable(targetConfig)
+		if target.isNoop() {
+			continue
+		}
+
+		// A target that can't be read fails its own sync, so it's just skipped here
+		hints, err := target.columnTypeHints()
+		if err != nil {
+			continue
+		}
+
+		for _, column := range target.columns {
+			if hint, ok := targetHints[column]; ok && hint != hints[column] {
+				differs[column] = true
+			}
+			targetHints[column] = hints[column]
+		}
+	}
+
+	hints := map[string]string{}
+	for column, hint := range job.TypeHints {
+		hints[column] = hint
+	}
+
+	for _, column := range job.syncColumns() {
+		if _, ok := job.TypeHints[column]; ok {
+			continue
+		}
+
+		if differs[column] {
+			if slices.Contains(job.PrimaryKeys, column) {
+				return nil, fmt.Errorf(
+					"primary key '%s' has different types in the targets, so csv fields can't "+
+						"match all of them (set its typeHints)",
+					column,
+				)
+			}
+			continue
+		}
+
+		if hint := targetHints[column]; hint != "" {
+			hints[column] = hint
+		}
+	}
+
+	return hints, nil
+}
+
+// columnTypeHints connects to the table and returns the type hint for each of its columns that has
+// an integer or float type (see scanTypeOf), without reading any rows
+func (t table) columnTypeHints() (map[string]string, error) {
+	if err := t.connect(); err != nil {
+		return nil, err
+	}
+	defer t.disconnect()
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s LIMIT 0", strings.Join(t.quoteColumns(t.columns), ", "), t.config.Table,
+	)
+	rows, err := t.reader().QueryxContext(t.context(), query)
+	if err != nil {
+		return nil, &SchemaError{Target: t.config, Err: err}
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	hints := map[string]string{}
+	for i, columnType := range columnTypes {
+		switch scanTypeOf(columnType.DatabaseTypeName()) {
+		case scanInt:
+			hints[t.columns[i]] = typeHintInt
+		case scanFloat:
+			hints[t.columns[i]] = typeHintFloat
+		}
+	}
+
+	return hints, nil
+}
+
+// fileSource reads a job's source rows from a CSV or JSON file
+type fileSource struct {
+	table  table // Used for the job's columns, primary keys, and comparison (never connected)
+	reader io.Reader
+	format string
+}
+
+func (f fileSource) readSource() (tableData, error) {
+	var entries [][]any
+	var err error
+
+	if f.format == ExportFormatCSV {
+		entries, err = readCSV(f.reader, f.table.columns)
+	} else {
+		entries, err = readJSON(f.reader, f.table.columns)
+	}
+	if err != nil {
+		return tableData{}, err
+	}
+
+	for _, row := range entries {
+		if err := f.table.coerceRow(row); err != nil {
+			return tableData{}, err
+		}
+
+		f.table.convertEmpty(row)
+	}
+
+	// Order the rows by primary key, like they would be when read from a table
+	f.table.sortByKey(entries)
+
+	entryMap := make(map[primaryKeyTuple][]any, len(entries))
+	for _, row := range entries {
+		key := f.table.keyOf(row)
+		if _, ok := entryMap[key]; ok {
+			return tableData{}, fmt.Errorf("file has duplicate primary key: %v", key)
+		}
+		entryMap[key] = row
+	}
+
+	checksum, err := f.table.checksum(entries)
+	if err != nil {
+		return tableData{}, err
+	}
+
+	return tableData{checksum: checksum, entries: entries, entryMap: entryMap}, nil
+}
+
+func readCSV(r io.Reader, columns []string) ([][]any, error) {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	// Find where each of the job's columns is in the file
+	headerIndices := make([]int, len(columns))
+	for i, col := range columns {
+		headerIndices[i] = slices.Index(header, col)
+		if headerIndices[i] == -1 {
+			return nil, fmt.Errorf("csv header is missing column '%s'", col)
+		}
+	}
+
+	entries := [][]any{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := make([]any, len(columns))
+		for i, idx := range headerIndices {
+			row[i] = parseCSVValue(record[idx])
+		}
+		entries = append(entries, row)
+	}
+
+	return entries, nil
+}
+
+// parseCSVValue converts a CSV field to a value. Empty fields are NULL, and every other field is a
+// string, even if it looks like a number, since it could be text (e.g. a zip code like "02134").
+// A column's values are only converted to numbers by its type hint (see csvTypeHints)
+func parseCSVValue(field string) any {
+	if field == "" {
+		return nil
+	}
+
+	return field
+}
+
+func readJSON(r io.Reader, columns []string) ([][]any, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var rows []map[string]any
+	if err := decoder.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	entries := make([][]any, len(rows))
+	for i, obj := range rows {
+		row := make([]any, len(columns))
+		for j, col := range columns {
+			val, ok := obj[col]
+			if !ok {
+				return nil, fmt.Errorf("json row %d is missing column '%s'", i, col)
+			}
+
+			// Numbers are decoded as json.Number so that integers don't become floats
+			if num, ok := val.(json.Number); ok {
+				if i, err := num.Int64(); err == nil {
+					val = i
+				} else if f, err := num.Float64(); err == nil {
+					val = f
+				}
+			}
+
+			row[j] = val
+		}
+		entries[i] = row
+	}
+
+	return entries, nil
+}
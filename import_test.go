@@ -0,0 +1,255 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportJob(t *testing.T) {
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:import_job_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	require.NoError(t, target.connect())
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			age INTEGER
+		)
+	`)
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name", "age"},
+				Source: TableConfig{
+					Driver: "sqlite3",
+					Table:  "users",
+					DSN:    "file:import_job_source.db?mode=memory&cache=shared",
+				},
+				Targets: []TableConfig{targetConfig},
+			},
+		},
+	}
+
+	getTargetRows := func() []map[string]any {
+		rows, err := target.Queryx("SELECT * FROM users ORDER BY id")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var result []map[string]any
+		for rows.Next() {
+			row := map[string]any{}
+			require.NoError(t, rows.MapScan(row))
+			result = append(result, row)
+		}
+		return result
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		target.MustExec("DELETE FROM users")
+		target.MustExec("INSERT INTO users VALUES (1, 'Alice', 30), (4, 'Dan', 50)")
+
+		// Columns are out of order and there's an extra column, which should be ignored
+		data := "name,extra,id,age\n" +
+			"Charlie,x,3,\n" +
+			"Alice,y,1,31\n" +
+			"Bob,z,2,25\n"
+
+		result, err := config.ImportJob("users", strings.NewReader(data), "csv")
+		require.NoError(t, err)
+		require.Len(t, result.Results, 1)
+		require.NoError(t, result.Results[0].Error)
+		require.True(t, result.Results[0].Synced)
+		require.Equal(t, 2, result.Results[0].NumInserts)
+		require.Equal(t, 1, result.Results[0].NumUpdates)
+		require.Equal(t, 1, result.Results[0].NumDeletes)
+
+		expected := []map[string]any{
+			{"id": int64(1), "name": "Alice", "age": int64(31)},
+			{"id": int64(2), "name": "Bob", "age": int64(25)},
+			{"id": int64(3), "name": "Charlie", "age": nil},
+		}
+		require.Equal(t, expected, getTargetRows())
+
+		// Importing the same file again should find the target in sync
+		result, err = config.ImportJob("users", strings.NewReader(data), "csv")
+		require.NoError(t, err)
+		require.False(t, result.Results[0].Synced)
+		require.Equal(t, result.Checksum, result.Results[0].TargetChecksum)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		target.MustExec("DELETE FROM users")
+
+		data := `[
+			{"id": 2, "name": "Bob", "age": 25},
+			{"id": 1, "name": "Alice", "age": null}
+		]`
+
+		result, err := config.ImportJob("users", strings.NewReader(data), "json")
+		require.NoError(t, err)
+		require.NoError(t, result.Results[0].Error)
+		require.Equal(t, 2, result.Results[0].NumInserts)
+
+		expected := []map[string]any{
+			{"id": int64(1), "name": "Alice", "age": nil},
+			{"id": int64(2), "name": "Bob", "age": int64(25)},
+		}
+		require.Equal(t, expected, getTargetRows())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := config.ImportJob("users", strings.NewReader("id,name\n1,Alice\n"), "csv")
+		require.ErrorContains(t, err, "csv header is missing column 'age'")
+
+		_, err = config.ImportJob("users", strings.NewReader(`[{"id": 1, "name": "A"}]`), "json")
+		require.ErrorContains(t, err, "json row 0 is missing column 'age'")
+
+		data := "id,name,age\n1,Alice,30\n1,Alicia,31\n"
+		_, err = config.ImportJob("users", strings.NewReader(data), "csv")
+		require.ErrorContains(t, err, "file has duplicate primary key")
+
+		_, err = config.ImportJob("users", strings.NewReader(""), "xml")
+		require.ErrorContains(t, err, "unsupported import format: xml")
+
+		_, err = config.ImportJob("pets", strings.NewReader(""), "csv")
+		require.ErrorContains(t, err, "job 'pets' not found in config")
+	})
+}
+
+func TestImportJob_csv_text(t *testing.T) {
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "addresses",
+		DSN:    "file:import_job_csv_text_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	require.NoError(t, target.connect())
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS addresses (id INTEGER PRIMARY KEY NOT NULL, zip TEXT NOT NULL)
+	`)
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"addresses": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "zip"},
+				Source: TableConfig{
+					Driver: "sqlite3",
+					Table:  "addresses",
+					DSN:    "file:import_job_csv_text_source.db?mode=memory&cache=shared",
+				},
+				Targets: []TableConfig{targetConfig},
+			},
+		},
+	}
+
+	// The zip codes look like numbers, but they're text, so their leading zeros are kept
+	data := "id,zip\n1,02134\n2,007\n3,90210\n"
+
+	result, err := config.ImportJob("addresses", strings.NewReader(data), "csv")
+	require.NoError(t, err)
+	require.Len(t, result.Results, 1)
+	require.NoError(t, result.Results[0].Error)
+	require.Equal(t, 3, result.Results[0].NumInserts)
+
+	var zips []string
+	require.NoError(t, target.Select(&zips, "SELECT zip FROM addresses ORDER BY id"))
+	require.Equal(t, []string{"02134", "007", "90210"}, zips)
+
+	// Importing the same file again finds the target in sync, instead of updating every row
+	result, err = config.ImportJob("addresses", strings.NewReader(data), "csv")
+	require.NoError(t, err)
+	require.NoError(t, result.Results[0].Error)
+	require.False(t, result.Results[0].Synced)
+	require.Zero(t, result.Results[0].NumUpdates)
+	require.Equal(t, result.Checksum, result.Results[0].TargetChecksum)
+}
+
+func TestImportJob_csv_types(t *testing.T) {
+	createTable := func(target table, idType string) {
+		target.MustExec(fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS scores (
+				id %s PRIMARY KEY NOT NULL,
+				points INTEGER NOT NULL,
+				ratio REAL NOT NULL
+			)
+		`, idType))
+	}
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "scores",
+		DSN:    "file:import_job_csv_types_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	require.NoError(t, target.connect())
+	createTable(target, "INTEGER")
+	target.MustExec("INSERT INTO scores VALUES (1, 10, 0.5), (2, 20, 0.25)")
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "points", "ratio"},
+		Source: TableConfig{
+			Driver: "sqlite3",
+			Table:  "scores",
+			DSN:    "file:import_job_csv_types_source.db?mode=memory&cache=shared",
+		},
+		Targets: []TableConfig{targetConfig},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"scores": job}}
+
+	// Without typeHints, the fields are converted to the target's column types, so only the rows
+	// that really differ are changed (instead of every row being deleted and reinserted)
+	data := "id,points,ratio\n1,10,0.5\n2,21,0.25\n3,30,1\n"
+
+	result, err := config.ImportJob("scores", strings.NewReader(data), "csv")
+	require.NoError(t, err)
+	require.Len(t, result.Results, 1)
+	require.NoError(t, result.Results[0].Error)
+	require.Equal(t, 1, result.Results[0].NumInserts)
+	require.Equal(t, 1, result.Results[0].NumUpdates)
+	require.Zero(t, result.Results[0].NumDeletes)
+
+	result, err = config.ImportJob("scores", strings.NewReader(data), "csv")
+	require.NoError(t, err)
+	require.NoError(t, result.Results[0].Error)
+	require.False(t, result.Results[0].Synced)
+	require.Equal(t, result.Checksum, result.Results[0].TargetChecksum)
+
+	// A primary key can't match targets that have different types for it
+	textTargetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "scores",
+		DSN:    "file:import_job_csv_types_text_target.db?mode=memory&cache=shared",
+	}
+
+	textTarget := table{config: textTargetConfig}
+	require.NoError(t, textTarget.connect())
+	createTable(textTarget, "TEXT")
+
+	job.Targets = []TableConfig{targetConfig, textTargetConfig}
+	config.Jobs["scores"] = job
+
+	_, err = config.ImportJob("scores", strings.NewReader(data), "csv")
+	require.ErrorContains(t, err, "primary key 'id' has different types in the targets")
+
+	// Unless it has its own hint
+	job.TypeHints = map[string]string{"id": "int"}
+	config.Jobs["scores"] = job
+
+	_, err = config.ImportJob("scores", strings.NewReader(data), "csv")
+	require.NoError(t, err)
+}
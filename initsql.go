@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// initConnector opens connections with another connector, and executes the init SQL (see
+// TableConfig.InitSQL) on each one before the pool uses it. Since every connection the pool opens
+// goes through it, the init SQL applies to all of them, not just the first
+type initConnector struct {
+	driver.Connector
+	initSQL []string
+}
+
+func (c initConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, statement := range c.initSQL {
+		if err := execConn(ctx, conn, statement); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to execute init SQL '%s': %w", statement, err)
+		}
+	}
+
+	return conn, nil
+}
+
+// execConn executes a statement (without any arguments) on a driver connection
+func execConn(ctx context.Context, conn driver.Conn, statement string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, statement, nil)
+		return err
+	}
+
+	stmt, err := conn.Prepare(statement)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(nil)
+	return err
+}
+
+// dsnConnector is a connector for drivers that don't provide their own (see driver.DriverContext)
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// openInitDB opens a connection pool whose connections each execute the init SQL when they open
+func openInitDB(driverName, dsn string, initSQL []string) (*sql.DB, error) {
+	// The registered driver is only available from a pool, which doesn't connect until it's used
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	db.Close()
+
+	var connector driver.Connector = dsnConnector{driver: d, dsn: dsn}
+	if dc, ok := d.(driver.DriverContext); ok {
+		if connector, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	return sql.OpenDB(initConnector{Connector: connector, initSQL: initSQL}), nil
+}
@@ -0,0 +1,88 @@
+package sync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnect_init_sql(t *testing.T) {
+	target := table{
+		config: TableConfig{
+			Driver:  "sqlite3",
+			Table:   "users",
+			DSN:     "file:connect_init_sql.db?mode=memory&cache=shared",
+			InitSQL: []string{"PRAGMA foreign_keys = ON", "PRAGMA recursive_triggers = ON"},
+		},
+	}
+
+	require.NoError(t, target.connect())
+	defer target.disconnect()
+
+	// Hold several connections at once, so the pool has to open a new one for each
+	ctx := context.Background()
+	for range 3 {
+		conn, err := target.Conn(ctx)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var foreignKeys, recursiveTriggers int
+		require.NoError(t, conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys))
+		require.NoError(
+			t, conn.QueryRowContext(ctx, "PRAGMA recursive_triggers").Scan(&recursiveTriggers),
+		)
+		assert.Equal(t, 1, foreignKeys)
+		assert.Equal(t, 1, recursiveTriggers)
+	}
+
+	// The settings are enforced when the target is synced
+	target.MustExec(`CREATE TABLE IF NOT EXISTS teams (id INTEGER PRIMARY KEY NOT NULL)`)
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			team_id INTEGER NOT NULL REFERENCES teams (id)
+		)
+	`)
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:connect_init_sql_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	require.NoError(t, source.connect())
+	source.MustExec(`CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, team_id INTEGER)`)
+	source.MustExec(`INSERT INTO users (id, team_id) VALUES (1, 42)`)
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "team_id"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{target.config},
+			},
+		},
+	}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.ErrorContains(t, results.Results[0].Error, "FOREIGN KEY constraint failed")
+
+	// Init SQL that fails keeps the table from connecting
+	broken := table{
+		config: TableConfig{
+			Driver:  "sqlite3",
+			Table:   "users",
+			DSN:     "file:connect_init_sql_broken.db?mode=memory&cache=shared",
+			InitSQL: []string{"SET SESSION nonsense = 1"},
+		},
+	}
+
+	err = broken.connect()
+	assert.ErrorContains(t, err, "failed to execute init SQL 'SET SESSION nonsense = 1'")
+}
@@ -1,35 +1,280 @@
 package sync
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
 
 // ExecJobResult contains the results of executing a single sync job
 type ExecJobResult struct {
 	Checksum string
 	Results  []SyncResult
+
+	// SourceRowCount is the number of rows that were read from the source (see
+	// SyncResult.TargetRowCount). When only recently changed rows are read (see JobConfig.Since),
+	// this is only the number of those rows
+	SourceRowCount int
 }
 
 // ExecJob executes a single job in the sync config
 func (c Config) ExecJob(jobName string) (ExecJobResult, error) {
+	return c.ExecJobContext(context.Background(), jobName)
+}
+
+// ExecJobContext is like ExecJob, but its queries and statements are executed with the given
+// context. When the context is cancelled, the targets that are being synced abort (rolling back
+// their writes), and every target that didn't finish has the context's error as its Error
+func (c Config) ExecJobContext(ctx context.Context, jobName string) (ExecJobResult, error) {
+	return c.execJob(ctx, jobName, nil, newConnectLimiter(c.MaxConnectAttemptsPerHost))
+}
+
+func (c Config) execJob(
+	ctx context.Context,
+	jobName string,
+	sources *sharedConnections,
+	connectLimits *connectLimiter,
+) (ExecJobResult, error) {
 	// Find the job with the given name
 	job, ok := c.Jobs[jobName]
 	if !ok {
 		return ExecJobResult{}, fmt.Errorf("job '%s' not found in config", jobName)
 	}
 
-	checksum, results, err := job.syncTargets()
-	return ExecJobResult{checksum, results}, err
+	if err := ctx.Err(); err != nil {
+		return ExecJobResult{}, err
+	}
+
+	job.name = jobName
+	job.connectLimits = connectLimits
+
+	var span trace.Span
+	job.tracing, span = newTracer(c.Tracer, ctx).
+		start("ExecJob", attribute.String("sync.job", jobName))
+
+	result, err := job.syncTargets(sources)
+	endJobSpan(span, result, err)
+
+	return result, err
 }
 
-// ExecAllJobs executes all jobs in the sync config
+// ExecAllJobs executes all jobs in the sync config. Jobs that read from the same source database
+// share a single connection pool to it. If the config has a MaxConcurrency, jobs are executed
+// concurrently (see Config.MaxConcurrency), and otherwise one at a time. Either way, a job is only
+// executed once the jobs it depends on have been, and it is skipped if any of them failed
 func (c Config) ExecAllJobs() (map[string]ExecJobResult, map[string]error) {
 	results := make(map[string]ExecJobResult, len(c.Jobs))
 	errors := make(map[string]error, len(c.Jobs))
 
-	for jobName := range c.Jobs {
-		result, err := c.ExecJob(jobName)
-		results[jobName] = result
-		errors[jobName] = err
+	sources := newSharedConnections()
+	defer sources.close()
+
+	// Connection attempts are limited across every job, since their tables can share servers
+	connectLimits := newConnectLimiter(c.MaxConnectAttemptsPerHost)
+
+	if c.MaxConcurrency <= 0 {
+		failed := func(jobName string) bool { return jobFailed(results[jobName], errors[jobName]) }
+
+		for _, jobName := range c.JobNames() {
+			if err := c.dependencyError(jobName, failed); err != nil {
+				results[jobName] = ExecJobResult{}
+				errors[jobName] = err
+				continue
+			}
+
+			result, err := c.execJob(context.Background(), jobName, sources, connectLimits)
+			results[jobName] = result
+			errors[jobName] = err
+		}
+
+		return results, errors
+	}
+
+	// Limit each job to its share of the targets, so every job that is executing gets to sync
+	// some of its targets, no matter how many targets the other jobs have
+	share := fairShare(c.MaxConcurrency, len(c.Jobs))
+
+	jobNames := c.JobNames()
+	jobs := make(map[string]JobConfig, len(c.Jobs))
+	for jobName, job := range c.Jobs {
+		if job.MaxConcurrency <= 0 || job.MaxConcurrency > share {
+			job.MaxConcurrency = share
+		}
+
+		jobs[jobName] = job
+	}
+	c.Jobs = jobs
+
+	jobResults := make([]ExecJobResult, len(jobNames))
+	jobErrs := make([]error, len(jobNames))
+
+	// Each job's channel is closed once it is done, after its result is set
+	indices := make(map[string]int, len(jobNames))
+	done := make([]chan struct{}, len(jobNames))
+	for i, jobName := range jobNames {
+		indices[jobName] = i
+		done[i] = make(chan struct{})
+	}
+
+	failed := func(jobName string) bool {
+		i := indices[jobName]
+		return jobFailed(jobResults[i], jobErrs[i])
+	}
+
+	var wg sync.WaitGroup
+	sem := newSemaphore(c.MaxConcurrency)
+
+	for i, jobName := range jobNames {
+		wg.Add(1)
+		go func(i int, jobName string) {
+			defer wg.Done()
+			defer close(done[i])
+
+			// Wait for the job's dependencies before acquiring the semaphore, so that a job that is
+			// waiting never holds a slot that one of its dependencies needs
+			for _, dependency := range c.Jobs[jobName].DependsOn {
+				<-done[indices[dependency]]
+			}
+
+			if err := c.dependencyError(jobName, failed); err != nil {
+				jobErrs[i] = err
+				return
+			}
+
+			sem.acquire()
+			defer sem.release()
+
+			jobResults[i], jobErrs[i] = c.execJob(
+				context.Background(), jobName, sources, connectLimits,
+			)
+		}(i, jobName)
+	}
+
+	wg.Wait()
+
+	for i, jobName := range jobNames {
+		results[jobName] = jobResults[i]
+		errors[jobName] = jobErrs[i]
 	}
 
 	return results, errors
 }
+
+// dependencyError returns a DependencyError if any of the jobs that the job depends on failed
+func (c Config) dependencyError(jobName string, failed func(jobName string) bool) error {
+	for _, dependency := range c.Jobs[jobName].DependsOn {
+		if failed(dependency) {
+			return &DependencyError{Dependency: dependency}
+		}
+	}
+
+	return nil
+}
+
+// jobFailed returns whether a job failed (or was skipped), or any of its targets did
+func jobFailed(result ExecJobResult, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	for _, r := range result.Results {
+		if r.Error != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// JobNames returns the names of the config's jobs, in the order that ExecAllJobs executes them:
+// the jobs in JobOrder first (in that order), and then the rest, in order of their names. A job
+// that another job depends on is moved before it (see JobConfig.DependsOn)
+func (c Config) JobNames() []string {
+	var rest []string
+	for _, jobName := range sortedKeys(c.Jobs) {
+		if !slices.Contains(c.JobOrder, jobName) {
+			rest = append(rest, jobName)
+		}
+	}
+
+	var ordered []string
+	for _, jobName := range c.JobOrder {
+		if _, ok := c.Jobs[jobName]; ok {
+			ordered = append(ordered, jobName)
+		}
+	}
+	ordered = append(ordered, rest...)
+
+	// Sort the jobs topologically, visiting them (and their dependencies) in the order above, so
+	// that the order is only changed where a job has to come after its dependencies
+	var jobNames []string
+	visited := map[string]bool{}
+
+	var visit func(jobName string)
+	visit = func(jobName string) {
+		if visited[jobName] {
+			return
+		}
+		visited[jobName] = true // Before visiting its dependencies, in case there is a cycle
+
+		dependencies := slices.Clone(c.Jobs[jobName].DependsOn)
+		slices.SortStableFunc(dependencies, func(a, b string) int {
+			return slices.Index(ordered, a) - slices.Index(ordered, b)
+		})
+
+		for _, dependency := range dependencies {
+			if _, ok := c.Jobs[dependency]; ok {
+				visit(dependency)
+			}
+		}
+
+		jobNames = append(jobNames, jobName)
+	}
+
+	for _, jobName := range ordered {
+		visit(jobName)
+	}
+
+	return jobNames
+}
+
+// fairShare returns how many targets each job can sync at once, so that every one of numJobs jobs
+// can execute at once without syncing more than limit targets in total. If there are more jobs
+// than limit, only limit jobs execute at once, with one target each
+func fairShare(limit, numJobs int) int {
+	if numJobs == 0 || limit < numJobs {
+		return 1
+	}
+
+	return limit / numJobs
+}
+
+// Sync is a convenience for syncing a single source table to the given targets, without building
+// a Config. The columns and primaryKeys are the same as a job's (if primaryKeys is empty, it
+// defaults to "id")
+func Sync(
+	source TableConfig,
+	targets []TableConfig,
+	columns, primaryKeys []string,
+) (ExecJobResult, error) {
+	if len(primaryKeys) == 0 {
+		primaryKeys = []string{"id"}
+	}
+
+	job := JobConfig{
+		Columns:     columns,
+		PrimaryKeys: primaryKeys,
+		Source:      source,
+		Targets:     targets,
+	}
+
+	if err := job.validate(); err != nil {
+		return ExecJobResult{}, err
+	}
+
+	return job.syncTargets(nil)
+}
@@ -1,13 +1,25 @@
 package sync
 
 import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -107,10 +119,27 @@ func TestExecJob(t *testing.T) {
 	for _, result := range results.Results {
 		assert.NoError(t, result.Error)
 
+		// Make sure the phase durations are populated
+		assert.Positive(t, result.FetchDuration)
+		assert.Positive(t, result.CompareDuration)
+
+		// Make sure the pool stats were captured. The connections used during the sync should
+		// still be open (but idle), since the stats are captured before disconnecting
+		assert.Equal(t, 5, result.PoolStats.MaxOpenConnections)
+		assert.Positive(t, result.PoolStats.OpenConnections)
+		assert.Positive(t, result.PoolStats.Idle)
+		assert.Zero(t, result.PoolStats.InUse)
+
+		// While the target was read (and written), its connections were in use
+		assert.Positive(t, result.PeakInUseConnections)
+		assert.LessOrEqual(t, result.PeakInUseConnections, result.PoolStats.MaxOpenConnections)
+
 		if result.Target.Label == "already in sync" {
 			assert.False(t, result.Synced)
+			assert.Zero(t, result.WriteDuration)
 		} else {
 			assert.True(t, result.Synced)
+			assert.Positive(t, result.WriteDuration)
 		}
 	}
 
@@ -140,6 +169,91 @@ func TestExecJob(t *testing.T) {
 	}
 }
 
+func TestSync(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			age INT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:sync_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name, age) VALUES (1, 'Alice', 30), (2, 'Bob', 25)")
+
+	target1Config := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:sync_target1.db?mode=memory&cache=shared",
+	}
+
+	target1 := table{config: target1Config}
+	target1.connect()
+	target1.MustExec(createTable)
+
+	// target1 has some data that needs to be updated/deleted
+	target1.MustExec("INSERT INTO users (id, name, age) VALUES (1, 'Nick', 31), (420, 'Azamat', 69)")
+
+	target2Config := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:sync_target2.db?mode=memory&cache=shared",
+	}
+
+	target2 := table{config: target2Config}
+	target2.connect()
+	target2.MustExec(createTable)
+
+	// The primary key defaults to "id"
+	results, err := Sync(
+		sourceConfig,
+		[]TableConfig{target1Config, target2Config},
+		[]string{"id", "name", "age"},
+		nil,
+	)
+	require.NoError(t, err)
+	require.Len(t, results.Results, 2)
+
+	for _, result := range results.Results {
+		assert.NoError(t, result.Error)
+		assert.True(t, result.Synced)
+	}
+
+	// Check that the data was copied to each target
+	for _, target := range []table{target1, target2} {
+		var names []string
+		require.NoError(t, target.Select(&names, "SELECT name FROM users ORDER BY id"))
+		assert.Equal(t, []string{"Alice", "Bob"}, names)
+	}
+
+	// Syncing again should find every target in sync
+	results, err = Sync(
+		sourceConfig,
+		[]TableConfig{target1Config, target2Config},
+		[]string{"id", "name", "age"},
+		[]string{"id"},
+	)
+	require.NoError(t, err)
+
+	for _, result := range results.Results {
+		assert.NoError(t, result.Error)
+		assert.False(t, result.Synced)
+		assert.Equal(t, results.Checksum, result.TargetChecksum)
+	}
+
+	// The job is validated like it would be in a config
+	_, err = Sync(sourceConfig, []TableConfig{target1Config}, []string{"id", "name"}, []string{"age"})
+	assert.ErrorContains(t, err, "has primary key 'age' not in columns")
+}
+
 func TestExecJob_multiple_primary_key(t *testing.T) {
 	createTable := `
 		CREATE TABLE IF NOT EXISTS users (
@@ -237,280 +351,5840 @@ func TestExecJob_multiple_primary_key(t *testing.T) {
 	}
 }
 
-func TestExecJob_mysql(t *testing.T) {
-	dbName := os.Getenv("MYSQL_DB_NAME")
-	dbPortStr := os.Getenv("MYSQL_DB_PORT")
-	dbPort, _ := strconv.Atoi(dbPortStr)
+func TestExecJob_reordered_primary_keys(t *testing.T) {
+	// The table's key is (org_id, id), and neither is the first column
+	createTable := `
+		CREATE TABLE IF NOT EXISTS members (
+			name TEXT NOT NULL,
+			id INT NOT NULL,
+			org_id INT NOT NULL,
+			PRIMARY KEY (org_id, id)
+		)
+	`
 
-	createTable := func(name string) string {
-		return fmt.Sprintf(`
-			CREATE TABLE IF NOT EXISTS %s (
-				id INT PRIMARY KEY NOT NULL,
-				name TEXT NOT NULL,
-				age INT NOT NULL,
-				created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-			)
-		`, name)
+	// Matching rows is set-based, so the order the primary keys are listed in shouldn't matter
+	orderings := [][]string{{"org_id", "id"}, {"id", "org_id"}}
+
+	for i, primaryKeys := range orderings {
+		sourceConfig := TableConfig{
+			Driver: "sqlite3",
+			Table:  "members",
+			DSN:    fmt.Sprintf("file:exec_job_reordered_pk_source%d.db?mode=memory&cache=shared", i),
+		}
+
+		source := table{config: sourceConfig}
+		source.connect()
+		source.MustExec(createTable)
+		source.MustExec(`
+			INSERT INTO members (name, id, org_id)
+			VALUES ('Alice', 1, 1), ('Bob', 2, 1), ('Charlie', 1, 2)
+		`)
+
+		// The keys are asymmetric (e.g. org 1, id 2 vs. org 2, id 1), so a WHERE clause that mixed
+		// up the primary keys would update or delete the wrong rows
+		targetConfig := TableConfig{
+			Driver: "sqlite3",
+			Table:  "members",
+			DSN:    fmt.Sprintf("file:exec_job_reordered_pk_target%d.db?mode=memory&cache=shared", i),
+		}
+
+		target := table{config: targetConfig}
+		target.connect()
+		target.MustExec(createTable)
+		target.MustExec(`
+			INSERT INTO members (name, id, org_id)
+			VALUES ('Alice', 1, 1), ('Bobby', 2, 1), ('Dan', 2, 3)
+		`)
+
+		config := Config{
+			Jobs: map[string]JobConfig{
+				"members": {
+					PrimaryKeys: primaryKeys,
+					Columns:     []string{"name", "id", "org_id"},
+					Source:      sourceConfig,
+					Targets:     []TableConfig{targetConfig},
+				},
+			},
+		}
+
+		results, err := config.ExecJob("members")
+		require.NoError(t, err, primaryKeys)
+		require.Len(t, results.Results, 1)
+
+		result := results.Results[0]
+		require.NoError(t, result.Error, primaryKeys)
+		assert.True(t, result.Synced, primaryKeys)
+		assert.Equal(t, 1, result.NumInserts, primaryKeys)
+		assert.Equal(t, 1, result.NumUpdates, primaryKeys)
+		assert.Equal(t, 1, result.NumDeletes, primaryKeys)
+
+		type member struct {
+			Name  string
+			ID    int
+			OrgID int `db:"org_id"`
+		}
+
+		var members []member
+		err = target.Select(&members, "SELECT name, id, org_id FROM members ORDER BY org_id, id")
+		require.NoError(t, err)
+
+		expected := []member{{"Alice", 1, 1}, {"Bob", 2, 1}, {"Charlie", 1, 2}}
+		assert.Equal(t, expected, members, primaryKeys)
+
+		// Now the target should be in sync
+		results, err = config.ExecJob("members")
+		require.NoError(t, err)
+		require.Len(t, results.Results, 1)
+		assert.False(t, results.Results[0].Synced, primaryKeys)
+		assert.Equal(t, results.Checksum, results.Results[0].TargetChecksum, primaryKeys)
 	}
+}
 
+func TestExecJob_target_primary_keys(t *testing.T) {
 	sourceConfig := TableConfig{
-		Driver: "mysql",
+		Driver: "sqlite3",
 		Table:  "users",
-		User:   "root",
-		DB:     dbName,
-		Port:   dbPort,
+		DSN:    "file:exec_job_target_pk_source.db?mode=memory&cache=shared",
 	}
 
 	source := table{config: sourceConfig}
-	err := source.connect()
-	require.NoError(t, err)
-	source.MustExec(createTable(sourceConfig.Table))
+	source.connect()
+	source.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			email TEXT NOT NULL,
+			name TEXT NOT NULL
+		)
+	`)
+	source.MustExec(`
+		INSERT INTO users (id, email, name)
+		VALUES (1, 'alice@x.com', 'Alice'), (2, 'bob@x.com', 'Bob'), (3, 'carol@x.com', 'Carol')
+	`)
 
-	expectedData := [][]any{
-		{1, "Alice", 30, "2024-05-29 00:00:00"},
-		{2, "Bob", 25, "2024-04-20 00:00:00"},
-		{3, "Charlie", 35, "2024-04-27 00:00:00"},
+	// The target is keyed by email, and its ids were assigned independently of the source's
+	targetConfig := TableConfig{
+		Driver:      "sqlite3",
+		Table:       "users",
+		DSN:         "file:exec_job_target_pk_target.db?mode=memory&cache=shared",
+		PrimaryKeys: []string{"email"},
 	}
 
-	insert := sq.
-		Insert(sourceConfig.Table).
-		Columns("id", "name", "age", "created_at")
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			email TEXT PRIMARY KEY NOT NULL,
+			id INTEGER NOT NULL,
+			name TEXT NOT NULL
+		)
+	`)
+	target.MustExec(`
+		INSERT INTO users (email, id, name)
+		VALUES ('alice@x.com', 10, 'Alice'), ('bob@x.com', 2, 'Robert'), ('dan@x.com', 3, 'Dan')
+	`)
 
-	for _, row := range expectedData {
-		insert = insert.Values(row...)
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "email", "name"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		Verbose:     true,
 	}
 
-	sql, args, err := insert.ToSql()
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err := config.ExecJob("users")
 	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
 
-	// Insert some data into the source
-	source.MustExec(sql, args...)
+	// Rows are matched by email: Alice's id and Bob's name are updated, Carol is inserted, and
+	// Dan is deleted (even though the source has a row with Dan's id)
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 1, result.NumInserts)
+	assert.Equal(t, 2, result.NumUpdates)
+	assert.Equal(t, 1, result.NumDeletes)
 
-	target1Config := TableConfig{
-		Driver: "mysql",
-		Table:  "users2",
-		User:   "root",
-		DB:     dbName,
-		Port:   dbPort,
+	expectedUpdates := []RowUpdate{
+		{
+			KeyColumns: []string{"email"},
+			Key:        []any{"alice@x.com"},
+			Columns:    []string{"id"},
+			Source:     []any{int64(1)},
+			Target:     []any{int64(10)},
+		},
+		{
+			KeyColumns: []string{"email"},
+			Key:        []any{"bob@x.com"},
+			Columns:    []string{"name"},
+			Source:     []any{"Bob"},
+			Target:     []any{"Robert"},
+		},
 	}
+	assert.Equal(t, expectedUpdates, result.Updates)
 
-	target1 := table{config: target1Config}
-	err = target1.connect()
-	require.NoError(t, err)
-	target1.MustExec(createTable(target1Config.Table))
+	type user struct {
+		ID    int
+		Email string
+		Name  string
+	}
 
-	// target1 has some data that needs to be updated/deleted
-	target1.MustExec(
-		fmt.Sprintf(
-			"INSERT INTO %s (id, name, age) VALUES (1, 'Nick', 31)",
-			target1Config.Table,
-		),
-	)
-	target1.MustExec(
-		fmt.Sprintf(
-			"INSERT INTO %s (id, name, age) VALUES (420, 'Azamat', 69)",
-			target1Config.Table,
-		),
-	)
+	var users []user
+	require.NoError(t, target.Select(&users, "SELECT id, email, name FROM users ORDER BY email"))
 
-	target2Config := TableConfig{
-		Driver: "mysql",
-		Table:  "users3",
-		User:   "root",
-		DB:     dbName,
-		Port:   dbPort,
+	expected := []user{
+		{1, "alice@x.com", "Alice"},
+		{2, "bob@x.com", "Bob"},
+		{3, "carol@x.com", "Carol"},
 	}
+	assert.Equal(t, expected, users)
 
-	target2 := table{config: target2Config}
-	err = target2.connect()
+	// Now the target should be in sync
+	results, err = config.ExecJob("users")
 	require.NoError(t, err)
-	target2.MustExec(createTable(target2Config.Table))
-
-	// target2 has no data
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.False(t, results.Results[0].Synced)
+	assert.Zero(t, results.Results[0].DriftRows())
 
-	target3Config := TableConfig{
-		Label:  "already in sync",
-		Driver: "mysql",
-		Table:  "users4",
-		User:   "root",
-		DB:     dbName,
-		Port:   dbPort,
-	}
+	// If the source's rows aren't unique by the target's primary keys, they can't be matched
+	source.MustExec("UPDATE users SET email = 'alice@x.com' WHERE id = 2")
 
-	target3 := table{config: target3Config}
-	err = target3.connect()
+	results, err = config.ExecJob("users")
 	require.NoError(t, err)
-	target3.MustExec(createTable(target3Config.Table))
+	require.Len(t, results.Results, 1)
+	assert.ErrorContains(
+		t, results.Results[0].Error, "source has multiple rows with the target's primary key",
+	)
+}
 
-	// table3 is already in sync
-	insert = sq.Insert(target3Config.Table).Columns("id", "name", "age", "created_at")
+func TestExecJob_dry_run(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			age INT NOT NULL
+		)
+	`
 
-	for _, row := range expectedData {
-		insert = insert.Values(row...)
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_dry_run_source.db?mode=memory&cache=shared",
 	}
 
-	sql, args, err = insert.ToSql()
-	require.NoError(t, err)
-	target3.MustExec(sql, args...)
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name, age) VALUES (1, 'Alice', 30)")
+	source.MustExec("INSERT INTO users (id, name, age) VALUES (2, 'Bob', 25)")
+	source.MustExec("INSERT INTO users (id, name, age) VALUES (3, 'Charlie', 35)")
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_dry_run_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// target needs 1 update (id=1), 1 insert (id=3), and 1 delete (id=420). id=2 is already in sync
+	target.MustExec("INSERT INTO users (id, name, age) VALUES (1, 'Nick', 31)")
+	target.MustExec("INSERT INTO users (id, name, age) VALUES (2, 'Bob', 25)")
+	target.MustExec("INSERT INTO users (id, name, age) VALUES (420, 'Azamat', 69)")
 
 	config := Config{
 		Jobs: map[string]JobConfig{
 			"users": {
 				PrimaryKeys: []string{"id"},
-				Columns:     []string{"id", "name", "age", "created_at"},
+				Columns:     []string{"id", "name", "age"},
 				Source:      sourceConfig,
-				Targets:     []TableConfig{target1Config, target2Config, target3Config},
+				Targets:     []TableConfig{targetConfig},
+				DryRun:      true,
 			},
 		},
 	}
 
 	results, err := config.ExecJob("users")
 	require.NoError(t, err)
-	require.Len(t, results.Results, 3)
+	require.Len(t, results.Results, 1)
 
-	for _, result := range results.Results {
-		assert.NoError(t, result.Error)
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Equal(t, 1, result.NumInserts)
+	assert.Equal(t, 1, result.NumUpdates)
+	assert.Equal(t, 1, result.NumDeletes)
 
-		if result.Target.Label == "already in sync" {
-			assert.False(t, result.Synced)
-		} else {
-			assert.True(t, result.Synced)
-		}
-	}
+	// The statements that would have been executed are planned, in order
+	assert.Equal(t, []Statement{
+		{SQL: "DELETE FROM users WHERE `id` = ?", Args: []any{int64(420)}},
+		{
+			SQL:  "UPDATE users SET `name` = ?, `age` = ? WHERE `id` = ?",
+			Args: []any{"Alice", int64(30), int64(1)},
+		},
+		{
+			SQL:  "INSERT INTO users (`id`,`name`,`age`) VALUES (?,?,?)",
+			Args: []any{int64(3), "Charlie", int64(35)},
+		},
+	}, result.Statements)
 
-	// Check that the data was copied to each target
-	for _, target := range []table{target1, target2, target3} {
-		query := fmt.Sprintf("SELECT * FROM %s", target.config.Table)
-		rows, err := target.Queryx(query)
-		require.NoError(t, err)
+	// In direct mode, the statements' values are inlined, like they would be when executed
+	job := config.Jobs["users"]
+	job.Targets[0].StatementMode = statementModeDirect
+	config.Jobs["users"] = job
 
-		defer rows.Close()
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, []Statement{
+		{SQL: "DELETE FROM users WHERE `id` = 420"},
+		{SQL: "UPDATE users SET `name` = 'Alice', `age` = 30 WHERE `id` = 1"},
+		{SQL: "INSERT INTO users (`id`,`name`,`age`) VALUES (3,'Charlie',35)"},
+	}, results.Results[0].Statements)
 
-		var data [][]any
-		for rows.Next() {
-			cols, err := rows.SliceScan()
-			require.NoError(t, err)
-			data = append(data, cols)
-		}
+	// In replace mode, the target's rows would all be deleted, and the source's inserted
+	job.Targets[0].StatementMode = ""
+	job.ReplaceMode = true
+	job.BatchSize = 2
+	config.Jobs["users"] = job
 
-		require.Equal(t, len(expectedData), len(data))
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, []Statement{
+		{SQL: "DELETE FROM users"},
+		{
+			SQL:  "INSERT INTO users (`id`,`name`,`age`) VALUES (?,?,?),(?,?,?)",
+			Args: []any{int64(1), "Alice", int64(30), int64(2), "Bob", int64(25)},
+		},
+		{
+			SQL:  "INSERT INTO users (`id`,`name`,`age`) VALUES (?,?,?)",
+			Args: []any{int64(3), "Charlie", int64(35)},
+		},
+	}, results.Results[0].Statements)
 
-		// Make sure the data is correct
-		for i := range expectedData {
-			require.Len(t, data[i], len(expectedData[i]))
-			for j := range expectedData[i] {
-				require.EqualValues(t, expectedData[i][j], data[i][j])
-			}
-		}
+	// Make sure nothing was written to the target
+	var data []struct {
+		ID   int
+		Name string
+		Age  int
 	}
+	err = target.Select(&data, "SELECT id, name, age FROM users ORDER BY id")
+	require.NoError(t, err)
+	require.Len(t, data, 3)
+	assert.Equal(t, 1, data[0].ID)
+	assert.Equal(t, "Nick", data[0].Name)
+	assert.Equal(t, 2, data[1].ID)
+	assert.Equal(t, 420, data[2].ID)
 }
 
-func TestExecJob_mysql_multiple_primary_key(t *testing.T) {
-	dbName := os.Getenv("MYSQL_DB_NAME")
-	dbPortStr := os.Getenv("MYSQL_DB_PORT")
-	dbPort, _ := strconv.Atoi(dbPortStr)
+func TestExecJob_rollback_dry_run(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			email TEXT NOT NULL UNIQUE
+		)
+	`
 
-	createTable := func(name string) string {
-		return fmt.Sprintf(`
-			CREATE TABLE IF NOT EXISTS %s (
-				name VARCHAR(69) NOT NULL,
-				age INT NOT NULL,
-				favoriteColor TEXT NOT NULL,
-				PRIMARY KEY (age, name)
-			)
-		`, name)
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_rollback_dry_run_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, email) VALUES (1, 'alice@x.com'), (2, 'bob@x.com')")
+
+	// The first target's changes can be applied
+	okConfig := TableConfig{
+		Label:  "ok",
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_rollback_dry_run_ok.db?mode=memory&cache=shared",
+	}
+
+	okTarget := table{config: okConfig}
+	okTarget.connect()
+	okTarget.MustExec(createTable)
+	okTarget.MustExec("INSERT INTO users (id, email) VALUES (1, 'nick@x.com')")
+
+	// The second target's emails are swapped, so updating the first row violates the UNIQUE
+	// constraint (which a regular dry run can't tell)
+	swappedConfig := TableConfig{
+		Label:  "swapped",
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_rollback_dry_run_swapped.db?mode=memory&cache=shared",
+	}
+
+	swappedTarget := table{config: swappedConfig}
+	swappedTarget.connect()
+	swappedTarget.MustExec(createTable)
+	swappedTarget.MustExec(
+		"INSERT INTO users (id, email) VALUES (1, 'bob@x.com'), (2, 'alice@x.com')",
+	)
+
+	job := JobConfig{
+		PrimaryKeys:    []string{"id"},
+		Columns:        []string{"id", "email"},
+		Source:         sourceConfig,
+		Targets:        []TableConfig{okConfig, swappedConfig},
+		RollbackDryRun: true,
 	}
 
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 2)
+
+	ok := results.Results[0]
+	require.NoError(t, ok.Error)
+	assert.False(t, ok.Synced)
+	assert.True(t, ok.RolledBack)
+	assert.Equal(t, 1, ok.NumInserts)
+	assert.Equal(t, 1, ok.NumUpdates)
+
+	swapped := results.Results[1]
+	var syncErr *SyncError
+	require.ErrorAs(t, swapped.Error, &syncErr)
+	assert.ErrorContains(t, swapped.Error, "UNIQUE constraint failed")
+	assert.False(t, swapped.Synced)
+	assert.False(t, swapped.RolledBack)
+	assert.Equal(t, 2, swapped.NumUpdates)
+
+	// Neither target was changed
+	var emails []string
+	require.NoError(t, okTarget.Select(&emails, "SELECT email FROM users ORDER BY id"))
+	assert.Equal(t, []string{"nick@x.com"}, emails)
+
+	require.NoError(t, swappedTarget.Select(&emails, "SELECT email FROM users ORDER BY id"))
+	assert.Equal(t, []string{"bob@x.com", "alice@x.com"}, emails)
+
+	// It can't be combined with a checkpoint, which would record progress that was rolled back
+	job.CheckpointFile = filepath.Join(t.TempDir(), "checkpoints.json")
+	config.Jobs["users"] = job
+
+	_, err = config.ExecJob("users")
+	assert.ErrorContains(t, err, "job uses checkpointFile, so it can't use rollbackDryRun")
+}
+
+func TestExecJob_verbose(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			age INTEGER NOT NULL,
+			email TEXT NOT NULL
+		)
+	`
+
 	sourceConfig := TableConfig{
-		Driver: "mysql",
-		Table:  "users_multi_pk",
-		User:   "root",
-		DB:     dbName,
-		Port:   dbPort,
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_verbose_source.db?mode=memory&cache=shared",
 	}
 
 	source := table{config: sourceConfig}
-	err := source.connect()
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO users (id, name, age, email)
+		VALUES
+			(1, 'Alice', 30, 'alice@x.com'),
+			(2, 'Bob', 25, 'bob@x.com'),
+			(3, 'Dan', 40, 'd@x.com'),
+			(4, 'Eve', 22, 'eve@x.com')
+	`)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_verbose_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// Alice's age differs, Bob's name and email differ, Dan is the same, Eve is missing, and Zed
+	// isn't in the source
+	target.MustExec(`
+		INSERT INTO users (id, name, age, email)
+		VALUES
+			(1, 'Alice', 31, 'alice@x.com'),
+			(2, 'Robert', 25, 'rob@x.com'),
+			(3, 'Dan', 40, 'd@x.com'),
+			(5, 'Zed', 50, 'zed@x.com')
+	`)
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name", "age", "email"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		DryRun:      true,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// Without verbose, the changed columns aren't recorded
+	results, err := config.ExecJob("users")
 	require.NoError(t, err)
-	source.MustExec(createTable(sourceConfig.Table))
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 2, results.Results[0].NumUpdates)
+	assert.Empty(t, results.Results[0].Updates)
+	assert.Empty(t, results.Results[0].Inserts)
+	assert.Empty(t, results.Results[0].Deletes)
 
-	expectedData := [][]any{
-		{"Bob", 25, "blue"},
-		{"Alice", 30, "red"},
-		{"Charlie", 35, "green"},
+	job.Verbose = true
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+
+	expected := []RowUpdate{
+		{
+			KeyColumns: []string{"id"},
+			Key:        []any{int64(1)},
+			Columns:    []string{"age"},
+			Source:     []any{int64(30)},
+			Target:     []any{int64(31)},
+		},
+		{
+			KeyColumns: []string{"id"},
+			Key:        []any{int64(2)},
+			Columns:    []string{"name", "email"},
+			Source:     []any{"Bob", "bob@x.com"},
+			Target:     []any{"Robert", "rob@x.com"},
+		},
 	}
+	assert.Equal(t, expected, results.Results[0].Updates)
 
-	insert := sq.Insert(sourceConfig.Table).Columns("name", "age", "favoriteColor")
+	// Inserted rows have the source's values, and deleted rows have the target's
+	columns := []string{"id", "name", "age", "email"}
+	assert.Equal(t, []RowValues{{
+		KeyColumns: []string{"id"},
+		Key:        []any{int64(4)},
+		Columns:    columns,
+		Values:     []any{int64(4), "Eve", int64(22), "eve@x.com"},
+	}}, results.Results[0].Inserts)
+	assert.Equal(t, []RowValues{{
+		KeyColumns: []string{"id"},
+		Key:        []any{int64(5)},
+		Columns:    columns,
+		Values:     []any{int64(5), "Zed", int64(50), "zed@x.com"},
+	}}, results.Results[0].Deletes)
+}
 
-	for _, row := range expectedData {
-		insert = insert.Values(row...)
+func TestExecJob_max_targets(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_max_targets_source.db?mode=memory&cache=shared",
 	}
 
-	sql, args, err := insert.ToSql()
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	var targets []table
+	var targetConfigs []TableConfig
+	for _, label := range []string{"first", "second", "third"} {
+		config := TableConfig{
+			Label:  label,
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    fmt.Sprintf("file:exec_job_max_targets_%s.db?mode=memory&cache=shared", label),
+		}
+
+		target := table{config: config}
+		target.connect()
+		target.MustExec(createTable)
+		target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Nick')")
+
+		targets = append(targets, target)
+		targetConfigs = append(targetConfigs, config)
+	}
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name"},
+		Source:      sourceConfig,
+		Targets:     targetConfigs,
+		MaxTargets:  2,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err := config.ExecJob("users")
 	require.NoError(t, err)
 
-	// Insert some data into the source
-	source.MustExec(sql, args...)
+	// Only the first two targets were synced
+	require.Len(t, results.Results, 2)
+	for i, result := range results.Results {
+		require.NoError(t, result.Error)
+		assert.Equal(t, targetConfigs[i].Label, result.Target.Label)
+		assert.True(t, result.Synced)
+	}
 
-	target1Config := TableConfig{
-		Driver: "mysql",
-		Table:  "users_multi_pk_1",
-		User:   "root",
-		DB:     dbName,
-		Port:   dbPort,
+	expectedNames := map[string][]string{
+		"first":  {"Alice", "Bob"},
+		"second": {"Alice", "Bob"},
+		"third":  {"Nick"}, // Untouched
 	}
 
-	target1 := table{config: target1Config}
-	err = target1.connect()
+	for _, target := range targets {
+		var names []string
+		require.NoError(t, target.Select(&names, "SELECT name FROM users ORDER BY id"))
+		assert.Equal(t, expectedNames[target.config.Label], names, target.config.Label)
+	}
+
+	// A MaxTargets larger than the number of targets syncs all of them
+	job.MaxTargets = 10
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
 	require.NoError(t, err)
-	target1.MustExec(createTable(target1Config.Table))
+	require.Len(t, results.Results, 3)
+	assert.False(t, results.Results[0].Synced)
+	assert.False(t, results.Results[1].Synced)
+	assert.True(t, results.Results[2].Synced)
+}
 
-	// target1 has no data
+func TestExecJobContext(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
 
-	primaryKeys := []string{"age", "name"}
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_context_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	var targets []table
+	var targetConfigs []TableConfig
+	for i := range 2 {
+		config := TableConfig{
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    fmt.Sprintf("file:exec_job_context_target%d.db?mode=memory&cache=shared", i),
+		}
+
+		target := table{config: config}
+		target.connect()
+		target.MustExec(createTable)
+		target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Nick')")
+
+		targets = append(targets, target)
+		targetConfigs = append(targetConfigs, config)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
+	// The job is cancelled while its first target is being synced, before its changes are written.
+	// The other target is still waiting to be synced, since only one is synced at once
 	config := Config{
 		Jobs: map[string]JobConfig{
 			"users": {
-				PrimaryKeys: primaryKeys,
-				Columns:     []string{"name", "age", "favoriteColor"},
-				Source:      sourceConfig,
-				Targets:     []TableConfig{target1Config},
+				PrimaryKeys:    []string{"id"},
+				Columns:        []string{"id", "name"},
+				Source:         sourceConfig,
+				Targets:        targetConfigs,
+				MaxConcurrency: 1,
+				Approve: func(SyncResult) bool {
+					cancel()
+					return true
+				},
 			},
 		},
 	}
 
-	results, err := config.ExecJob("users")
+	results, err := config.ExecJobContext(ctx, "users")
 	require.NoError(t, err)
-	require.Len(t, results.Results, 1)
+	require.Len(t, results.Results, 2)
 
 	for _, result := range results.Results {
-		assert.NoError(t, result.Error)
+		assert.ErrorIs(t, result.Error, context.Canceled)
+		assert.Equal(t, SyncStatusError, result.Status)
+		assert.False(t, result.Synced)
+	}
+
+	// Neither target was written to
+	for _, target := range targets {
+		var names []string
+		err := target.Select(&names, "SELECT name FROM users ORDER BY id")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Nick"}, names)
+	}
+
+	// A job whose context is already cancelled isn't executed at all
+	results, err = config.ExecJobContext(ctx, "users")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, results.Results)
+
+	// With a context that isn't cancelled, the job is executed like ExecJob
+	results, err = config.ExecJobContext(context.Background(), "users")
+	require.NoError(t, err)
+	for _, result := range results.Results {
+		require.NoError(t, result.Error)
 		assert.True(t, result.Synced)
 	}
+}
+
+func TestExecJob_approve(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_approve_source.db?mode=memory&cache=shared",
+	}
 
-	// Check that the data was copied to the target
-	order := strings.Join(primaryKeys, ", ")
-	query := fmt.Sprintf("SELECT * FROM %s ORDER BY %s", target1.config.Table, order)
-	rows, err := target1.Queryx(query)
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	var targets []table
+	var targetConfigs []TableConfig
+	for _, label := range []string{"approved", "declined"} {
+		config := TableConfig{
+			Label:  label,
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    fmt.Sprintf("file:exec_job_approve_%s.db?mode=memory&cache=shared", label),
+		}
+
+		target := table{config: config}
+		target.connect()
+		target.MustExec(createTable)
+		target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Nick')")
+
+		targets = append(targets, target)
+		targetConfigs = append(targetConfigs, config)
+	}
+
+	var approvals []SyncResult
+	var mu sync.Mutex
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name"},
+		Source:      sourceConfig,
+		Targets:     targetConfigs,
+		Approve: func(result SyncResult) bool {
+			mu.Lock()
+			defer mu.Unlock()
+
+			approvals = append(approvals, result)
+			return result.Target.Label == "approved"
+		},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err := config.ExecJob("users")
 	require.NoError(t, err)
+	require.Len(t, results.Results, 2)
 
-	defer rows.Close()
+	// Each target was asked for approval with its planned changes
+	require.Len(t, approvals, 2)
+	for _, approval := range approvals {
+		assert.Equal(t, 1, approval.NumInserts)
+		assert.Equal(t, 1, approval.NumUpdates)
+		assert.Zero(t, approval.NumDeletes)
+	}
 
-	var data [][]any
-	for rows.Next() {
-		cols, err := rows.SliceScan()
-		require.NoError(t, err)
-		data = append(data, cols)
+	for _, result := range results.Results {
+		require.NoError(t, result.Error)
+
+		if result.Target.Label == "approved" {
+			assert.True(t, result.Synced)
+			assert.False(t, result.Skipped)
+		} else {
+			assert.False(t, result.Synced)
+			assert.True(t, result.Skipped)
+		}
 	}
 
-	require.Equal(t, len(expectedData), len(data))
+	// Only the approved target was written to
+	var names []string
+	require.NoError(t, targets[0].Select(&names, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Alice", "Bob"}, names)
 
-	// Make sure the data is correct
-	for i := range expectedData {
-		require.Len(t, data[i], len(expectedData[i]))
-		for j := range expectedData[i] {
-			require.EqualValues(t, expectedData[i][j], data[i][j])
+	names = nil
+	require.NoError(t, targets[1].Select(&names, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Nick"}, names)
+}
+
+func TestExecJob_target_transaction(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_target_tx_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	// syncWithConcurrentWriter syncs a target, while another writer inserts Bob into the target
+	// after the sync has read the target but before it writes to it
+	syncWithConcurrentWriter := func(name string, targetTransaction bool) (SyncResult, error) {
+		targetConfig := TableConfig{
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    fmt.Sprintf("file:exec_job_target_tx_%s.db?mode=memory&cache=shared", name),
+		}
+
+		target := table{config: targetConfig}
+		target.connect()
+		target.MustExec(createTable)
+		target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Nick'), (3, 'Dan')")
+
+		// The concurrent writer has its own connection to the target
+		writer := table{config: targetConfig}
+		require.NoError(t, writer.connect())
+		defer writer.Close()
+
+		var writeErr error
+
+		job := JobConfig{
+			PrimaryKeys:       []string{"id"},
+			Columns:           []string{"id", "name"},
+			Source:            sourceConfig,
+			Targets:           []TableConfig{targetConfig},
+			TargetTransaction: targetTransaction,
+			Approve: func(SyncResult) bool {
+				_, writeErr = writer.Exec("INSERT INTO users (id, name) VALUES (2, 'Zed')")
+				return true
+			},
 		}
+
+		config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+		results, err := config.ExecJob("users")
+		require.NoError(t, err)
+		require.Len(t, results.Results, 1)
+
+		return results.Results[0], writeErr
+	}
+
+	// Without a transaction, the concurrent write succeeds, and the sync's insert of the same row
+	// fails since the diff is stale
+	result, writeErr := syncWithConcurrentWriter("no_tx", false)
+	require.NoError(t, writeErr)
+
+	var syncErr *SyncError
+	require.ErrorAs(t, result.Error, &syncErr)
+	assert.ErrorContains(t, syncErr, "UNIQUE constraint failed")
+	assert.False(t, result.Synced)
+
+	// With a transaction, the concurrent writer can't write to the target until the sync is done,
+	// so the sync's diff is applied to exactly the rows it read
+	result, writeErr = syncWithConcurrentWriter("tx", true)
+	assert.ErrorContains(t, writeErr, "locked")
+
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 1, result.NumInserts)
+	assert.Equal(t, 1, result.NumUpdates)
+	assert.Equal(t, 1, result.NumDeletes)
+
+	target := table{config: result.Target}
+	target.connect()
+
+	var names []string
+	require.NoError(t, target.Select(&names, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Alice", "Bob"}, names)
+}
+
+func TestExecJob_attach_sqlite(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT,
+			age INT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_attach_source.db?mode=memory&cache=shared",
 	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name, age) VALUES (1, 'Alice', 30)")
+	source.MustExec("INSERT INTO users (id, name, age) VALUES (2, NULL, 25)")
+	source.MustExec("INSERT INTO users (id, name, age) VALUES (3, 'Charlie', 35)")
+
+	// Sync the same initial target data with the row-by-row path and the ATTACH path
+	newTarget := func(name string) (table, TableConfig) {
+		config := TableConfig{
+			Label:  name,
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    fmt.Sprintf("file:exec_job_attach_%s.db?mode=memory&cache=shared", name),
+		}
+
+		target := table{config: config}
+		target.connect()
+		target.MustExec(createTable)
+		target.MustExec("INSERT INTO users (id, name, age) VALUES (1, 'Nick', 31)")
+		target.MustExec("INSERT INTO users (id, name, age) VALUES (2, 'Bob', 25)")
+		target.MustExec("INSERT INTO users (id, name, age) VALUES (420, 'Azamat', 69)")
+
+		return target, config
+	}
+
+	rowByRowTarget, rowByRowConfig := newTarget("row_by_row")
+	attachedTarget, attachedConfig := newTarget("attached")
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name", "age"},
+		Source:      sourceConfig,
+	}
+
+	rowByRowJob := job
+	rowByRowJob.Targets = []TableConfig{rowByRowConfig}
+
+	attachedJob := job
+	attachedJob.Targets = []TableConfig{attachedConfig}
+	attachedJob.AttachSQLite = true
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"row_by_row": rowByRowJob,
+			"attached":   attachedJob,
+		},
+	}
+
+	rowByRowResults, err := config.ExecJob("row_by_row")
+	require.NoError(t, err)
+	require.Len(t, rowByRowResults.Results, 1)
+
+	attachedResults, err := config.ExecJob("attached")
+	require.NoError(t, err)
+	require.Len(t, attachedResults.Results, 1)
+
+	rowByRowResult := rowByRowResults.Results[0]
+	attachedResult := attachedResults.Results[0]
+	require.NoError(t, rowByRowResult.Error)
+	require.NoError(t, attachedResult.Error)
+
+	assert.True(t, attachedResult.Synced)
+	assert.Equal(t, rowByRowResult.NumInserts, attachedResult.NumInserts)
+	assert.Equal(t, rowByRowResult.NumUpdates, attachedResult.NumUpdates)
+	assert.Equal(t, rowByRowResult.NumDeletes, attachedResult.NumDeletes)
+
+	getData := func(target table) [][]any {
+		rows, err := target.Queryx("SELECT * FROM users ORDER BY id")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var data [][]any
+		for rows.Next() {
+			cols, err := rows.SliceScan()
+			require.NoError(t, err)
+			data = append(data, cols)
+		}
+
+		return data
+	}
+
+	expectedData := getData(source)
+	assert.Equal(t, expectedData, getData(rowByRowTarget))
+	assert.Equal(t, expectedData, getData(attachedTarget))
+
+	// Syncing again should be a no-op
+	attachedResults, err = config.ExecJob("attached")
+	require.NoError(t, err)
+	require.Len(t, attachedResults.Results, 1)
+	assert.NoError(t, attachedResults.Results[0].Error)
+	assert.False(t, attachedResults.Results[0].Synced)
+}
+
+func TestExecJob_checkpoint(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_checkpoint_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave", "Eve"} {
+		source.MustExec("INSERT INTO users (name) VALUES (?)", name)
+	}
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_checkpoint_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+	target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Nick')")
+
+	// Simulate an interruption partway through the sync
+	target.MustExec(`
+		CREATE TRIGGER interrupt BEFORE INSERT ON users WHEN NEW.id = 4
+		BEGIN
+			SELECT RAISE(ABORT, 'interrupted');
+		END
+	`)
+
+	checkpointFile := filepath.Join(t.TempDir(), "checkpoints.json")
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys:    []string{"id"},
+				Columns:        []string{"id", "name"},
+				Source:         sourceConfig,
+				Targets:        []TableConfig{targetConfig},
+				CheckpointFile: checkpointFile,
+			},
+		},
+	}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.ErrorContains(t, results.Results[0].Error, "interrupted")
+
+	// The checkpoint should point at the last row that was synced before the interruption
+	fileBytes, err := os.ReadFile(checkpointFile)
+	require.NoError(t, err)
+
+	var checkpoints map[string]string
+	require.NoError(t, json.Unmarshal(fileBytes, &checkpoints))
+	require.Contains(t, checkpoints, targetConfig.id())
+
+	expectedKey, err := encodeKey(primaryKeyTuple{First: int64(3)})
+	require.NoError(t, err)
+	assert.Equal(t, expectedKey, checkpoints[targetConfig.id()])
+
+	// Resume the sync. Only the rows after the checkpoint should be synced
+	target.MustExec("DROP TRIGGER interrupt")
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 2, result.NumInserts)
+	assert.Zero(t, result.NumUpdates)
+	assert.Zero(t, result.NumDeletes)
+
+	// The target should now match the source, and the checkpoint should be cleared
+	var sourceNames, targetNames []string
+	require.NoError(t, source.Select(&sourceNames, "SELECT name FROM users ORDER BY id"))
+	require.NoError(t, target.Select(&targetNames, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, sourceNames, targetNames)
+
+	fileBytes, err = os.ReadFile(checkpointFile)
+	require.NoError(t, err)
+
+	checkpoints = nil
+	require.NoError(t, json.Unmarshal(fileBytes, &checkpoints))
+	assert.NotContains(t, checkpoints, targetConfig.id())
+}
+
+func TestExecJob_checkpoint_attach_sqlite(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT)"
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_checkpoint_attach_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob'), (3, 'Charlie')")
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_checkpoint_attach_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+	target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice')")
+
+	// An earlier sync (that didn't attach) was interrupted after the row with id=2
+	key, err := encodeKey(primaryKeyTuple{First: int64(2)})
+	require.NoError(t, err)
+
+	checkpointBytes, err := json.Marshal(map[string]string{targetConfig.id(): key})
+	require.NoError(t, err)
+
+	checkpointFile := filepath.Join(t.TempDir(), "checkpoints.json")
+	require.NoError(t, os.WriteFile(checkpointFile, checkpointBytes, 0o644))
+
+	job := JobConfig{
+		PrimaryKeys:    []string{"id"},
+		Columns:        []string{"id", "name"},
+		Source:         sourceConfig,
+		Targets:        []TableConfig{targetConfig},
+		CheckpointFile: checkpointFile,
+		AttachSQLite:   true,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+
+	// The attached sync synced every row, so the checkpoint is cleared
+	fileBytes, err := os.ReadFile(checkpointFile)
+	require.NoError(t, err)
+
+	var checkpoints map[string]string
+	require.NoError(t, json.Unmarshal(fileBytes, &checkpoints))
+	assert.NotContains(t, checkpoints, targetConfig.id())
+
+	// So a later sync that doesn't attach doesn't skip the rows before it
+	target.MustExec("DELETE FROM users WHERE id = 1")
+
+	job.AttachSQLite = false
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 1, results.Results[0].NumInserts)
+
+	var count int
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 3, count)
+}
+
+func TestExecJob_skip_unchanged_source(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_skip_unchanged_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (name) VALUES ('Alice'), ('Bob')")
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_skip_unchanged_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys:         []string{"id"},
+				Columns:             []string{"id", "name"},
+				Source:              sourceConfig,
+				Targets:             []TableConfig{targetConfig},
+				SkipUnchangedSource: true,
+				StateFile:           stateFile,
+			},
+		},
+	}
+
+	// The first sync reads and writes the target, and persists the source's checksum
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.False(t, results.Results[0].Unchanged)
+
+	fileBytes, err := os.ReadFile(stateFile)
+	require.NoError(t, err)
+
+	var states map[string]string
+	require.NoError(t, json.Unmarshal(fileBytes, &states))
+	assert.Equal(t, map[string]string{"users": results.Checksum}, states)
+
+	// Dropping the target table proves that the next sync doesn't read it, since the source is
+	// unchanged
+	target.MustExec("DROP TABLE users")
+
+	unchangedResults, err := config.ExecJob("users")
+	require.NoError(t, err)
+	assert.Equal(t, results.Checksum, unchangedResults.Checksum)
+	require.Len(t, unchangedResults.Results, 1)
+
+	result := unchangedResults.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Unchanged)
+	assert.False(t, result.Synced)
+	assert.Empty(t, result.TargetChecksum)
+
+	// Once the source changes, the target is read again
+	target.MustExec(createTable)
+	source.MustExec("INSERT INTO users (name) VALUES ('Charlie')")
+
+	changedResults, err := config.ExecJob("users")
+	require.NoError(t, err)
+	assert.NotEqual(t, results.Checksum, changedResults.Checksum)
+	require.Len(t, changedResults.Results, 1)
+
+	result = changedResults.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Unchanged)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 3, result.NumInserts)
+
+	fileBytes, err = os.ReadFile(stateFile)
+	require.NoError(t, err)
+
+	states = nil
+	require.NoError(t, json.Unmarshal(fileBytes, &states))
+	assert.Equal(t, map[string]string{"users": changedResults.Checksum}, states)
+}
+
+func TestExecJob_partitioned_checksum(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_partitioned_checksum_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO users (id, name)
+		VALUES (1, 'Alice'), (2, 'Bob'), (3, 'Charlie'), (5, 'Dave'), (6, 'Eve'), (-3, 'Frank')
+	`)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_partitioned_checksum_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys:         []string{"id"},
+				Columns:             []string{"id", "name"},
+				Source:              sourceConfig,
+				Targets:             []TableConfig{targetConfig},
+				SkipUnchangedSource: true,
+				StateFile:           stateFile,
+				PartitionedChecksum: true,
+				PartitionCount:      4,
+			},
+		},
+	}
+	require.NoError(t, config.validate())
+
+	// The first sync has no partition checksums to compare, so it reads the whole target
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Nil(t, result.Partitions)
+	assert.Equal(t, 6, result.NumInserts)
+
+	fileBytes, err := os.ReadFile(stateFile)
+	require.NoError(t, err)
+
+	var states map[string]sourceState
+	require.NoError(t, json.Unmarshal(fileBytes, &states))
+	assert.Equal(t, results.Checksum, states["users"].Checksum)
+	assert.Len(t, states["users"].Partitions, 4)
+
+	// Rows in partition 1 (ids 1 and 5, and -3, whose partition is also 1) change in the source,
+	// while a row in partition 2 drifts in the target. Only partition 1 is read and diffed, so the
+	// drifted row isn't fetched (or fixed)
+	source.MustExec("UPDATE users SET name = 'David' WHERE id = 5")
+	source.MustExec("DELETE FROM users WHERE id = -3")
+	target.MustExec("UPDATE users SET name = 'Robert' WHERE id = 2")
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.Equal(t, 5, results.SourceRowCount)
+
+	result = results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, []int{1}, result.Partitions)
+	assert.Equal(t, 2, result.TargetRowCount)
+	assert.Equal(t, 1, result.NumUpdates)
+	assert.Equal(t, 1, result.NumDeletes)
+	assert.Equal(t, 0, result.NumInserts)
+
+	var rows []struct {
+		ID   int
+		Name string
+	}
+	require.NoError(t, target.Select(&rows, "SELECT id, name FROM users ORDER BY id"))
+	assert.Equal(t, []struct {
+		ID   int
+		Name string
+	}{
+		{1, "Alice"}, {2, "Robert"}, {3, "Charlie"}, {5, "David"}, {6, "Eve"},
+	}, rows)
+
+	// Once the source is unchanged, the target isn't read at all
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.True(t, results.Results[0].Unchanged)
+}
+
+func TestSourceState_json(t *testing.T) {
+	// A state without partitions is encoded as just its checksum, like before they were persisted
+	encoded, err := json.Marshal(map[string]sourceState{
+		"teams": {Checksum: "abc"},
+		"users": {Checksum: "def", Partitions: []string{"1", "2"}},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(
+		t, `{"teams": "abc", "users": {"checksum": "def", "partitions": ["1", "2"]}}`, string(encoded),
+	)
+
+	var decoded map[string]sourceState
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, map[string]sourceState{
+		"teams": {Checksum: "abc"},
+		"users": {Checksum: "def", Partitions: []string{"1", "2"}},
+	}, decoded)
+}
+
+func TestExecJob_checksum_columns(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			bio TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_checksum_columns_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name, bio) VALUES (1, 'Alice', 'a'), (2, 'Bob', 'b')")
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_checksum_columns_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+	target.MustExec("INSERT INTO users (id, name, bio) VALUES (1, 'Alice', 'x'), (2, 'Bob', 'b')")
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys:     []string{"id"},
+				Columns:         []string{"id", "name", "bio"},
+				Source:          sourceConfig,
+				Targets:         []TableConfig{targetConfig},
+				ChecksumColumns: []string{"name", "id"},
+			},
+		},
+	}
+
+	// The rows only differ in a column that isn't checksummed, so the change is missed
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+	assert.False(t, result.Synced)
+
+	// The checksum is only of the checksum columns (in column order)
+	expectedChecksum, err := checksumData([][]any{{int64(1), "Alice"}, {int64(2), "Bob"}})
+	require.NoError(t, err)
+	assert.Equal(t, expectedChecksum, results.Checksum)
+
+	// Once a checksum column differs, every column is diffed and synced
+	target.MustExec("UPDATE users SET name = 'Bobby' WHERE id = 2")
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result = results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 2, result.NumUpdates)
+
+	type user struct {
+		ID   int
+		Name string
+		Bio  string
+	}
+
+	var sourceUsers, targetUsers []user
+	require.NoError(t, source.Select(&sourceUsers, "SELECT id, name, bio FROM users ORDER BY id"))
+	require.NoError(t, target.Select(&targetUsers, "SELECT id, name, bio FROM users ORDER BY id"))
+	assert.Equal(t, sourceUsers, targetUsers)
+}
+
+func TestExecJob_skip_missing_columns(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_skip_missing_columns_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			bio TEXT NOT NULL
+		)
+	`)
+	source.MustExec("INSERT INTO users (id, name, bio) VALUES (1, 'Alice', 'a'), (2, 'Bob', 'b')")
+
+	// The target hasn't been migrated to have the bio column yet
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_skip_missing_columns_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`)
+	target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Nick'), (3, 'Charlie')")
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name", "bio"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// Without skipping missing columns, the target can't be synced
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.ErrorContains(t, results.Results[0].Error, "no such column: bio")
+
+	job.SkipMissingColumns = true
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, []string{"bio"}, result.SkippedColumns)
+	assert.Equal(t, 1, result.NumInserts)
+	assert.Equal(t, 1, result.NumUpdates)
+	assert.Equal(t, 1, result.NumDeletes)
+
+	type user struct {
+		ID   int
+		Name string
+	}
+
+	var sourceUsers, targetUsers []user
+	require.NoError(t, source.Select(&sourceUsers, "SELECT id, name FROM users ORDER BY id"))
+	require.NoError(t, target.Select(&targetUsers, "SELECT id, name FROM users ORDER BY id"))
+	assert.Equal(t, sourceUsers, targetUsers)
+
+	// Now that the remaining columns are in sync, the target is too
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.False(t, results.Results[0].Synced)
+	assert.Equal(t, []string{"bio"}, results.Results[0].SkippedColumns)
+
+	// A missing primary key can never be skipped
+	job.PrimaryKeys = []string{"bio"}
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.ErrorContains(t, results.Results[0].Error, "table is missing primary key column 'bio'")
+}
+
+func TestExecJob_replace_mode(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS countries (
+			code TEXT PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "countries",
+		DSN:    "file:exec_job_replace_mode_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(
+		"INSERT INTO countries VALUES ('CA', 'Canada'), ('MX', 'Mexico'), ('US', 'USA')",
+	)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "countries",
+		DSN:    "file:exec_job_replace_mode_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+	target.MustExec("INSERT INTO countries VALUES ('CA', 'Canada'), ('FR', 'France')")
+
+	job := JobConfig{
+		PrimaryKeys: []string{"code"},
+		Columns:     []string{"code", "name"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		ReplaceMode: true,
+		DryRun:      true,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"countries": job}}
+
+	// In dry-run mode, every target row would be deleted and every source row would be inserted
+	results, err := config.ExecJob("countries")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Equal(t, 3, result.NumInserts)
+	assert.Zero(t, result.NumUpdates)
+	assert.Equal(t, 2, result.NumDeletes)
+
+	var count int
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM countries"))
+	assert.Equal(t, 2, count)
+
+	job.DryRun = false
+	config.Jobs["countries"] = job
+
+	results, err = config.ExecJob("countries")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result = results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 3, result.NumInserts)
+	assert.Equal(t, 2, result.NumDeletes)
+
+	var sourceNames, targetNames []string
+	require.NoError(t, source.Select(&sourceNames, "SELECT name FROM countries ORDER BY code"))
+	require.NoError(t, target.Select(&targetNames, "SELECT name FROM countries ORDER BY code"))
+	assert.Equal(t, sourceNames, targetNames)
+
+	// Once the target is in sync, it isn't rewritten
+	results, err = config.ExecJob("countries")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result = results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Zero(t, result.DriftRows())
+	assert.Equal(t, 3, result.TargetRowCount)
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+
+	// A source with more rows than the ceiling isn't replaced
+	job.ReplaceMaxRows = 2
+	config.Jobs["countries"] = job
+
+	results, err = config.ExecJob("countries")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.ErrorContains(
+		t, results.Results[0].Error, "source has 3 rows, which is more than replaceMaxRows (2)",
+	)
+}
+
+func TestExecJob_empty_source(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT)"
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_empty_source.db?mode=memory&cache=shared",
+	}
+
+	// The source was (accidentally) truncated
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_empty_source_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+	target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// By default, an empty source aborts the sync, and the target is untouched
+	results, err := config.ExecJob("users")
+	assert.ErrorContains(t, err, "source is empty")
+	assert.Empty(t, results.Results)
+
+	var count int
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 2, count)
+
+	// When it's allowed, every target row is deleted
+	job.AllowEmptySource = true
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.Equal(t, 2, results.Results[0].NumDeletes)
+
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Zero(t, count)
+}
+
+func TestExecJob_max_target_to_source_ratio(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT)"
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_max_target_to_source_ratio_source.db?mode=memory&cache=shared",
+	}
+
+	// The source is a partial extract of the target's rows
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_max_target_to_source_ratio_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+	target.MustExec(`
+		INSERT INTO users (id, name)
+		VALUES (1, 'Alice'), (2, 'Bob'), (3, 'Carol'), (4, 'Dan'), (5, 'Eve')
+	`)
+
+	job := JobConfig{
+		PrimaryKeys:            []string{"id"},
+		Columns:                []string{"id", "name"},
+		Source:                 sourceConfig,
+		Targets:                []TableConfig{targetConfig},
+		MaxTargetToSourceRatio: 2,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// The target has 5 rows, which is more than twice the source's 2, so nothing is deleted
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	assert.EqualError(
+		t,
+		result.Error,
+		"target has 5 rows, which is more than maxTargetToSourceRatio (2) times the source's 2 rows",
+	)
+	assert.False(t, result.Synced)
+	assert.Equal(t, 5, result.TargetRowCount)
+
+	var count int
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 5, count)
+
+	// The same goes for replacing the target's rows
+	job.ReplaceMode = true
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.ErrorContains(t, results.Results[0].Error, "more than maxTargetToSourceRatio")
+
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 5, count)
+
+	// With a higher ratio, the target's extra rows are deleted
+	job.ReplaceMode = false
+	job.MaxTargetToSourceRatio = 2.5
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result = results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 3, result.NumDeletes)
+
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 2, count)
+}
+
+func TestExecJob_float_tolerance(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS measurements (
+			id INTEGER PRIMARY KEY NOT NULL,
+			value REAL NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "measurements",
+		DSN:    "file:exec_job_float_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO measurements (id, value) VALUES (1, 0.1), (2, 2.5)")
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "measurements",
+		DSN:    "file:exec_job_float_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// id=1 differs in the last bits, but id=2 differs meaningfully
+	target.MustExec("INSERT INTO measurements (id, value) VALUES (1, 0.1 + 1e-12), (2, 2.6)")
+
+	job := JobConfig{
+		PrimaryKeys:    []string{"id"},
+		Columns:        []string{"id", "value"},
+		Source:         sourceConfig,
+		Targets:        []TableConfig{targetConfig},
+		FloatTolerance: 1e-9,
+		DryRun:         true,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"measurements": job}}
+
+	results, err := config.ExecJob("measurements")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 1, results.Results[0].NumUpdates)
+
+	// Once the meaningful difference is fixed, the near-equal floats should be considered in sync
+	target.MustExec("UPDATE measurements SET value = 2.5 WHERE id = 2")
+
+	job.DryRun = false
+	config.Jobs["measurements"] = job
+
+	results, err = config.ExecJob("measurements")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Zero(t, result.DriftRows())
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+}
+
+func TestExecJob_trim_text_columns(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			city TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_trim_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name, city) VALUES (1, 'Alice', 'LA'), (2, 'Bob', 'NY')")
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_trim_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// The target's names are padded differently, and Bob's city is padded too
+	target.MustExec(
+		"INSERT INTO users (id, name, city) VALUES (1, 'Alice  ', 'LA'), (2, 'Bob	', 'NY ')",
+	)
+
+	job := JobConfig{
+		PrimaryKeys:     []string{"id"},
+		Columns:         []string{"id", "name", "city"},
+		Source:          sourceConfig,
+		Targets:         []TableConfig{targetConfig},
+		TrimTextColumns: []string{"name"},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// Only the padded city is a difference, since city isn't trimmed
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.Equal(t, 1, results.Results[0].NumUpdates)
+
+	// The update writes the source's values as-is, so the updated row's name is no longer padded
+	var names []string
+	require.NoError(t, target.Select(&names, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Alice  ", "Bob"}, names)
+
+	// Now the padded text should be considered in sync
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Zero(t, result.DriftRows())
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+}
+
+func TestExecJob_case_insensitive_columns(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			email TEXT NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_case_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO users (id, email, name)
+		VALUES (1, 'alice@example.com', 'Alice'), (2, 'bob@example.com', 'Bob')
+	`)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_case_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// The emails differ only in case, but Bob's name also differs in case
+	target.MustExec(`
+		INSERT INTO users (id, email, name)
+		VALUES (1, 'Alice@Example.com', 'Alice'), (2, 'BOB@example.com', 'bob')
+	`)
+
+	job := JobConfig{
+		PrimaryKeys:            []string{"id"},
+		Columns:                []string{"id", "email", "name"},
+		Source:                 sourceConfig,
+		Targets:                []TableConfig{targetConfig},
+		CaseInsensitiveColumns: []string{"email"},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// Only Bob's name is a difference, since name isn't case insensitive
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.Equal(t, 1, results.Results[0].NumUpdates)
+
+	// The update writes the source's values as-is
+	var emails []string
+	require.NoError(t, target.Select(&emails, "SELECT email FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Alice@Example.com", "bob@example.com"}, emails)
+
+	// Now the emails should be considered in sync, despite differing in case
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Zero(t, result.DriftRows())
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+}
+
+func TestExecJob_treat_empty_as_null(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			nickname TEXT
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_empty_as_null_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, nickname) VALUES (1, ''), (2, ''), (3, 'Chuck')")
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_empty_as_null_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// Alice's nickname is NULL in the target, but empty in the source. Bob is missing
+	target.MustExec("INSERT INTO users (id, nickname) VALUES (1, NULL), (3, 'Chuck')")
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "nickname"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// Without treating them the same, the empty string and NULL differ
+	job.DryRun = true
+	config.Jobs["users"] = job
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 1, results.Results[0].NumUpdates)
+
+	job.DryRun = false
+	job.TreatEmptyAsNull = []string{"nickname"}
+	config.Jobs["users"] = job
+
+	// Alice is considered in sync, and only Bob is inserted
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 1, result.NumInserts)
+	assert.Zero(t, result.NumUpdates)
+	assert.Zero(t, result.NumDeletes)
+
+	// Bob's empty nickname is written as NULL
+	var nicknames []string
+	query := "SELECT COALESCE(nickname, 'NULL') FROM users ORDER BY id"
+	require.NoError(t, target.Select(&nicknames, query))
+	assert.Equal(t, []string{"NULL", "NULL", "Chuck"}, nicknames)
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.False(t, results.Results[0].Synced)
+	assert.Equal(t, results.Checksum, results.Results[0].TargetChecksum)
+
+	// Treating NULL as empty also considers them in sync
+	job.TreatEmptyAsNull = nil
+	job.TreatNullAsEmpty = []string{"nickname"}
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.False(t, results.Results[0].Synced)
+	assert.Zero(t, results.Results[0].DriftRows())
+}
+
+func TestExecJob_decimal_columns(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS products (
+			id INTEGER PRIMARY KEY NOT NULL,
+			price TEXT NOT NULL,
+			cost TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "products",
+		DSN:    "file:exec_job_decimal_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO products (id, price, cost)
+		VALUES (1, '10.50', '3.00'), (2, '0.10', '1.25')
+	`)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "products",
+		DSN:    "file:exec_job_decimal_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// The prices are formatted differently, but the second product's cost actually differs
+	target.MustExec(`
+		INSERT INTO products (id, price, cost)
+		VALUES (1, '10.5', '3'), (2, '000.1', '1.2')
+	`)
+
+	job := JobConfig{
+		PrimaryKeys:    []string{"id"},
+		Columns:        []string{"id", "price", "cost"},
+		Source:         sourceConfig,
+		Targets:        []TableConfig{targetConfig},
+		DecimalColumns: []string{"price", "cost"},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"products": job}}
+
+	results, err := config.ExecJob("products")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.Equal(t, 1, results.Results[0].NumUpdates)
+
+	// Now the decimals should be considered in sync, despite being formatted differently
+	results, err = config.ExecJob("products")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Zero(t, result.DriftRows())
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+}
+
+func TestExecJob_compare_as_strings(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "products",
+		DSN:    "file:exec_job_compare_as_strings_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(`
+		CREATE TABLE IF NOT EXISTS products (
+			id INTEGER PRIMARY KEY NOT NULL,
+			quantity INTEGER,
+			price REAL,
+			note TEXT
+		)
+	`)
+	source.MustExec(`
+		INSERT INTO products (id, quantity, price, note)
+		VALUES (1, 42, 1.5, NULL), (2, 1, 2.25, 'x'), (3, 5, 3.5, '')
+	`)
+
+	// The target stores everything as text
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "products",
+		DSN:    "file:exec_job_compare_as_strings_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS products (
+			id INTEGER PRIMARY KEY NOT NULL,
+			quantity TEXT,
+			price TEXT,
+			note TEXT
+		)
+	`)
+
+	// The first product's values are written the same. The second product's quantity is written
+	// differently, and the third product's note is NULL instead of empty
+	target.MustExec(`
+		INSERT INTO products (id, quantity, price, note)
+		VALUES (1, '42', '1.5', NULL), (2, '1.0', '2.25', 'x'), (3, '5', '3.5', NULL)
+	`)
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "quantity", "price", "note"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		DryRun:      true,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"products": job}}
+
+	// By default, every row differs, since the values' types differ
+	results, err := config.ExecJob("products")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 3, results.Results[0].NumUpdates)
+
+	// As strings, only the rows that are written differently differ
+	job.CompareAsStrings = true
+	job.DryRun = false
+	config.Jobs["products"] = job
+
+	results, err = config.ExecJob("products")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.Equal(t, 2, results.Results[0].NumUpdates)
+
+	// The values are written as-is, and then they're considered in sync
+	var quantity any
+	require.NoError(t, target.Get(&quantity, "SELECT quantity FROM products WHERE id = 2"))
+	assert.Equal(t, "1", quantity)
+
+	results, err = config.ExecJob("products")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Zero(t, result.DriftRows())
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+}
+
+func TestExecJob_json_columns(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			settings TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_json_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO users (id, settings)
+		VALUES (1, '{"theme": "dark", "tabs": [1, 2]}'), (2, '{"a": {"c": 3, "b": 2}}')
+	`)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_json_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// The first user's keys are reordered (with different whitespace), but the second user's
+	// settings actually differ
+	target.MustExec(`
+		INSERT INTO users (id, settings)
+		VALUES (1, '{"tabs":[1,2],"theme":"dark"}'), (2, '{"a": {"b": 2, "c": 4}}')
+	`)
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "settings"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		JSONColumns: []string{"settings"},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.Equal(t, 1, results.Results[0].NumUpdates)
+
+	// Now the settings should checksum the same, despite their keys being in a different order
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Zero(t, result.DriftRows())
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+
+	// The reordered settings are left as they were
+	var settings string
+	require.NoError(t, target.Get(&settings, "SELECT settings FROM users WHERE id = 1"))
+	assert.Equal(t, `{"tabs":[1,2],"theme":"dark"}`, settings)
+}
+
+func TestExecJob_auto_update_columns(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_auto_update_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO users (id, name, updated_at)
+		VALUES (1, 'Alice', '2024-01-01'), (2, 'Bob', '2024-01-01'), (3, 'Charlie', '2024-01-01')
+	`)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_auto_update_target.db?mode=memory&cache=shared",
+	}
+
+	// sqlite doesn't have `ON UPDATE CURRENT_TIMESTAMP`, so a trigger does the same thing
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+	target.MustExec(`
+		CREATE TRIGGER IF NOT EXISTS users_updated_at AFTER UPDATE ON users
+		BEGIN
+			UPDATE users SET updated_at = '2024-12-31' WHERE id = NEW.id;
+		END
+	`)
+
+	// id=1 only differs in its updated_at, and id=2's name actually differs
+	target.MustExec(`
+		INSERT INTO users (id, name, updated_at)
+		VALUES (1, 'Alice', '2024-06-01'), (2, 'Robert', '2024-06-01')
+	`)
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name", "updated_at"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		DryRun:      true,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// Without auto-update columns, every row whose updated_at differs needs an update
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 2, results.Results[0].NumUpdates)
+
+	job.DryRun = false
+	job.AutoUpdateColumns = []string{"updated_at"}
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.Equal(t, 1, results.Results[0].NumInserts)
+	assert.Equal(t, 1, results.Results[0].NumUpdates)
+
+	// The updated row's updated_at was left to the database, and the inserted row has the source's
+	var updatedAts []string
+	require.NoError(t, target.Select(&updatedAts, "SELECT updated_at FROM users ORDER BY id"))
+	assert.Equal(t, []string{"2024-06-01", "2024-12-31", "2024-01-01"}, updatedAts)
+
+	// The target no longer drifts, even though its updated_at values differ from the source's
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Zero(t, result.DriftRows())
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{`{"b": 1, "a": 2}`, `{"a":2,"b":1}`, true},
+		{` [ {"y": null, "x": true} ] `, `[{"x":true,"y":null}]`, true},
+		{`{"n": 1.50}`, `{"n":1.50}`, true},
+		{`"text"`, `"text"`, true},
+		{`{"a": 1`, "", false},
+		{`{"a": 1} {"b": 2}`, "", false},
+		{`not json`, "", false},
+	}
+
+	for _, tc := range testCases {
+		canonical, ok := canonicalJSON(tc.input)
+		assert.Equal(t, tc.ok, ok, tc.input)
+		assert.Equal(t, tc.expected, canonical, tc.input)
+	}
+}
+
+func TestCanonicalDecimal(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"10.50", "10.5", true},
+		{"10.5", "10.5", true},
+		{"010.500", "10.5", true},
+		{"10.00", "10", true},
+		{"10", "10", true},
+		{"+3.10", "3.1", true},
+		{"-3.10", "-3.1", true},
+		{"-0.00", "0", true},
+		{"0", "0", true},
+		{".5", "0.5", true},
+		{"5.", "5", true},
+		{" 1.20 ", "1.2", true},
+		{"", "", false},
+		{".", "", false},
+		{"-", "", false},
+		{"1e3", "", false},
+		{"1.2.3", "", false},
+		{"abc", "", false},
+	}
+
+	for _, tc := range testCases {
+		canonical, ok := canonicalDecimal(tc.input)
+		assert.Equal(t, tc.ok, ok, tc.input)
+		assert.Equal(t, tc.expected, canonical, tc.input)
+	}
+}
+
+func TestExecJob_location(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS events (
+			id INTEGER PRIMARY KEY NOT NULL,
+			happened_at DATETIME NOT NULL
+		)
+	`
+
+	// The source and target read times in different locations
+	sourceConfig := TableConfig{
+		Driver:   "sqlite3",
+		Table:    "events",
+		DSN:      "file:exec_job_location_source.db?mode=memory&cache=shared",
+		Location: "America/New_York",
+	}
+
+	source := table{config: sourceConfig}
+	require.NoError(t, source.connect())
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO events (id, happened_at)
+		VALUES (1, '2024-01-01 10:00:00'), (2, '2024-07-01 23:30:00')
+	`)
+
+	targetConfig := TableConfig{
+		Driver:   "sqlite3",
+		Table:    "events",
+		DSN:      "file:exec_job_location_target.db?mode=memory&cache=shared",
+		Location: "Asia/Tokyo",
+	}
+
+	target := table{config: targetConfig}
+	require.NoError(t, target.connect())
+	target.MustExec(createTable)
+
+	// The same instant as the source, but written with a different offset
+	target.MustExec("INSERT INTO events (id, happened_at) VALUES (1, '2024-01-01 19:00:00+09:00')")
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"events": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "happened_at"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+			},
+		},
+	}
+
+	// Only the missing event is inserted, since the first event is the same instant
+	results, err := config.ExecJob("events")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 1, result.NumInserts)
+	assert.Zero(t, result.NumUpdates)
+
+	var times []time.Time
+	require.NoError(t, target.Select(&times, "SELECT happened_at FROM events ORDER BY id"))
+	require.Len(t, times, 2)
+	assert.True(t, times[0].Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)), times[0])
+	assert.True(t, times[1].Equal(time.Date(2024, 7, 1, 23, 30, 0, 0, time.UTC)), times[1])
+
+	// Now the target should be in sync, even though its times are read in a different location
+	results, err = config.ExecJob("events")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.False(t, results.Results[0].Synced)
+	assert.Equal(t, results.Checksum, results.Results[0].TargetChecksum)
+}
+
+func TestExecJob_type_hints(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_type_hints_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			zip INTEGER NOT NULL,
+			score TEXT NOT NULL
+		)
+	`)
+	source.MustExec("INSERT INTO users (id, zip, score) VALUES (1, 90210, '42'), (2, 10001, '7')")
+
+	// The target's column types are the other way around
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_type_hints_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			zip TEXT NOT NULL,
+			score INTEGER NOT NULL
+		)
+	`)
+	target.MustExec("INSERT INTO users (id, zip, score) VALUES (1, '90210', 41)")
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "zip", "score"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		TypeHints:   map[string]string{"zip": "string", "score": "int"},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// Only the score of id=1 differs, since the zips are coerced to the same representation
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.Equal(t, 1, results.Results[0].NumUpdates)
+	assert.Equal(t, 1, results.Results[0].NumInserts)
+
+	rows, err := target.Queryx("SELECT zip, score FROM users ORDER BY id")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var data [][]any
+	for rows.Next() {
+		cols, err := rows.SliceScan()
+		require.NoError(t, err)
+		data = append(data, cols)
+	}
+	assert.Equal(t, [][]any{{"90210", int64(42)}, {"10001", int64(7)}}, data)
+
+	// Now the target should be considered in sync, despite the mismatched column types
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+
+	// Values that can't be coerced are an error, rather than being written as-is
+	source.MustExec("INSERT INTO users (id, zip, score) VALUES (3, 60601, 'high')")
+
+	_, err = config.ExecJob("users")
+	assert.ErrorContains(t, err, "column 'score': cannot coerce high (string) to int")
+}
+
+func TestExecJob_quoted_columns(t *testing.T) {
+	// These column names would break the SQL if they weren't quoted
+	createTable := "CREATE TABLE IF NOT EXISTS `quoted users` " +
+		"(`user id` INTEGER PRIMARY KEY NOT NULL, `full name` TEXT, `contact.email` TEXT, `order` INT)"
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "`quoted users`",
+		DSN:    "file:exec_job_quoted_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(
+		"INSERT INTO `quoted users` VALUES (1, 'Alice A', 'a@x.com', 1), (2, 'Bob B', 'b@x.com', 2)",
+	)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "`quoted users`",
+		DSN:    "file:exec_job_quoted_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+	target.MustExec(
+		"INSERT INTO `quoted users` VALUES (1, 'Alice', 'a@x.com', 1), (3, 'Dan', 'd@x.com', 3)",
+	)
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"user id"},
+				Columns:     []string{"user id", "full name", "contact.email", "order"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+			},
+		},
+	}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 1, result.NumInserts)
+	assert.Equal(t, 1, result.NumUpdates)
+	assert.Equal(t, 1, result.NumDeletes)
+
+	var names []string
+	err = target.Select(&names, "SELECT `full name` FROM `quoted users` ORDER BY `user id`")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alice A", "Bob B"}, names)
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	assert.Equal(t, results.Checksum, results.Results[0].TargetChecksum)
+
+	// Pinging should find the columns too
+	pingResults, err := config.PingJob("users", 30*time.Second)
+	require.NoError(t, err)
+	for _, result := range pingResults {
+		assert.NoError(t, result.Error)
+	}
+}
+
+func TestExecJob_read_write_split(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_split_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	// Two separate databases simulate a replica (for reads) and a primary (for writes)
+	replica := table{config: TableConfig{
+		Driver: "sqlite3",
+		DSN:    "file:exec_job_split_replica.db?mode=memory&cache=shared",
+	}}
+	replica.connect()
+	replica.MustExec(createTable)
+	replica.MustExec("INSERT INTO users (id, name) VALUES (1, 'Nick')")
+
+	primary := table{config: TableConfig{
+		Driver: "sqlite3",
+		DSN:    "file:exec_job_split_primary.db?mode=memory&cache=shared",
+	}}
+	primary.connect()
+	primary.MustExec(createTable)
+	primary.MustExec("INSERT INTO users (id, name) VALUES (1, 'Nick')")
+
+	targetConfig := TableConfig{
+		Driver:   "sqlite3",
+		Table:    "users",
+		ReadDSN:  replica.config.DSN,
+		WriteDSN: primary.config.DSN,
+	}
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+			},
+		},
+	}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 1, result.NumUpdates)
+	assert.Equal(t, 1, result.NumInserts)
+
+	// The statements were executed against the primary, but the replica wasn't written to
+	var names []string
+	require.NoError(t, primary.Select(&names, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Alice", "Bob"}, names)
+
+	names = nil
+	require.NoError(t, replica.Select(&names, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Nick"}, names)
+
+	// Once the replica catches up, the target should be in sync
+	replica.MustExec("DELETE FROM users")
+	replica.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.False(t, results.Results[0].Synced)
+}
+
+func TestExecJob_rowid(t *testing.T) {
+	// These tables have no explicit primary key, so they are matched by their implicit rowid
+	createTable := `
+		CREATE TABLE IF NOT EXISTS tags (
+			name TEXT NOT NULL,
+			color TEXT NOT NULL
+		)
+	`
+
+	sourceDSN := "file:exec_job_rowid_source.db?mode=memory&cache=shared"
+	targetDSN := "file:exec_job_rowid_target.db?mode=memory&cache=shared"
+
+	config, err := loadConfig(fmt.Sprintf(`
+        defaults:
+          driver: sqlite3
+
+        jobs:
+          tags:
+            columns: [name, color]
+            source:
+              dsn: "%s"
+              table: tags
+            targets:
+              - dsn: "%s"
+    `, sourceDSN, targetDSN))
+	require.NoError(t, err)
+	require.NoError(t, config.validate())
+	assert.Equal(t, []string{"rowid"}, config.Jobs["tags"].PrimaryKeys)
+
+	source := table{config: config.Jobs["tags"].Source}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO tags (rowid, name, color) VALUES (1, 'bug', 'red')")
+	source.MustExec("INSERT INTO tags (rowid, name, color) VALUES (2, 'feature', 'green')")
+	source.MustExec("INSERT INTO tags (rowid, name, color) VALUES (5, 'docs', 'blue')")
+
+	target := table{config: config.Jobs["tags"].Targets[0]}
+	target.connect()
+	target.MustExec(createTable)
+	target.MustExec("INSERT INTO tags (rowid, name, color) VALUES (1, 'bug', 'orange')")
+	target.MustExec("INSERT INTO tags (rowid, name, color) VALUES (3, 'stale', 'gray')")
+
+	results, err := config.ExecJob("tags")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 2, result.NumInserts)
+	assert.Equal(t, 1, result.NumUpdates)
+	assert.Equal(t, 1, result.NumDeletes)
+
+	type tag struct {
+		RowID int64 `db:"rowid"`
+		Name  string
+		Color string
+	}
+
+	var sourceTags, targetTags []tag
+	query := "SELECT rowid, name, color FROM tags ORDER BY rowid"
+	require.NoError(t, source.Select(&sourceTags, query))
+	require.NoError(t, target.Select(&targetTags, query))
+	assert.Equal(t, sourceTags, targetTags)
+
+	// rowid isn't portable across databases, so it can only be used if every table is sqlite
+	job := config.Jobs["tags"]
+	job.Targets[0].Driver = "mysql"
+	assert.ErrorContains(t, job.validate(), "can only use rowid as a primary key")
+}
+
+func TestExecJob_max_memory_bytes(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_max_memory_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)")
+	for i := 0; i < 100; i++ {
+		source.MustExec("INSERT INTO users (name) VALUES (?)", strings.Repeat("x", 100))
+	}
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name"},
+		Source:      sourceConfig,
+		Targets: []TableConfig{
+			{
+				Driver: "sqlite3",
+				Table:  "users",
+				DSN:    "file:exec_job_max_memory_target.db?mode=memory&cache=shared",
+			},
+		},
+		MaxMemoryBytes: 1024,
+		DryRun:         true,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	_, err := config.ExecJob("users")
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "table 'users' exceeds maxMemoryBytes")
+
+	// A generous limit shouldn't trip
+	job.MaxMemoryBytes = 1024 * 1024
+	config.Jobs["users"] = job
+
+	target := table{config: job.Targets[0]}
+	target.connect()
+	target.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)")
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 100, results.Results[0].NumInserts)
+}
+
+func TestExecJob_spill_threshold_rows(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			avatar BLOB
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_spill_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_spill_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// Every 7th row is missing from the target, every 5th row differs, and the target has extra rows
+	for i := 1; i <= 1000; i++ {
+		name := fmt.Sprintf("user%d", i)
+		source.MustExec("INSERT INTO users VALUES (?, ?, ?)", i, name, []byte(name))
+
+		if i%7 == 0 {
+			continue
+		}
+		if i%5 == 0 {
+			name += "-stale"
+		}
+		target.MustExec("INSERT INTO users VALUES (?, ?, ?)", i, name, []byte(name))
+	}
+	target.MustExec("INSERT INTO users VALUES (1001, 'extra', NULL), (1002, 'extra', NULL)")
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name", "avatar"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		DryRun:      true,
+		Verbose:     true,
+	}
+
+	check := func(spillThresholdRows int) SyncResult {
+		job.SpillThresholdRows = spillThresholdRows
+		job.SpillDir = t.TempDir()
+
+		config := Config{Jobs: map[string]JobConfig{"users": job}}
+		results, err := config.ExecJob("users")
+		require.NoError(t, err)
+		require.Len(t, results.Results, 1)
+		require.NoError(t, results.Results[0].Error)
+
+		// The spilled rows are deleted once the target is synced
+		spilled, err := os.ReadDir(job.SpillDir)
+		require.NoError(t, err)
+		assert.Empty(t, spilled)
+
+		result := results.Results[0]
+		result.FetchDuration, result.CompareDuration, result.WriteDuration = 0, 0, 0
+		result.PoolStats, result.PeakInUseConnections = sql.DBStats{}, 0
+		return result
+	}
+
+	// Diffing the target on disk gives the same results as diffing it in memory
+	inMemory := check(0)
+	assert.Equal(t, 142, inMemory.NumInserts)
+	assert.Equal(t, 172, inMemory.NumUpdates)
+	assert.Equal(t, 2, inMemory.NumDeletes)
+
+	assert.Equal(t, inMemory, check(100))
+	assert.Equal(t, inMemory, check(5000)) // The target is under the threshold
+
+	// Syncing from disk brings the target in sync
+	job.DryRun = false
+	result := check(100)
+	assert.True(t, result.Synced)
+
+	result = check(100)
+	assert.False(t, result.Synced)
+
+	var count int
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 1000, count)
+}
+
+func TestExecJob_since(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			updated_at DATETIME NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_since_source.db?mode=memory&cache=shared",
+	}
+
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users VALUES (1, 'Alice', ?)", old)
+	source.MustExec("INSERT INTO users VALUES (2, 'Bob', ?)", recent)
+	source.MustExec("INSERT INTO users VALUES (3, 'Charlie', ?)", recent)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_since_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// id=1 is stale but changed before the window, id=2 needs an update, id=3 needs an insert,
+	// and id=420 is not in the source (but shouldn't be deleted, since we only read a window)
+	target.MustExec("INSERT INTO users VALUES (1, 'Nick', ?)", old)
+	target.MustExec("INSERT INTO users VALUES (2, 'Robert', ?)", old)
+	target.MustExec("INSERT INTO users VALUES (420, 'Azamat', ?)", old)
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name", "updated_at"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		Since:       time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// Since requires an incremental column
+	_, err := config.ExecJob("users")
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "job has no incrementalColumn configured")
+
+	job.IncrementalColumn = "updated_at"
+	config.Jobs["users"] = job
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 1, result.NumInserts)
+	assert.Equal(t, 1, result.NumUpdates)
+	assert.Zero(t, result.NumDeletes)
+
+	var names []string
+	require.NoError(t, target.Select(&names, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Nick", "Bob", "Charlie", "Azamat"}, names)
+
+	// The attachSqlite path would copy the whole source (and delete what's missing from it), so
+	// it isn't used for a window of the source's rows
+	job.AttachSQLite = true
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Zero(t, results.Results[0].NumDeletes)
+
+	names = nil
+	require.NoError(t, target.Select(&names, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Nick", "Bob", "Charlie", "Azamat"}, names)
+}
+
+func TestExecJob_primary_key_range(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT)"
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_primary_key_range_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO users (id, name)
+		VALUES (1, 'Alice'), (2, 'Bob'), (3, 'Charlie'), (5, 'Eve'), (6, 'Frank')
+	`)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_primary_key_range_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// In the range [2, 5], id=2 and id=5 need inserts, id=3 needs an update, and id=4 needs a
+	// delete. Outside of it, id=1 is stale, id=6 is missing, and id=7 isn't in the source, but they
+	// are all left alone
+	target.MustExec(`
+		INSERT INTO users (id, name)
+		VALUES (1, 'Nick'), (3, 'Chuck'), (4, 'Dave'), (7, 'Grace')
+	`)
+
+	job := JobConfig{
+		PrimaryKeys:   []string{"id"},
+		Columns:       []string{"id", "name"},
+		Source:        sourceConfig,
+		Targets:       []TableConfig{targetConfig},
+		PrimaryKeyMin: int64(2),
+		PrimaryKeyMax: int64(5),
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.Equal(t, 3, results.SourceRowCount)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 2, result.NumInserts)
+	assert.Equal(t, 1, result.NumUpdates)
+	assert.Equal(t, 1, result.NumDeletes)
+
+	type user struct {
+		ID   int
+		Name string
+	}
+
+	var users []user
+	require.NoError(t, target.Select(&users, "SELECT id, name FROM users ORDER BY id"))
+	assert.Equal(t, []user{
+		{1, "Nick"}, {2, "Bob"}, {3, "Charlie"}, {5, "Eve"}, {7, "Grace"},
+	}, users)
+
+	// A range can be open-ended. Now only id=6 (missing) and id=7 (not in the source) are out of
+	// sync at or above id=6
+	job.PrimaryKeyMin = int64(6)
+	job.PrimaryKeyMax = nil
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 1, results.Results[0].NumInserts)
+	assert.Equal(t, 1, results.Results[0].NumDeletes)
+
+	users = nil
+	require.NoError(t, target.Select(&users, "SELECT id, name FROM users ORDER BY id"))
+	assert.Equal(t, []user{
+		{1, "Nick"}, {2, "Bob"}, {3, "Charlie"}, {5, "Eve"}, {6, "Frank"},
+	}, users)
+
+	// The source can have no rows in the range, in which case only the target's rows in it are
+	// deleted
+	job.PrimaryKeyMin = int64(7)
+	job.PrimaryKeyMax = int64(100)
+	config.Jobs["users"] = job
+
+	target.MustExec("INSERT INTO users (id, name) VALUES (8, 'Heidi'), (200, 'Ivan')")
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.Zero(t, results.SourceRowCount)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.Zero(t, results.Results[0].NumInserts)
+	assert.Equal(t, 1, results.Results[0].NumDeletes)
+
+	users = nil
+	require.NoError(t, target.Select(&users, "SELECT id, name FROM users ORDER BY id"))
+	assert.Equal(t, []user{
+		{1, "Nick"}, {2, "Bob"}, {3, "Charlie"}, {5, "Eve"}, {6, "Frank"}, {200, "Ivan"},
+	}, users)
+
+	// The range can't be empty
+	job.PrimaryKeyMin = int64(6)
+	job.PrimaryKeyMax = int64(5)
+	config.Jobs["users"] = job
+	_, err = config.ExecJob("users")
+	assert.ErrorContains(t, err, "primary key range is empty (min 6 is greater than max 5)")
+
+	// It needs a single primary key
+	job.PrimaryKeyMin = int64(2)
+	job.PrimaryKeys = []string{"id", "name"}
+	config.Jobs["users"] = job
+	_, err = config.ExecJob("users")
+	assert.ErrorContains(
+		t, err, "job has a composite primary key, so it can't use a primary key range",
+	)
+
+	// Replacing the targets would delete their rows outside of the range
+	job.PrimaryKeys = []string{"id"}
+	job.ReplaceMode = true
+	config.Jobs["users"] = job
+	_, err = config.ExecJob("users")
+	assert.ErrorContains(t, err, "job uses replaceMode, so it can't use a primary key range")
+}
+
+func TestExecJob_insert_ignore(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT)"
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_insert_ignore_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob'), (3, 'Charlie')")
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_insert_ignore_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	// Another writer inserts id=2 after the target is read, but before the sync inserts it
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		BatchSize:   10,
+		Approve: func(SyncResult) bool {
+			target.MustExec("INSERT INTO users (id, name) VALUES (2, 'Bobby')")
+			return true
+		},
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// By default, the race fails the sync (and the batch isn't inserted)
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.ErrorContains(t, results.Results[0].Error, "UNIQUE constraint failed")
+
+	var names []string
+	require.NoError(t, target.Select(&names, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Bobby"}, names)
+
+	// With insertIgnore, the row that was already inserted is skipped, and the others are inserted
+	target.MustExec("DELETE FROM users")
+	job.InsertIgnore = true
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.Equal(t, 3, results.Results[0].NumInserts)
+
+	names = nil
+	require.NoError(t, target.Select(&names, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Alice", "Bobby", "Charlie"}, names)
+}
+
+func TestCompareValues(t *testing.T) {
+	// Numbers are ordered by value, and integers are compared exactly, including a BIGINT UNSIGNED
+	// that is too big for an int64 (which a typed scan keeps as a uint64)
+	values := []any{
+		"abc",
+		uint64(math.MaxUint64),
+		int64(math.MaxInt64),
+		1.5,
+		nil,
+		int64(math.MaxInt64 - 1),
+		uint64(math.MaxInt64) + 1,
+		int64(-3),
+	}
+
+	slices.SortFunc(values, compareValues)
+	assert.Equal(t, []any{
+		nil,
+		int64(-3),
+		1.5,
+		int64(math.MaxInt64 - 1),
+		int64(math.MaxInt64),
+		uint64(math.MaxInt64) + 1,
+		uint64(math.MaxUint64),
+		"abc",
+	}, values)
+
+	assert.Zero(t, compareValues(uint64(math.MaxUint64), uint64(math.MaxUint64)))
+}
+
+func TestTable_insert(t *testing.T) {
+	job := JobConfig{
+		PrimaryKeys:  []string{"id", "region"},
+		Columns:      []string{"id", "region", "name"},
+		InsertIgnore: true,
+	}
+
+	tests := []struct {
+		driver   string
+		expected string
+	}{
+		{"mysql", "INSERT IGNORE INTO users (`id`,`region`,`name`) VALUES (?,?,?)"},
+		{
+			"sqlite3",
+			"INSERT INTO users (`id`,`region`,`name`) VALUES (?,?,?) " +
+				"ON CONFLICT (`id`, `region`) DO NOTHING",
+		},
+	}
+
+	for _, test := range tests {
+		target := job.newTable(TableConfig{Driver: test.driver, Table: "users"})
+
+		sql, _, err := target.insert().Values(1, "us", "Alice").ToSql()
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, sql)
+	}
+
+	// Otherwise, conflicting inserts aren't ignored
+	job.InsertIgnore = false
+	target := job.newTable(TableConfig{Driver: "mysql", Table: "users"})
+
+	sql, _, err := target.insert().Values(1, "us", "Alice").ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO users (`id`,`region`,`name`) VALUES (?,?,?)", sql)
+}
+
+func TestExecJob_typed_scan(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_typed_scan_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, active INTEGER NOT NULL)
+	`)
+	source.MustExec("INSERT INTO users (id, active) VALUES (1, 1), (2, 0)")
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_typed_scan_target.db?mode=memory&cache=shared",
+	}
+
+	// The target has the same values, but the driver returns a BOOLEAN column's values as bools
+	// (like mysql returns a TINYINT's values as text), so they are represented differently
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, active BOOLEAN NOT NULL)
+	`)
+	target.MustExec("INSERT INTO users (id, active) VALUES (1, 1), (2, 0)")
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "active"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		DryRun:      true,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// By default, every row looks like it changed, and the checksums differ
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 2, results.Results[0].NumUpdates)
+	assert.NotEqual(t, results.Checksum, results.Results[0].TargetChecksum)
+
+	// With a typed scan, both columns' values are integers, so the tables are in sync
+	job.TypedScan = true
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Zero(t, results.Results[0].DriftRows())
+	assert.Equal(t, results.Checksum, results.Results[0].TargetChecksum)
+}
+
+func TestExecJob_phases(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT)"
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_phases_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	tests := []struct {
+		name          string
+		phases        Phase
+		counts        [3]int // The expected number of inserts, updates, and deletes
+		expectedNames []string
+	}{
+		// id=1 needs an update, id=2 needs an insert, and id=3 needs a delete
+		{"inserts", PhaseInserts, [3]int{1, 0, 0}, []string{"Nick", "Bob", "Charlie"}},
+		{"updates", PhaseUpdates, [3]int{0, 1, 0}, []string{"Alice", "Charlie"}},
+		{"deletes", PhaseDeletes, [3]int{0, 0, 1}, []string{"Nick"}},
+		{
+			"inserts_updates",
+			PhaseInserts | PhaseUpdates,
+			[3]int{1, 1, 0},
+			[]string{"Alice", "Bob", "Charlie"},
+		},
+		{"inserts_deletes", PhaseInserts | PhaseDeletes, [3]int{1, 0, 1}, []string{"Nick", "Bob"}},
+		{"updates_deletes", PhaseUpdates | PhaseDeletes, [3]int{0, 1, 1}, []string{"Alice"}},
+		{"all", AllPhases, [3]int{1, 1, 1}, []string{"Alice", "Bob"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targetConfig := TableConfig{
+				Driver: "sqlite3",
+				Table:  "users",
+				DSN:    fmt.Sprintf("file:exec_job_phases_%s.db?mode=memory&cache=shared", tt.name),
+			}
+
+			target := table{config: targetConfig}
+			target.connect()
+			target.MustExec(createTable)
+			target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Nick'), (3, 'Charlie')")
+
+			job := JobConfig{
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+				Phases:      tt.phases,
+			}
+
+			config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+			results, err := config.ExecJob("users")
+			require.NoError(t, err)
+			require.Len(t, results.Results, 1)
+
+			// Only the selected phases are counted
+			result := results.Results[0]
+			require.NoError(t, result.Error)
+			counts := [3]int{result.NumInserts, result.NumUpdates, result.NumDeletes}
+			assert.Equal(t, tt.counts, counts)
+
+			var names []string
+			require.NoError(t, target.Select(&names, "SELECT name FROM users ORDER BY id"))
+			assert.Equal(t, tt.expectedNames, names)
+		})
+	}
+
+	// A subset of phases can't be combined with replaceMode or checkpointFile
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{sourceConfig},
+		Phases:      PhaseInserts,
+		ReplaceMode: true,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+	_, err := config.ExecJob("users")
+	assert.ErrorContains(t, err, "job uses replaceMode, so it can't run a subset of phases")
+
+	job.ReplaceMode = false
+	job.CheckpointFile = filepath.Join(t.TempDir(), "checkpoints.json")
+	config.Jobs["users"] = job
+
+	_, err = config.ExecJob("users")
+	assert.ErrorContains(t, err, "job uses checkpointFile, so it can't run a subset of phases")
+}
+
+func TestExecJob_noop_target(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_noop_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)")
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      sourceConfig,
+				Targets: []TableConfig{
+					{Label: "in sync", Driver: "noop", Table: "users"},
+					{Label: "empty", Driver: "noop", Table: "users", DSN: "empty"},
+				},
+			},
+		},
+	}
+	require.NoError(t, config.validate())
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 2)
+
+	for _, result := range results.Results {
+		require.NoError(t, result.Error)
+
+		switch result.Target.Label {
+		case "in sync":
+			assert.False(t, result.Synced)
+			assert.Equal(t, results.Checksum, result.TargetChecksum)
+			assert.Zero(t, result.DriftRows())
+		case "empty":
+			assert.True(t, result.Synced)
+			assert.NotEqual(t, results.Checksum, result.TargetChecksum)
+			assert.Equal(t, 2, result.NumInserts)
+		default:
+			t.Fatalf("unexpected target: %s", result.Target.Label)
+		}
+	}
+
+	// A noop target can always be pinged
+	pingResults, err := config.PingJob("users", 30*time.Second)
+	require.NoError(t, err)
+	require.Len(t, pingResults, 3)
+	for _, result := range pingResults {
+		assert.NoError(t, result.Error)
+	}
+}
+
+func TestChecksumData(t *testing.T) {
+	// checksumMarshaled is how the checksum was computed before it was streamed
+	checksumMarshaled := func(data [][]any) string {
+		jsonData, err := json.Marshal(data)
+		require.NoError(t, err)
+
+		sum := md5.Sum(jsonData)
+		return hex.EncodeToString(sum[:])
+	}
+
+	testCases := [][][]any{
+		nil,
+		{},
+		{{}},
+		{{int64(1), "Alice", nil}},
+		{
+			{int64(1), "Alice <alice@example.com>", 30.5, true},
+			{int64(2), []byte("Bob & \"friends\""), nil, false},
+			{int64(3), time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC), "日本", int64(-1)},
+		},
+	}
+
+	for _, data := range testCases {
+		checksum, err := checksumData(data)
+		require.NoError(t, err)
+		assert.Equal(t, checksumMarshaled(data), checksum, "%v", data)
+	}
+
+	// A large table should checksum the same too
+	var large [][]any
+	for i := 0; i < 10000; i++ {
+		large = append(large, []any{int64(i), fmt.Sprintf("user%d", i), float64(i) / 3})
+	}
+
+	checksum, err := checksumData(large)
+	require.NoError(t, err)
+	assert.Equal(t, checksumMarshaled(large), checksum)
+
+	// Unserializable values are still an error
+	_, err = checksumData([][]any{{make(chan int)}})
+	assert.Error(t, err)
+}
+
+func TestKeyOf(t *testing.T) {
+	job := JobConfig{
+		PrimaryKeys: []string{"first", "second"},
+		Columns:     []string{"first", "second", "name"},
+	}
+	source := job.newTable(TableConfig{})
+
+	// These rows would have the same key if the primary key values were naively joined with "|"
+	rows := [][]any{
+		{"a|b", "c", "Alice"},
+		{"a", "b|c", "Bob"},
+		{[]byte("a|b"), "c", "Charlie"}, // Same key as the first row, since []byte becomes string
+		{`a\`, "|b|c", "Dan"},
+		{"a", `\|b|c`, "Eve"},
+	}
+
+	keys := make([]primaryKeyTuple, len(rows))
+	encodedKeys := make([]string, len(rows))
+	for i, row := range rows {
+		keys[i] = source.keyOf(row)
+
+		var err error
+		encodedKeys[i], err = encodeKey(keys[i])
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, keys[0], keys[2])
+	assert.Equal(t, encodedKeys[0], encodedKeys[2])
+
+	// Every other pair of keys must remain distinct, both as map keys and when encoded as strings
+	// (like in checkpoint files)
+	for _, i := range []int{0, 1, 3, 4} {
+		for _, j := range []int{0, 1, 3, 4} {
+			if i == j {
+				continue
+			}
+
+			assert.NotEqual(t, keys[i], keys[j], "rows %d and %d", i, j)
+			assert.NotEqual(t, encodedKeys[i], encodedKeys[j], "rows %d and %d", i, j)
+		}
+	}
+
+	entryMap := map[primaryKeyTuple][]any{}
+	for i, row := range rows {
+		entryMap[keys[i]] = row
+	}
+	assert.Len(t, entryMap, 4)
+}
+
+func TestWhereClause(t *testing.T) {
+	job := JobConfig{
+		PrimaryKeys: []string{"org_id", "id"},
+		Columns:     []string{"name", "id", "org_id"},
+	}
+	target := job.newTable(TableConfig{Driver: "sqlite3"})
+
+	// The key's values are in primary key order, not column order
+	key := target.keyOf([]any{"Bob", int64(2), int64(1)})
+	assert.Equal(t, primaryKeyTuple{First: int64(1), Second: int64(2)}, key)
+
+	where := key.whereClause(target.quoteColumns(target.primaryKeys))
+	assert.Equal(t, sq.Eq{"`org_id`": int64(1), "`id`": int64(2)}, where)
+}
+
+func TestRowKey_String(t *testing.T) {
+	single := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"name", "id"},
+	}
+	singleTable := single.newTable(TableConfig{})
+
+	assert.Equal(t, "id=2", singleTable.rowKeyOf([]any{"Bob", int64(2)}).String())
+	assert.Equal(t, "id=NULL", singleTable.rowKeyOf([]any{"Bob", nil}).String())
+
+	composite := JobConfig{
+		PrimaryKeys: []string{"org_id", "email", "region"},
+		Columns:     []string{"email", "org_id", "region", "name"},
+	}
+	compositeTable := composite.newTable(TableConfig{})
+
+	// The values are in primary key order, and text values are quoted, so a value that contains
+	// the separator can't be mistaken for another column
+	key := compositeTable.rowKeyOf([]any{[]byte("a@b.com"), int64(1), nil, "Alice"})
+	assert.Equal(t, `org_id=1, email="a@b.com", region=NULL`, key.String())
+
+	key = compositeTable.rowKeyOf([]any{`x", region="y`, int64(1), "eu", "Alice"})
+	assert.Equal(t, `org_id=1, email="x\", region=\"y", region="eu"`, key.String())
+
+	key = compositeTable.rowKeyOf([]any{"", 1.5, []byte{0xff}, "Alice"})
+	assert.Equal(t, `org_id=1.5, email="", region="\xff"`, key.String())
+}
+
+func TestExecJob_row_key_in_error(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_row_key_in_error_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			org_id INTEGER NOT NULL,
+			email TEXT NOT NULL,
+			name TEXT,
+			PRIMARY KEY (org_id, email)
+		)
+	`)
+	source.MustExec(`
+		INSERT INTO users (org_id, email, name)
+		VALUES (1, 'alice@example.com', 'Alice'), (1, 'bob@example.com', NULL)
+	`)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_row_key_in_error_target.db?mode=memory&cache=shared",
+	}
+
+	// The target requires a name, which one of the source's rows doesn't have
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			org_id INTEGER NOT NULL,
+			email TEXT NOT NULL,
+			name TEXT NOT NULL,
+			PRIMARY KEY (org_id, email)
+		)
+	`)
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"org_id", "email"},
+				Columns:     []string{"org_id", "email", "name"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+				BatchSize:   1, // Insert each row separately, so the error names the row
+			},
+		},
+	}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	var syncErr *SyncError
+	require.ErrorAs(t, results.Results[0].Error, &syncErr)
+	assert.ErrorContains(
+		t, syncErr, `failed to insert row org_id=1, email="bob@example.com": NOT NULL constraint failed`,
+	)
+
+	// By default, the rows are inserted in batches, so the error names the batch's range of rows
+	job := config.Jobs["users"]
+	job.BatchSize = 0
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	require.ErrorAs(t, results.Results[0].Error, &syncErr)
+	assert.ErrorContains(t, syncErr, `failed to insert 2 rows (org_id=1, email="alice@example.com" `+
+		`to org_id=1, email="bob@example.com"): NOT NULL constraint failed`)
+}
+
+func TestExecJob_failed_write_rolls_back(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_failed_write_rolls_back_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT)")
+	source.MustExec(`
+		INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Robert'), (4, 'Dan'), (5, NULL)
+	`)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_failed_write_rolls_back_target.db?mode=memory&cache=shared",
+	}
+
+	// The target requires a name, which one of the source's rows doesn't have
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT NOT NULL)
+	`)
+	target.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob'), (3, 'Carol')")
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+				BatchSize:   1, // Insert each row separately, so Dan is inserted before the failure
+			},
+		},
+	}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	var syncErr *SyncError
+	require.ErrorAs(t, result.Error, &syncErr)
+	assert.ErrorContains(t, syncErr, "failed to insert row id=5: NOT NULL constraint failed")
+	assert.ErrorContains(t, syncErr, "(rolled back)")
+	assert.False(t, result.Synced)
+
+	// The delete, the update, and the insert before the failure were all rolled back
+	var rows []struct {
+		ID   int
+		Name string
+	}
+	err = target.Select(&rows, "SELECT id, name FROM users ORDER BY id")
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "Alice", rows[0].Name)
+	assert.Equal(t, "Bob", rows[1].Name)
+	assert.Equal(t, "Carol", rows[2].Name)
+}
+
+func TestCheckPrimaryKeyIndices(t *testing.T) {
+	testCases := []struct {
+		primaryKeys []string
+		columns     []string
+		expectedErr string
+	}{
+		{[]string{"id"}, []string{"id", "name"}, ""},
+		{[]string{"id"}, []string{"name", "id"}, ""},
+		{[]string{"org_id", "id"}, []string{"name", "id", "org_id"}, ""},
+		{[]string{"id", "org_id"}, []string{"name", "id", "org_id"}, ""},
+		{[]string{"c", "a", "b"}, []string{"a", "b", "c"}, ""},
+		{[]string{"rowid"}, []string{"name"}, ""},
+		{[]string{"age"}, []string{"id", "name"}, "primary keys [age] are not all in columns"},
+	}
+
+	for _, tc := range testCases {
+		job := JobConfig{PrimaryKeys: tc.primaryKeys, Columns: tc.columns}
+
+		err := job.checkPrimaryKeyIndices()
+		if tc.expectedErr == "" {
+			assert.NoError(t, err, tc.primaryKeys)
+		} else {
+			assert.ErrorContains(t, err, tc.expectedErr)
+		}
+	}
+}
+
+func TestBatchInserts(t *testing.T) {
+	// A wide table, where 1000 rows per statement would exceed sqlite's placeholder limit
+	columns := make([]string, 100)
+	for i := range columns {
+		columns[i] = fmt.Sprintf("col%d", i)
+	}
+
+	rows := make([][]any, 1000)
+	for i := range rows {
+		rows[i] = make([]any, len(columns))
+		for j := range columns {
+			rows[i][j] = int64(i*len(columns) + j)
+		}
+	}
+
+	target := table{
+		config:    TableConfig{Driver: "sqlite3", Table: "wide"},
+		columns:   columns,
+		batchSize: 1000,
+	}
+
+	assert.Equal(t, 327, target.insertBatchSize())
+
+	inserts := target.batchInserts(rows)
+	require.Len(t, inserts, 4)
+
+	var numArgs int
+	for _, insert := range inserts {
+		_, args, err := insert.ToSql()
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(args), maxPlaceholders["sqlite3"])
+		numArgs += len(args)
+	}
+	assert.Equal(t, len(rows)*len(columns), numArgs)
+
+	// mysql allows more placeholders per statement
+	target.config.Driver = "mysql"
+	assert.Equal(t, 655, target.insertBatchSize())
+
+	// A narrow table uses the configured batch size
+	target.columns = []string{"id", "name"}
+	assert.Equal(t, 1000, target.insertBatchSize())
+
+	// Without a batch size, each row is inserted separately
+	target.batchSize = 0
+	assert.Equal(t, 1, target.insertBatchSize())
+	assert.Len(t, target.batchInserts(rows), len(rows))
+
+	// A job without a batch size uses the default
+	assert.Equal(t, defaultBatchSize, JobConfig{}.batchSize())
+	assert.Equal(t, 10, JobConfig{BatchSize: 10}.batchSize())
+}
+
+func TestExecJob_batch_size(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_batch_size_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	for i := 1; i <= 25; i++ {
+		source.MustExec("INSERT INTO users (id, name) VALUES (?, ?)", i, fmt.Sprintf("user%d", i))
+	}
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_batch_size_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+				BatchSize:   10,
+			},
+		},
+	}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.Equal(t, 25, results.Results[0].NumInserts)
+
+	var count int
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 25, count)
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	assert.Equal(t, results.Checksum, results.Results[0].TargetChecksum)
+}
+
+func TestSelectQuery(t *testing.T) {
+	source := table{
+		config:      TableConfig{Driver: "sqlite3", Table: "users"},
+		primaryKeys: []string{"id"},
+		columns:     []string{"name", "id", "age"},
+	}
+
+	query, err := source.selectQuery()
+	require.NoError(t, err)
+
+	sql, args, err := query.ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `name`, `id`, `age` FROM users ORDER BY `id`", sql)
+	assert.Empty(t, args)
+
+	// In a mysql transaction, the rows that are read are locked
+	mysqlSource := source
+	mysqlSource.config.Driver = "mysql"
+	mysqlSource.tx = &sqlx.Tx{}
+
+	query, err = mysqlSource.selectQuery()
+	require.NoError(t, err)
+
+	sql, _, err = query.ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `name`, `id`, `age` FROM users ORDER BY `id` FOR UPDATE", sql)
+
+	// The job's source index hint goes right after the table name
+	job := JobConfig{
+		Columns:         []string{"id", "name"},
+		PrimaryKeys:     []string{"id"},
+		Source:          TableConfig{Driver: "mysql", Table: "users"},
+		SourceIndexHint: "FORCE INDEX (PRIMARY)",
+	}
+
+	query, err = job.newSourceTable().selectQuery()
+	require.NoError(t, err)
+
+	sql, _, err = query.ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `id`, `name` FROM users FORCE INDEX (PRIMARY) ORDER BY `id`", sql)
+
+	// Targets are read without it
+	query, err = job.newTable(TableConfig{Driver: "mysql", Table: "users"}).selectQuery()
+	require.NoError(t, err)
+
+	sql, _, err = query.ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `id`, `name` FROM users ORDER BY `id`", sql)
+
+	// We should never fall back to `SELECT *`
+	source.columns = nil
+	_, err = source.selectQuery()
+	assert.ErrorContains(t, err, "no columns to select")
+
+	source.columns = []string{"*"}
+	_, err = source.selectQuery()
+	assert.ErrorContains(t, err, "cannot select wildcard column")
+}
+
+func TestScanEach_column_count_mismatch(t *testing.T) {
+	source := table{
+		config: TableConfig{
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    "file:scan_each_column_count.db?mode=memory&cache=shared",
+		},
+		primaryKeys:       []string{"id"},
+		primaryKeyIndices: []int{0},
+		columns:           []string{"id", "name"},
+	}
+
+	require.NoError(t, source.connect())
+	source.MustExec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	source.MustExec("INSERT INTO users (id, name, age) VALUES (1, 'Alice', 30)")
+
+	scan := func(query string) ([][]any, error) {
+		rows, err := source.Queryx(query)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var scanned [][]any
+		err = source.scanEach(rows, func(row []any) error {
+			scanned = append(scanned, row)
+			return nil
+		})
+		return scanned, err
+	}
+
+	scanned, err := scan("SELECT id, name FROM users")
+	require.NoError(t, err)
+	assert.Len(t, scanned, 1)
+
+	// A query that returns more (or fewer) columns than the table's would misalign every row
+	scanned, err = scan("SELECT * FROM users")
+	assert.EqualError(
+		t, err, "table 'users': query returned 3 columns [id name age], but expected 2 columns [id name]",
+	)
+	assert.Empty(t, scanned)
+
+	_, err = scan("SELECT name FROM users")
+	assert.EqualError(
+		t, err, "table 'users': query returned 1 columns [name], but expected 2 columns [id name]",
+	)
+}
+
+func TestGetEntries_primary_key_out_of_range(t *testing.T) {
+	// The table's primary key index is past the columns that its query selects, as if its columns
+	// and primary keys had been set up inconsistently
+	source := table{
+		config: TableConfig{
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    "file:get_entries_primary_key_out_of_range.db?mode=memory&cache=shared",
+		},
+		primaryKeys:       []string{"id"},
+		primaryKeyIndices: []int{1},
+		columns:           []string{"name"},
+	}
+
+	require.NoError(t, source.connect())
+	source.MustExec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	var entries [][]any
+	var err error
+	require.NotPanics(t, func() {
+		entries, _, err = source.getEntries()
+	})
+	assert.EqualError(
+		t,
+		err,
+		"table 'users': primary key 'id' is column 1, but the query only returned 1 columns [name]",
+	)
+	assert.Empty(t, entries)
+}
+
+func TestExecJob_mysql(t *testing.T) {
+	dbName := os.Getenv("MYSQL_DB_NAME")
+	dbPortStr := os.Getenv("MYSQL_DB_PORT")
+	dbPort, _ := strconv.Atoi(dbPortStr)
+
+	createTable := func(name string) string {
+		return fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				id INT PRIMARY KEY NOT NULL,
+				name TEXT NOT NULL,
+				age INT NOT NULL,
+				created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)
+		`, name)
+	}
+
+	sourceConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "users",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	source := table{config: sourceConfig}
+	err := source.connect()
+	require.NoError(t, err)
+	source.MustExec(createTable(sourceConfig.Table))
+
+	expectedData := [][]any{
+		{1, "Alice", 30, "2024-05-29 00:00:00"},
+		{2, "Bob", 25, "2024-04-20 00:00:00"},
+		{3, "Charlie", 35, "2024-04-27 00:00:00"},
+	}
+
+	insert := sq.
+		Insert(sourceConfig.Table).
+		Columns("id", "name", "age", "created_at")
+
+	for _, row := range expectedData {
+		insert = insert.Values(row...)
+	}
+
+	sql, args, err := insert.ToSql()
+	require.NoError(t, err)
+
+	// Insert some data into the source
+	source.MustExec(sql, args...)
+
+	target1Config := TableConfig{
+		Driver: "mysql",
+		Table:  "users2",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	target1 := table{config: target1Config}
+	err = target1.connect()
+	require.NoError(t, err)
+	target1.MustExec(createTable(target1Config.Table))
+
+	// target1 has some data that needs to be updated/deleted
+	target1.MustExec(
+		fmt.Sprintf(
+			"INSERT INTO %s (id, name, age) VALUES (1, 'Nick', 31)",
+			target1Config.Table,
+		),
+	)
+	target1.MustExec(
+		fmt.Sprintf(
+			"INSERT INTO %s (id, name, age) VALUES (420, 'Azamat', 69)",
+			target1Config.Table,
+		),
+	)
+
+	target2Config := TableConfig{
+		Driver: "mysql",
+		Table:  "users3",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	target2 := table{config: target2Config}
+	err = target2.connect()
+	require.NoError(t, err)
+	target2.MustExec(createTable(target2Config.Table))
+
+	// target2 has no data
+
+	target3Config := TableConfig{
+		Label:  "already in sync",
+		Driver: "mysql",
+		Table:  "users4",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	target3 := table{config: target3Config}
+	err = target3.connect()
+	require.NoError(t, err)
+	target3.MustExec(createTable(target3Config.Table))
+
+	// table3 is already in sync
+	insert = sq.Insert(target3Config.Table).Columns("id", "name", "age", "created_at")
+
+	for _, row := range expectedData {
+		insert = insert.Values(row...)
+	}
+
+	sql, args, err = insert.ToSql()
+	require.NoError(t, err)
+	target3.MustExec(sql, args...)
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name", "age", "created_at"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{target1Config, target2Config, target3Config},
+			},
+		},
+	}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 3)
+
+	for _, result := range results.Results {
+		assert.NoError(t, result.Error)
+
+		if result.Target.Label == "already in sync" {
+			assert.False(t, result.Synced)
+		} else {
+			assert.True(t, result.Synced)
+		}
+	}
+
+	// Check that the data was copied to each target
+	for _, target := range []table{target1, target2, target3} {
+		query := fmt.Sprintf("SELECT * FROM %s", target.config.Table)
+		rows, err := target.Queryx(query)
+		require.NoError(t, err)
+
+		defer rows.Close()
+
+		var data [][]any
+		for rows.Next() {
+			cols, err := rows.SliceScan()
+			require.NoError(t, err)
+			data = append(data, cols)
+		}
+
+		require.Equal(t, len(expectedData), len(data))
+
+		// Make sure the data is correct
+		for i := range expectedData {
+			require.Len(t, data[i], len(expectedData[i]))
+			for j := range expectedData[i] {
+				require.EqualValues(t, expectedData[i][j], data[i][j])
+			}
+		}
+	}
+}
+
+func TestExecJob_mysql_multiple_primary_key(t *testing.T) {
+	dbName := os.Getenv("MYSQL_DB_NAME")
+	dbPortStr := os.Getenv("MYSQL_DB_PORT")
+	dbPort, _ := strconv.Atoi(dbPortStr)
+
+	createTable := func(name string) string {
+		return fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				name VARCHAR(69) NOT NULL,
+				age INT NOT NULL,
+				favoriteColor TEXT NOT NULL,
+				PRIMARY KEY (age, name)
+			)
+		`, name)
+	}
+
+	sourceConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "users_multi_pk",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	source := table{config: sourceConfig}
+	err := source.connect()
+	require.NoError(t, err)
+	source.MustExec(createTable(sourceConfig.Table))
+
+	expectedData := [][]any{
+		{"Bob", 25, "blue"},
+		{"Alice", 30, "red"},
+		{"Charlie", 35, "green"},
+	}
+
+	insert := sq.Insert(sourceConfig.Table).Columns("name", "age", "favoriteColor")
+
+	for _, row := range expectedData {
+		insert = insert.Values(row...)
+	}
+
+	sql, args, err := insert.ToSql()
+	require.NoError(t, err)
+
+	// Insert some data into the source
+	source.MustExec(sql, args...)
+
+	target1Config := TableConfig{
+		Driver: "mysql",
+		Table:  "users_multi_pk_1",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	target1 := table{config: target1Config}
+	err = target1.connect()
+	require.NoError(t, err)
+	target1.MustExec(createTable(target1Config.Table))
+
+	// target1 has no data
+
+	primaryKeys := []string{"age", "name"}
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: primaryKeys,
+				Columns:     []string{"name", "age", "favoriteColor"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{target1Config},
+			},
+		},
+	}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	for _, result := range results.Results {
+		assert.NoError(t, result.Error)
+		assert.True(t, result.Synced)
+	}
+
+	// Check that the data was copied to the target
+	order := strings.Join(primaryKeys, ", ")
+	query := fmt.Sprintf("SELECT * FROM %s ORDER BY %s", target1.config.Table, order)
+	rows, err := target1.Queryx(query)
+	require.NoError(t, err)
+
+	defer rows.Close()
+
+	var data [][]any
+	for rows.Next() {
+		cols, err := rows.SliceScan()
+		require.NoError(t, err)
+		data = append(data, cols)
+	}
+
+	require.Equal(t, len(expectedData), len(data))
+
+	// Make sure the data is correct
+	for i := range expectedData {
+		require.Len(t, data[i], len(expectedData[i]))
+		for j := range expectedData[i] {
+			require.EqualValues(t, expectedData[i][j], data[i][j])
+		}
+	}
+}
+
+func TestExecJob_mysql_quoted_columns(t *testing.T) {
+	dbName := os.Getenv("MYSQL_DB_NAME")
+	dbPortStr := os.Getenv("MYSQL_DB_PORT")
+	dbPort, _ := strconv.Atoi(dbPortStr)
+
+	createTable := func(name string) string {
+		return fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				`+"`user id`"+` INT PRIMARY KEY NOT NULL,
+				`+"`full name`"+` TEXT,
+				`+"`contact.email`"+` TEXT,
+				`+"`order`"+` INT
+			)
+		`, name)
+	}
+
+	sourceConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "quoted_users",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	source := table{config: sourceConfig}
+	err := source.connect()
+	require.NoError(t, err)
+	source.MustExec(createTable(sourceConfig.Table))
+	source.MustExec(
+		"INSERT INTO quoted_users VALUES (1, 'Alice A', 'a@x.com', 1), (2, 'Bob B', 'b@x.com', 2)",
+	)
+
+	targetConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "quoted_users2",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	target := table{config: targetConfig}
+	err = target.connect()
+	require.NoError(t, err)
+	target.MustExec(createTable(targetConfig.Table))
+	target.MustExec(
+		"INSERT INTO quoted_users2 VALUES (1, 'Alice', 'a@x.com', 1), (3, 'Dan', 'd@x.com', 3)",
+	)
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"user id"},
+				Columns:     []string{"user id", "full name", "contact.email", "order"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+			},
+		},
+	}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 1, result.NumInserts)
+	assert.Equal(t, 1, result.NumUpdates)
+	assert.Equal(t, 1, result.NumDeletes)
+
+	var names []string
+	err = target.Select(&names, "SELECT `full name` FROM quoted_users2 ORDER BY `user id`")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alice A", "Bob B"}, names)
+}
+
+func TestExecJob_mysql_decimal_columns(t *testing.T) {
+	dbName := os.Getenv("MYSQL_DB_NAME")
+	dbPortStr := os.Getenv("MYSQL_DB_PORT")
+	dbPort, _ := strconv.Atoi(dbPortStr)
+
+	sourceConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "decimal_products",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	// The source stores prices with a scale of 2, so they come back as e.g. "10.50"
+	source := table{config: sourceConfig}
+	err := source.connect()
+	require.NoError(t, err)
+	source.MustExec(`
+		CREATE TABLE IF NOT EXISTS decimal_products (
+			id INT PRIMARY KEY NOT NULL,
+			price DECIMAL(10,2) NOT NULL
+		)
+	`)
+	source.MustExec("INSERT INTO decimal_products VALUES (1, 10.5), (2, 3), (3, 0.1)")
+
+	targetConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "decimal_products2",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	// The target stores prices with a scale of 4, so they come back as e.g. "10.5000"
+	target := table{config: targetConfig}
+	err = target.connect()
+	require.NoError(t, err)
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS decimal_products2 (
+			id INT PRIMARY KEY NOT NULL,
+			price DECIMAL(12,4) NOT NULL
+		)
+	`)
+	target.MustExec("INSERT INTO decimal_products2 VALUES (1, 10.5), (2, 3), (3, 0.1)")
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"products": {
+				PrimaryKeys:    []string{"id"},
+				Columns:        []string{"id", "price"},
+				Source:         sourceConfig,
+				Targets:        []TableConfig{targetConfig},
+				DecimalColumns: []string{"price"},
+			},
+		},
+	}
+
+	results, err := config.ExecJob("products")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Zero(t, result.DriftRows())
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+}
+
+func TestExecJob_mysql_typed_scan(t *testing.T) {
+	dbName := os.Getenv("MYSQL_DB_NAME")
+	dbPortStr := os.Getenv("MYSQL_DB_PORT")
+	dbPort, _ := strconv.Atoi(dbPortStr)
+
+	sourceConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "typed_scan_users",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	// Without parseTime, mysql returns the integers, floats, and datetimes as text. When a query
+	// has placeholders, it uses the binary protocol instead, which returns a FLOAT as a float32 and
+	// a BIGINT UNSIGNED as a uint64
+	source := table{config: sourceConfig}
+	err := source.connect()
+	require.NoError(t, err)
+	source.MustExec("DROP TABLE IF EXISTS typed_scan_users")
+	source.MustExec(`
+		CREATE TABLE typed_scan_users (
+			id INT PRIMARY KEY NOT NULL,
+			age INT NOT NULL,
+			score FLOAT NOT NULL,
+			views BIGINT UNSIGNED NOT NULL,
+			created_at DATETIME NOT NULL
+		)
+	`)
+	source.MustExec(`
+		INSERT INTO typed_scan_users VALUES
+			(1, 30, 0.1, 18446744073709, '2024-01-02 03:04:05'),
+			(2, 25, 1.5, 7, '2024-06-07 08:09:10')
+	`)
+
+	// sqlite returns the same values as int64s, float64s, and time.Times
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_mysql_typed_scan_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			age INTEGER NOT NULL,
+			score REAL NOT NULL,
+			views INTEGER NOT NULL,
+			created_at DATETIME NOT NULL
+		)
+	`)
+	target.MustExec(`
+		INSERT INTO users VALUES
+			(1, 30, 0.1, 18446744073709, '2024-01-02 03:04:05'),
+			(2, 25, 1.5, 7, '2024-06-07 08:09:10')
+	`)
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "age", "score", "views", "created_at"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		TypedScan:   true,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// The values are scanned into the same types, so the checksums match across the drivers
+	check := func() {
+		t.Helper()
+
+		results, err := config.ExecJob("users")
+		require.NoError(t, err)
+		require.Len(t, results.Results, 1)
+
+		result := results.Results[0]
+		require.NoError(t, result.Error)
+		assert.False(t, result.Synced)
+		assert.Zero(t, result.DriftRows())
+		assert.Equal(t, results.Checksum, result.TargetChecksum)
+	}
+
+	check()
+
+	// A primary key range reads the source with placeholders, so with the binary protocol
+	job.PrimaryKeyMin = int64(1)
+	job.PrimaryKeyMax = int64(2)
+	config.Jobs["users"] = job
+
+	check()
+}
+
+func TestExecJob_mysql_big_unsigned(t *testing.T) {
+	dbName := os.Getenv("MYSQL_DB_NAME")
+	dbPortStr := os.Getenv("MYSQL_DB_PORT")
+	dbPort, _ := strconv.Atoi(dbPortStr)
+
+	createTable := func(tableName string) string {
+		return fmt.Sprintf(`
+			CREATE TABLE %s (
+				id BIGINT UNSIGNED PRIMARY KEY NOT NULL,
+				views BIGINT UNSIGNED NOT NULL
+			)
+		`, tableName)
+	}
+
+	sourceConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "big_unsigned_counters",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	// The values don't fit in an int64, so a typed scan keeps them as uint64s
+	source := table{config: sourceConfig}
+	err := source.connect()
+	require.NoError(t, err)
+	source.MustExec("DROP TABLE IF EXISTS big_unsigned_counters")
+	source.MustExec(createTable("big_unsigned_counters"))
+	source.MustExec(
+		"INSERT INTO big_unsigned_counters VALUES (1, ?), (?, 2)",
+		uint64(math.MaxUint64), uint64(math.MaxUint64),
+	)
+
+	targetConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "big_unsigned_counters2",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	target := table{config: targetConfig}
+	err = target.connect()
+	require.NoError(t, err)
+	target.MustExec("DROP TABLE IF EXISTS big_unsigned_counters2")
+	target.MustExec(createTable("big_unsigned_counters2"))
+	target.MustExec("INSERT INTO big_unsigned_counters2 VALUES (1, 0)")
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "views"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		TypedScan:   true,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"counters": job}}
+
+	results, err := config.ExecJob("counters")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 1, result.NumInserts)
+	assert.Equal(t, 1, result.NumUpdates)
+
+	var views []uint64
+	require.NoError(t, target.Select(&views, "SELECT views FROM big_unsigned_counters2 ORDER BY id"))
+	assert.Equal(t, []uint64{math.MaxUint64, 2}, views)
+
+	// Once synced, the rows match, including with placeholders (i.e. the binary protocol)
+	for _, keyRange := range []bool{false, true} {
+		if keyRange {
+			job.PrimaryKeyMin = int64(0)
+			job.PrimaryKeyMax = uint64(math.MaxUint64)
+			config.Jobs["counters"] = job
+		}
+
+		results, err = config.ExecJob("counters")
+		require.NoError(t, err)
+		require.Len(t, results.Results, 1)
+
+		result = results.Results[0]
+		require.NoError(t, result.Error)
+		assert.False(t, result.Synced)
+		assert.Zero(t, result.DriftRows())
+		assert.Equal(t, results.Checksum, result.TargetChecksum)
+	}
+}
+
+func TestExecJob_mysql_replace_mode(t *testing.T) {
+	dbName := os.Getenv("MYSQL_DB_NAME")
+	dbPortStr := os.Getenv("MYSQL_DB_PORT")
+	dbPort, _ := strconv.Atoi(dbPortStr)
+
+	sourceConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "replace_countries",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	source := table{config: sourceConfig}
+	err := source.connect()
+	require.NoError(t, err)
+	source.MustExec(`
+		CREATE TABLE IF NOT EXISTS replace_countries (
+			code VARCHAR(2) PRIMARY KEY NOT NULL,
+			name VARCHAR(255) NOT NULL
+		)
+	`)
+	source.MustExec("DELETE FROM replace_countries")
+	source.MustExec(
+		"INSERT INTO replace_countries VALUES ('CA', 'Canada'), ('MX', 'Mexico'), ('US', 'USA')",
+	)
+
+	targetConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "replace_countries2",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	target := table{config: targetConfig}
+	err = target.connect()
+	require.NoError(t, err)
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS replace_countries2 (
+			code VARCHAR(2) PRIMARY KEY NOT NULL,
+			name VARCHAR(255) NOT NULL
+		)
+	`)
+	target.MustExec("DELETE FROM replace_countries2")
+	target.MustExec("INSERT INTO replace_countries2 VALUES ('CA', 'Canada'), ('FR', 'France')")
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"countries": {
+				PrimaryKeys: []string{"code"},
+				Columns:     []string{"code", "name"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+				ReplaceMode: true,
+				BatchSize:   2,
+			},
+		},
+	}
+
+	results, err := config.ExecJob("countries")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 3, result.NumInserts)
+	assert.Equal(t, 2, result.NumDeletes)
+
+	var sourceNames, targetNames []string
+	err = source.Select(&sourceNames, "SELECT name FROM replace_countries ORDER BY code")
+	require.NoError(t, err)
+	err = target.Select(&targetNames, "SELECT name FROM replace_countries2 ORDER BY code")
+	require.NoError(t, err)
+	assert.Equal(t, sourceNames, targetNames)
+}
+
+func TestExecJob_mysql_auto_update_columns(t *testing.T) {
+	dbName := os.Getenv("MYSQL_DB_NAME")
+	dbPortStr := os.Getenv("MYSQL_DB_PORT")
+	dbPort, _ := strconv.Atoi(dbPortStr)
+
+	createTable := func(tableName string) string {
+		return fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				id INT PRIMARY KEY NOT NULL,
+				name VARCHAR(255) NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+					DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+			)
+		`, tableName)
+	}
+
+	sourceConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "auto_update_users",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	source := table{config: sourceConfig}
+	err := source.connect()
+	require.NoError(t, err)
+	source.MustExec(createTable("auto_update_users"))
+	source.MustExec("DELETE FROM auto_update_users")
+	source.MustExec(`
+		INSERT INTO auto_update_users (id, name, updated_at)
+		VALUES (1, 'Alice', '2024-01-01 00:00:00'), (2, 'Bob', '2024-01-01 00:00:00')
+	`)
+
+	targetConfig := TableConfig{
+		Driver: "mysql",
+		Table:  "auto_update_users2",
+		User:   "root",
+		DB:     dbName,
+		Port:   dbPort,
+	}
+
+	target := table{config: targetConfig}
+	err = target.connect()
+	require.NoError(t, err)
+	target.MustExec(createTable("auto_update_users2"))
+	target.MustExec("DELETE FROM auto_update_users2")
+	target.MustExec(`
+		INSERT INTO auto_update_users2 (id, name, updated_at)
+		VALUES (1, 'Alice', '2024-06-01 00:00:00'), (2, 'Robert', '2024-06-01 00:00:00')
+	`)
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys:       []string{"id"},
+				Columns:           []string{"id", "name", "updated_at"},
+				Source:            sourceConfig,
+				Targets:           []TableConfig{targetConfig},
+				AutoUpdateColumns: []string{"updated_at"},
+			},
+		},
+	}
+
+	// Only the row whose name differs is updated, which bumps its updated_at
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.Equal(t, 1, results.Results[0].NumUpdates)
+
+	var names []string
+	err = target.Select(&names, "SELECT name FROM auto_update_users2 ORDER BY id")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alice", "Bob"}, names)
+
+	// The target's updated_at values differ from the source's, but it no longer drifts
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Zero(t, result.DriftRows())
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+}
+
+func TestExecJob_mysql_location(t *testing.T) {
+	dbName := os.Getenv("MYSQL_DB_NAME")
+	dbPortStr := os.Getenv("MYSQL_DB_PORT")
+	dbPort, _ := strconv.Atoi(dbPortStr)
+
+	// tableWithTimeZone simulates a server whose default time zone is the given offset
+	tableWithTimeZone := func(name, timeZone string) TableConfig {
+		mysqlConfig := mysql.NewConfig()
+		mysqlConfig.User = "root"
+		mysqlConfig.Addr = fmt.Sprintf("localhost:%d", dbPort)
+		mysqlConfig.DBName = dbName
+		mysqlConfig.Net = "tcp"
+		mysqlConfig.Params = map[string]string{"time_zone": fmt.Sprintf("'%s'", timeZone)}
+
+		return TableConfig{Driver: "mysql", Table: name, DSN: mysqlConfig.FormatDSN()}
+	}
+
+	createTable := func(name string) string {
+		return fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				id INT PRIMARY KEY NOT NULL,
+				happened_at TIMESTAMP NOT NULL
+			)
+		`, name)
+	}
+
+	sourceConfig := tableWithTimeZone("location_events", "+00:00")
+
+	source := table{config: sourceConfig}
+	err := source.connect()
+	require.NoError(t, err)
+	source.MustExec(createTable(sourceConfig.Table))
+	source.MustExec("INSERT INTO location_events VALUES (1, '2024-01-01 10:00:00')")
+
+	targetConfig := tableWithTimeZone("location_events2", "+09:00")
+
+	target := table{config: targetConfig}
+	err = target.connect()
+	require.NoError(t, err)
+	target.MustExec(createTable(targetConfig.Table))
+
+	// Both connections use the same session time zone, regardless of their servers' defaults
+	sourceConfig.Location = "UTC"
+	targetConfig.Location = "UTC"
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"events": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "happened_at"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+			},
+		},
+	}
+
+	results, err := config.ExecJob("events")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+
+	// The timestamp is the same instant in the target, rather than shifted by 9 hours
+	var sourceUnix, targetUnix []int64
+	err = source.Select(&sourceUnix, "SELECT UNIX_TIMESTAMP(happened_at) FROM location_events")
+	require.NoError(t, err)
+	err = target.Select(&targetUnix, "SELECT UNIX_TIMESTAMP(happened_at) FROM location_events2")
+	require.NoError(t, err)
+	assert.Equal(t, sourceUnix, targetUnix)
+
+	// And the target is in sync
+	results, err = config.ExecJob("events")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.False(t, results.Results[0].Synced)
+}
+
+func TestExecJob_with_columns(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			email TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_with_columns_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO users (id, name, email)
+		VALUES (1, 'Alice', 'alice@new.com'), (2, 'Bob', 'bob@new.com')
+	`)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_with_columns_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+	target.MustExec(`
+		INSERT INTO users (id, name, email)
+		VALUES (1, 'Alicia', 'alice@old.com'), (2, 'Bob', 'bob@old.com')
+	`)
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name", "email"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+	}
+
+	// Exclude email from the sync
+	job, err := job.WithColumns([]string{"id", "name"})
+	require.NoError(t, err)
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+	assert.Equal(t, 1, results.Results[0].NumUpdates)
+
+	// Only the names were synced, and the emails were left alone
+	var rows []struct {
+		Name  string
+		Email string
+	}
+	require.NoError(t, target.Select(&rows, "SELECT name, email FROM users ORDER BY id"))
+	require.Len(t, rows, 2)
+	assert.Equal(t, "Alice", rows[0].Name)
+	assert.Equal(t, "alice@old.com", rows[0].Email)
+	assert.Equal(t, "bob@old.com", rows[1].Email)
+}
+
+func TestExecJob_shards(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_shards_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO users (id, name)
+		VALUES (1, 'Alice'), (2, 'Bob'), (3, 'Charlie'), (4, 'David'), (5, 'Eve')
+	`)
+
+	var shards []table
+	var shardConfigs []TableConfig
+	for i := range 2 {
+		shardConfig := TableConfig{
+			Label:  fmt.Sprintf("shard %d", i),
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    fmt.Sprintf("file:exec_job_shards_target%d.db?mode=memory&cache=shared", i),
+		}
+
+		shard := table{config: shardConfig}
+		shard.connect()
+		shard.MustExec(createTable)
+
+		shards = append(shards, shard)
+		shardConfigs = append(shardConfigs, shardConfig)
+	}
+
+	// Shard 0 has a row that belongs to shard 1, and shard 1 has a stale row
+	shards[0].MustExec(`INSERT INTO users (id, name) VALUES (2, 'Bob'), (3, 'Charlie')`)
+	shards[1].MustExec(`INSERT INTO users (id, name) VALUES (1, 'Alicia')`)
+
+	job := JobConfig{
+		PrimaryKeys:  []string{"id"},
+		Columns:      []string{"id", "name"},
+		ShardColumn:  "id",
+		AttachSQLite: true, // Ignored, since it would copy every row to each shard
+		Source:       sourceConfig,
+		Targets:      shardConfigs,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 2)
+
+	shard0, shard1 := results.Results[0], results.Results[1]
+	require.NoError(t, shard0.Error)
+	require.NoError(t, shard1.Error)
+	assert.Equal(t, 1, shard0.NumInserts)
+	assert.Equal(t, 1, shard0.NumDeletes)
+	assert.Equal(t, 2, shard1.NumInserts)
+	assert.Equal(t, 1, shard1.NumUpdates)
+
+	// Each shard only has the rows whose id % 2 is its index
+	var ids []int
+	require.NoError(t, shards[0].Select(&ids, "SELECT id FROM users ORDER BY id"))
+	assert.Equal(t, []int{2, 4}, ids)
+
+	ids = nil
+	require.NoError(t, shards[1].Select(&ids, "SELECT id FROM users ORDER BY id"))
+	assert.Equal(t, []int{1, 3, 5}, ids)
+
+	// The shards are now in sync with their parts of the source
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	for _, result := range results.Results {
+		require.NoError(t, result.Error)
+		assert.False(t, result.Synced)
+		assert.Zero(t, result.DriftRows())
+	}
+}
+
+func TestExecJob_data_columns(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			email TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_data_columns_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO users (id, name, email)
+		VALUES (1, 'Alice', 'alice@new.com'), (2, 'Bob', 'bob@new.com'), (3, 'Charlie', 'c@new.com')
+	`)
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_data_columns_target.db?mode=memory&cache=shared",
+	}
+
+	// id=1's name and email differ, and id=2 only differs in its email
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(createTable)
+	target.MustExec(`
+		INSERT INTO users (id, name, email)
+		VALUES (1, 'Alicia', 'alice@old.com'), (2, 'Bob', 'bob@old.com')
+	`)
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name", "email"},
+		DataColumns: []string{"name"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+		Verbose:     true,
+	}
+
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Synced)
+	assert.Equal(t, 1, result.NumInserts)
+	assert.Equal(t, 1, result.NumUpdates)
+	expected := []RowUpdate{
+		{
+			KeyColumns: []string{"id"},
+			Key:        []any{int64(1)},
+			Columns:    []string{"name"},
+			Source:     []any{"Alice"},
+			Target:     []any{"Alicia"},
+		},
+	}
+	assert.Equal(t, expected, result.Updates)
+
+	// Only the names were updated, but the inserted row has every column
+	var rows []struct {
+		Name  string
+		Email string
+	}
+	require.NoError(t, target.Select(&rows, "SELECT name, email FROM users ORDER BY id"))
+	require.Len(t, rows, 3)
+	assert.Equal(t, "Alice", rows[0].Name)
+	assert.Equal(t, "alice@old.com", rows[0].Email)
+	assert.Equal(t, "bob@old.com", rows[1].Email)
+	assert.Equal(t, "c@new.com", rows[2].Email)
+
+	// The target no longer drifts, even though its emails differ from the source's
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result = results.Results[0]
+	require.NoError(t, result.Error)
+	assert.False(t, result.Synced)
+	assert.Equal(t, results.Checksum, result.TargetChecksum)
+}
+
+func TestExecAllJobs_max_concurrency(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	newTable := func(name string) TableConfig {
+		config := TableConfig{
+			Label:  name,
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    fmt.Sprintf("file:exec_all_jobs_concurrency_%s.db?mode=memory&cache=shared", name),
+		}
+
+		table := table{config: config}
+		table.connect()
+		table.MustExec(createTable)
+		return config
+	}
+
+	source := newTable("source")
+	sourceTable := table{config: source}
+	sourceTable.connect()
+	sourceTable.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	var mu sync.Mutex
+	var inProgress, maxInProgress int
+	inProgressByJob := map[string]int{}
+	maxInProgressByJob := map[string]int{}
+	approvedAt := map[string][]time.Time{}
+
+	// Each target takes a while to approve, which is tracked to see how many are synced at once
+	approve := func(jobName string) func(SyncResult) bool {
+		return func(SyncResult) bool {
+			mu.Lock()
+			inProgress++
+			inProgressByJob[jobName]++
+			maxInProgress = max(maxInProgress, inProgress)
+			maxInProgressByJob[jobName] = max(maxInProgressByJob[jobName], inProgressByJob[jobName])
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			inProgress--
+			inProgressByJob[jobName]--
+			approvedAt[jobName] = append(approvedAt[jobName], time.Now())
+			mu.Unlock()
+
+			return true
+		}
+	}
+
+	newJob := func(jobName string, numTargets int) JobConfig {
+		var targets []TableConfig
+		for i := range numTargets {
+			targets = append(targets, newTable(fmt.Sprintf("%s_%d", jobName, i)))
+		}
+
+		return JobConfig{
+			PrimaryKeys: []string{"id"},
+			Columns:     []string{"id", "name"},
+			Source:      source,
+			Targets:     targets,
+			Approve:     approve(jobName),
+		}
+	}
+
+	config := Config{
+		MaxConcurrency: 4,
+		Jobs: map[string]JobConfig{
+			"large":  newJob("large", 8),
+			"small1": newJob("small1", 1),
+			"small2": newJob("small2", 1),
+			"small3": newJob("small3", 1),
+		},
+	}
+
+	results, errs := config.ExecAllJobs()
+	for jobName, result := range results {
+		require.NoError(t, errs[jobName])
+		for _, r := range result.Results {
+			require.NoError(t, r.Error)
+			assert.True(t, r.Synced)
+		}
+	}
+
+	// The limit is shared fairly, so the large job only gets its share of it
+	assert.LessOrEqual(t, maxInProgress, 4)
+	assert.Equal(t, 1, maxInProgressByJob["large"])
+	assert.Equal(t, 4, fairShare(4, 1))
+	assert.Equal(t, 2, fairShare(4, 2))
+	assert.Equal(t, 1, fairShare(4, 5))
+
+	// The small jobs didn't wait for the large job's targets
+	require.Len(t, approvedAt["large"], 8)
+	lastLarge := approvedAt["large"][7]
+	for _, jobName := range []string{"small1", "small2", "small3"} {
+		require.Len(t, approvedAt[jobName], 1)
+		assert.True(t, approvedAt[jobName][0].Before(lastLarge), jobName)
+	}
+}
+
+func TestExecJob_statement_modes(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT,
+			score REAL
+		)
+	`
+
+	// Values that must be escaped when they are inlined
+	tricky := []string{
+		"O'Brien",
+		`back\slash`,
+		"'); DROP TABLE users; --",
+		"question? mark",
+		"",
+	}
+
+	for _, mode := range []string{"", statementModePrepared, statementModeDirect} {
+		t.Run("mode="+mode, func(t *testing.T) {
+			sourceConfig := TableConfig{
+				Driver: "sqlite3",
+				Table:  "users",
+				DSN: fmt.Sprintf(
+					"file:exec_job_statement_mode_%s_source.db?mode=memory&cache=shared", mode,
+				),
+			}
+
+			source := table{config: sourceConfig}
+			source.connect()
+			source.MustExec(createTable)
+			for i, name := range tricky {
+				source.MustExec(
+					"INSERT INTO users (id, name, score) VALUES (?, ?, ?)", i+1, name, float64(i)+0.5,
+				)
+			}
+			source.MustExec("INSERT INTO users (id, name, score) VALUES (100, NULL, NULL)")
+
+			targetConfig := TableConfig{
+				Driver: "sqlite3",
+				Table:  "users",
+				DSN: fmt.Sprintf(
+					"file:exec_job_statement_mode_%s_target.db?mode=memory&cache=shared", mode,
+				),
+				StatementMode: mode,
+			}
+
+			// id=1 needs an update, id=99 needs a delete, and the rest need inserts
+			target := table{config: targetConfig}
+			target.connect()
+			target.MustExec(createTable)
+			target.MustExec(`
+				INSERT INTO users (id, name, score) VALUES (1, 'someone else', 0), (99, 'gone', 0)
+			`)
+
+			job := JobConfig{
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name", "score"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+			}
+
+			config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+			results, err := config.ExecJob("users")
+			require.NoError(t, err)
+			require.Len(t, results.Results, 1)
+
+			result := results.Results[0]
+			require.NoError(t, result.Error)
+			assert.Equal(t, len(tricky), result.NumInserts)
+			assert.Equal(t, 1, result.NumUpdates)
+			assert.Equal(t, 1, result.NumDeletes)
+
+			// Every value was written exactly, so the target is now in sync
+			var sourceRows, targetRows []struct {
+				ID    int
+				Name  *string
+				Score *float64
+			}
+			query := "SELECT id, name, score FROM users ORDER BY id"
+			require.NoError(t, source.Select(&sourceRows, query))
+			require.NoError(t, target.Select(&targetRows, query))
+			assert.Equal(t, sourceRows, targetRows)
+
+			results, err = config.ExecJob("users")
+			require.NoError(t, err)
+			require.Len(t, results.Results, 1)
+			require.NoError(t, results.Results[0].Error)
+			assert.False(t, results.Results[0].Synced)
+		})
+	}
+}
+
+func TestExecJob_row_counts(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_row_counts_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec(`INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob'), (3, 'Charlie')`)
+
+	// The first target is missing a row and has an extra one, and the second is empty
+	targetConfigs := []TableConfig{
+		{
+			Label:  "target1",
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    "file:exec_job_row_counts_target1.db?mode=memory&cache=shared",
+		},
+		{
+			Label:  "target2",
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    "file:exec_job_row_counts_target2.db?mode=memory&cache=shared",
+		},
+	}
+
+	targets := make([]table, len(targetConfigs))
+	for i, targetConfig := range targetConfigs {
+		targets[i] = table{config: targetConfig}
+		targets[i].connect()
+		targets[i].MustExec(createTable)
+	}
+	targets[0].MustExec(`INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob'), (4, 'Dan')`)
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name"},
+		Source:      sourceConfig,
+		Targets:     targetConfigs,
+	}
+
+	// A dry run reports how many rows the targets have now
+	dryRunJob := job
+	dryRunJob.DryRun = true
+	config := Config{Jobs: map[string]JobConfig{"users": dryRunJob}}
+
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	assert.Equal(t, 3, results.SourceRowCount)
+	require.Len(t, results.Results, 2)
+	assert.Equal(t, 3, results.Results[0].TargetRowCount)
+	assert.Equal(t, 0, results.Results[1].TargetRowCount)
+
+	// After a sync, every target has as many rows as the source
+	config = Config{Jobs: map[string]JobConfig{"users": job}}
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	assert.Equal(t, 3, results.SourceRowCount)
+	require.Len(t, results.Results, 2)
+	for i, result := range results.Results {
+		require.NoError(t, result.Error)
+		assert.True(t, result.Synced)
+		assert.Equal(t, results.SourceRowCount, result.TargetRowCount)
+
+		var count int
+		require.NoError(t, targets[i].Get(&count, "SELECT COUNT(*) FROM users"))
+		assert.Equal(t, count, result.TargetRowCount)
+	}
+
+	// A target that is already in sync has the same count, without being written
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 2)
+	for _, result := range results.Results {
+		require.NoError(t, result.Error)
+		assert.False(t, result.Synced)
+		assert.Equal(t, results.SourceRowCount, result.TargetRowCount)
+	}
+}
+
+func TestExecAllJobs_job_order(t *testing.T) {
+	source := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_all_jobs_job_order.db?mode=memory&cache=shared",
+	}
+
+	sourceTable := table{config: source}
+	sourceTable.connect()
+	sourceTable.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)")
+	sourceTable.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice')")
+
+	// Each job's noop target always needs every row, so it is approved every time it is executed
+	var executed []string
+	newJob := func(jobName string) JobConfig {
+		return JobConfig{
+			PrimaryKeys: []string{"id"},
+			Columns:     []string{"id", "name"},
+			Source:      source,
+			Targets:     []TableConfig{{Driver: noopDriver, Table: "users", DSN: noopModeEmpty}},
+			Approve: func(SyncResult) bool {
+				executed = append(executed, jobName)
+				return false
+			},
+		}
+	}
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"teams":    newJob("teams"),
+			"users":    newJob("users"),
+			"accounts": newJob("accounts"),
+			"zebras":   newJob("zebras"),
+			"events":   newJob("events"),
+		},
+		JobOrder: []string{"users", "teams"},
+	}
+	require.NoError(t, config.validate())
+
+	// The ordered jobs are executed first, and then the rest by name
+	expected := []string{"users", "teams", "accounts", "events", "zebras"}
+	assert.Equal(t, expected, config.JobNames())
+
+	// Map iteration is random, so execute the jobs a few times
+	for range 5 {
+		executed = nil
+
+		_, errs := config.ExecAllJobs()
+		for jobName, err := range errs {
+			require.NoError(t, err, jobName)
+		}
+		assert.Equal(t, expected, executed)
+	}
+
+	// Without an order, every job is executed by name
+	config.JobOrder = nil
+	assert.Equal(t, []string{"accounts", "events", "teams", "users", "zebras"}, config.JobNames())
+}
+
+func TestExecAllJobs_depends_on(t *testing.T) {
+	source := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_all_jobs_depends_on.db?mode=memory&cache=shared",
+	}
+
+	sourceTable := table{config: source}
+	sourceTable.connect()
+	sourceTable.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)")
+	sourceTable.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice')")
+
+	// Each job's noop target always needs every row, so it is approved every time it is executed
+	var mu sync.Mutex
+	var executed []string
+	newJob := func(jobName string, dependsOn ...string) JobConfig {
+		return JobConfig{
+			PrimaryKeys: []string{"id"},
+			Columns:     []string{"id", "name"},
+			Source:      source,
+			Targets:     []TableConfig{{Driver: noopDriver, Table: "users", DSN: noopModeEmpty}},
+			DependsOn:   dependsOn,
+			Approve: func(SyncResult) bool {
+				mu.Lock()
+				defer mu.Unlock()
+				executed = append(executed, jobName)
+				return false
+			},
+		}
+	}
+
+	// The teams job fails, since its source table doesn't exist
+	teams := newJob("teams")
+	teams.Source.Table = "teams"
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"accounts": newJob("accounts", "users"),
+			"events":   newJob("events", "accounts", "zebras"),
+			"teams":    teams,
+			"users":    newJob("users", "teams"),
+			"zebras":   newJob("zebras"),
+		},
+	}
+	require.NoError(t, config.validate())
+
+	// Each job comes after its dependencies, and otherwise the jobs are ordered by name
+	expected := []string{"teams", "users", "accounts", "zebras", "events"}
+	assert.Equal(t, expected, config.JobNames())
+
+	for _, maxConcurrency := range []int{0, 3} {
+		config.MaxConcurrency = maxConcurrency
+		executed = nil
+
+		_, errs := config.ExecAllJobs()
+		assert.ErrorContains(t, errs["teams"], "no such table: teams")
+		assert.NoError(t, errs["zebras"])
+
+		// The jobs that depend on teams (directly or not) are skipped
+		var dependencyErr *DependencyError
+		require.ErrorAs(t, errs["users"], &dependencyErr)
+		assert.Equal(t, "teams", dependencyErr.Dependency)
+		require.ErrorAs(t, errs["accounts"], &dependencyErr)
+		assert.Equal(t, "users", dependencyErr.Dependency)
+		require.ErrorAs(t, errs["events"], &dependencyErr)
+		assert.Equal(t, "accounts", dependencyErr.Dependency)
+
+		assert.Equal(t, []string{"zebras"}, executed)
+	}
+}
+
+func TestExecJob_required_columns(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_required_columns_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)")
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (3, 'Charlie')")
+
+	// The target has a required column that isn't synced
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:exec_job_required_columns_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	target.connect()
+	target.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY,
+			name TEXT,
+			email TEXT NOT NULL
+		)
+	`)
+	target.MustExec(`
+		INSERT INTO users (id, name, email)
+		VALUES (1, 'Alicia', 'alice@example.com'), (2, 'Bob', 'bob@example.com')
+	`)
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{targetConfig},
+	}
+	config := Config{Jobs: map[string]JobConfig{"users": job}}
+
+	// Inserting Charlie would fail, so nothing is written (not even the update and delete)
+	results, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+
+	result := results.Results[0]
+	var schemaErr *SchemaError
+	require.ErrorAs(t, result.Error, &schemaErr)
+	assert.ErrorContains(t, result.Error, "has required columns that aren't synced")
+	assert.ErrorContains(t, result.Error, "email")
+	assert.False(t, result.Synced)
+
+	var names []string
+	require.NoError(t, target.Select(&names, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Alicia", "Bob"}, names)
+
+	// A dry run reports it too, since the sync would fail
+	job.DryRun = true
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.ErrorContains(t, results.Results[0].Error, "has required columns that aren't synced")
+
+	// Without any inserts, the target can still be synced
+	source.MustExec("DELETE FROM users WHERE id = 3")
+	job.DryRun = false
+	config.Jobs["users"] = job
+
+	results, err = config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	require.NoError(t, results.Results[0].Error)
+	assert.True(t, results.Results[0].Synced)
+
+	names = nil
+	require.NoError(t, target.Select(&names, "SELECT name FROM users ORDER BY id"))
+	assert.Equal(t, []string{"Alice"}, names)
+}
+
+func TestExecJob_statuses(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)"
+
+	newTable := func(name string) TableConfig {
+		return TableConfig{
+			Label:  name,
+			Driver: "sqlite3",
+			Table:  "users",
+			DSN:    fmt.Sprintf("file:exec_job_statuses_%s.db?mode=memory&cache=shared", name),
+		}
+	}
+
+	sourceConfig := newTable("source")
+	source := table{config: sourceConfig}
+	source.connect()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	// One target is already in sync, one needs every row, and one doesn't have the table
+	inSyncConfig := newTable("current")
+	inSync := table{config: inSyncConfig}
+	inSync.connect()
+	inSync.MustExec(createTable)
+	inSync.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	staleConfig := newTable("stale")
+	stale := table{config: staleConfig}
+	stale.connect()
+	stale.MustExec(createTable)
+
+	missingConfig := newTable("missing")
+
+	job := JobConfig{
+		PrimaryKeys: []string{"id"},
+		Columns:     []string{"id", "name"},
+		Source:      sourceConfig,
+		Targets:     []TableConfig{inSyncConfig, staleConfig, missingConfig},
+	}
+
+	statusesOf := func(job JobConfig) []SyncStatus {
+		config := Config{Jobs: map[string]JobConfig{"users": job}}
+		results, err := config.ExecJob("users")
+		require.NoError(t, err)
+
+		var statuses []SyncStatus
+		for _, result := range results.Results {
+			statuses = append(statuses, result.Status)
+		}
+		return statuses
+	}
+
+	dryRun := job
+	dryRun.DryRun = true
+	assert.Equal(
+		t, []SyncStatus{SyncStatusInSync, SyncStatusDryRun, SyncStatusError}, statusesOf(dryRun),
+	)
+
+	rollbackDryRun := job
+	rollbackDryRun.RollbackDryRun = true
+	assert.Equal(
+		t,
+		[]SyncStatus{SyncStatusInSync, SyncStatusDryRun, SyncStatusError},
+		statusesOf(rollbackDryRun),
+	)
+
+	declined := job
+	declined.Approve = func(SyncResult) bool { return false }
+	assert.Equal(
+		t, []SyncStatus{SyncStatusInSync, SyncStatusSkipped, SyncStatusError}, statusesOf(declined),
+	)
+
+	assert.Equal(
+		t, []SyncStatus{SyncStatusInSync, SyncStatusSynced, SyncStatusError}, statusesOf(job),
+	)
+
+	// Once the source's state is saved, the targets aren't read again until the source changes
+	job.Targets = []TableConfig{inSyncConfig, staleConfig}
+	job.SkipUnchangedSource = true
+	job.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+	assert.Equal(t, []SyncStatus{SyncStatusInSync, SyncStatusInSync}, statusesOf(job))
+	assert.Equal(t, []SyncStatus{SyncStatusUnchanged, SyncStatusUnchanged}, statusesOf(job))
 }
@@ -0,0 +1,73 @@
+package sync
+
+import (
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// hasKeyRange returns whether the sync is restricted to a range of primary keys (see
+// JobConfig.PrimaryKeyMin)
+func (job JobConfig) hasKeyRange() bool {
+	return job.PrimaryKeyMin != nil || job.PrimaryKeyMax != nil
+}
+
+// checkKeyRange makes sure that the job can be restricted to its range of primary keys
+func (job JobConfig) checkKeyRange() error {
+	if !job.hasKeyRange() {
+		return nil
+	}
+
+	// The range is compared with a single column
+	if len(job.PrimaryKeys) != 1 {
+		return fmt.Errorf("job has a composite primary key, so it can't use a primary key range")
+	}
+
+	// Replacing the target's rows with only the source's rows in the range would delete the rest
+	if job.ReplaceMode {
+		return fmt.Errorf("job uses replaceMode, so it can't use a primary key range")
+	}
+
+	if job.PrimaryKeyMin != nil && job.PrimaryKeyMax != nil &&
+		compareValues(job.PrimaryKeyMin, job.PrimaryKeyMax) > 0 {
+		return fmt.Errorf(
+			"primary key range is empty (min %v is greater than max %v)",
+			job.PrimaryKeyMin, job.PrimaryKeyMax,
+		)
+	}
+
+	return nil
+}
+
+// keyRangeFilter returns the condition that only selects a table's rows whose primary key is in
+// the job's range, or nil if the job doesn't have one
+func (job JobConfig) keyRangeFilter(driver string) sq.Sqlizer {
+	if !job.hasKeyRange() {
+		return nil
+	}
+
+	column := quoteIdentifier(driver, job.PrimaryKeys[0])
+
+	var filter sq.And
+	if job.PrimaryKeyMin != nil {
+		filter = append(filter, sq.GtOrEq{column: job.PrimaryKeyMin})
+	}
+	if job.PrimaryKeyMax != nil {
+		filter = append(filter, sq.LtOrEq{column: job.PrimaryKeyMax})
+	}
+
+	return filter
+}
+
+// andWhere combines a table's predicate (which may be nil) with another condition
+func andWhere(where, condition sq.Sqlizer) sq.Sqlizer {
+	if where == nil {
+		return condition
+	}
+
+	if condition == nil {
+		return where
+	}
+
+	return sq.And{where, condition}
+}
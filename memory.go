@@ -0,0 +1,33 @@
+package sync
+
+import "unsafe"
+
+// Rough per-item overheads (in bytes) used when estimating how much memory the entries use
+const (
+	sliceHeaderSize = int64(unsafe.Sizeof([]any{}))
+	interfaceSize   = int64(unsafe.Sizeof(any(nil)))
+	mapEntrySize    = int64(unsafe.Sizeof(primaryKeyTuple{})) + sliceHeaderSize
+)
+
+// estimateRowSize estimates how many bytes a single row occupies once it is stored in both the
+// entry list and the entry map. This is an approximation meant for guarding against loading
+// unexpectedly large tables, not an exact accounting
+func estimateRowSize(row []any) int64 {
+	size := 2*sliceHeaderSize + mapEntrySize // The row in the list, and the key/row in the map
+
+	for _, val := range row {
+		size += interfaceSize
+
+		switch v := val.(type) {
+		case string:
+			size += int64(len(v))
+		case []byte:
+			size += sliceHeaderSize + int64(len(v))
+		case nil:
+		default:
+			size += 8 // Numbers, bools, times, etc. are roughly word-sized
+		}
+	}
+
+	return size
+}
@@ -0,0 +1,126 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// WriteDriftMetrics writes drift metrics for the given results (as returned by CheckAllJobs) to a
+// Prometheus textfile (e.g. for node_exporter's textfile collector), so drift can be alerted on
+// without running a server. The file is written atomically, so a scrape never sees a partial file
+func WriteDriftMetrics(
+	filename string,
+	results map[string]CheckJobResult,
+	errs map[string]error,
+	checkedAt time.Time,
+) error {
+	metrics := FormatDriftMetrics(results, errs, checkedAt)
+
+	// Write to a temp file and rename it, so an interruption can't leave a partially written file
+	tmpFilename := filename + ".tmp"
+	if err := os.WriteFile(tmpFilename, []byte(metrics), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFilename, filename)
+}
+
+// driftMetric is a gauge that has a sample per target
+type driftMetric struct {
+	name string
+	help string
+
+	// value returns the target's sample, or false if the target doesn't have one
+	value func(r SyncResult, checkedAt time.Time) (int64, bool)
+
+	// countsJobErrors is true if a job that couldn't be checked at all has a sample of 1 (labeled
+	// with just the job). Otherwise, such a job doesn't have a sample
+	countsJobErrors bool
+}
+
+var driftMetrics = []driftMetric{
+	{
+		name: "sql_table_sync_rows_out_of_sync",
+		help: "Number of rows that differ between the source and the target",
+		value: func(r SyncResult, _ time.Time) (int64, bool) {
+			return int64(r.DriftRows()), r.Error == nil
+		},
+	},
+	{
+		name: "sql_table_sync_in_sync",
+		help: "Whether the target is in sync with the source (1) or not (0)",
+		value: func(r SyncResult, _ time.Time) (int64, bool) {
+			if r.DriftRows() == 0 {
+				return 1, r.Error == nil
+			}
+			return 0, r.Error == nil
+		},
+	},
+	{
+		name: "sql_table_sync_last_check_timestamp_seconds",
+		help: "Unix time that the target was last checked for drift",
+		value: func(_ SyncResult, checkedAt time.Time) (int64, bool) {
+			return checkedAt.Unix(), true
+		},
+	},
+	{
+		name: "sql_table_sync_check_error",
+		help: "Whether the job or target couldn't be checked (1) or not (0)",
+		value: func(r SyncResult, _ time.Time) (int64, bool) {
+			if r.Error != nil {
+				return 1, true
+			}
+			return 0, true
+		},
+		countsJobErrors: true,
+	},
+}
+
+// FormatDriftMetrics formats the results in the Prometheus text exposition format, e.g. to serve
+// them from a /metrics endpoint. A target that couldn't be checked only has check_error and
+// timestamp samples, and a job that couldn't be checked at all only has a check_error sample
+func FormatDriftMetrics(
+	results map[string]CheckJobResult,
+	errs map[string]error,
+	checkedAt time.Time,
+) string {
+	jobNames := sortedKeys(results) // So the metrics are written deterministically
+
+	var b strings.Builder
+
+	for _, metric := range driftMetrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", metric.name)
+
+		for _, jobName := range jobNames {
+			if errs[jobName] != nil {
+				if metric.countsJobErrors {
+					fmt.Fprintf(&b, "%s{job=\"%s\"} 1\n", metric.name, escapeLabelValue(jobName))
+				}
+				continue
+			}
+
+			for _, r := range results[jobName].Results {
+				if value, ok := metric.value(r, checkedAt); ok {
+					fmt.Fprintf(&b, "%s%s %d\n", metric.name, targetLabels(jobName, r), value)
+				}
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// targetLabels formats the labels that identify a target's samples
+func targetLabels(jobName string, r SyncResult) string {
+	return fmt.Sprintf(
+		"{job=\"%s\",target=\"%s\"}", escapeLabelValue(jobName), escapeLabelValue(r.Target.Label),
+	)
+}
+
+// escapeLabelValue escapes a label value for the Prometheus text exposition format
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDriftMetrics(t *testing.T) {
+	results := map[string]CheckJobResult{
+		"users": {
+			Checksum: "source_checksum",
+			Results: []SyncResult{
+				{Target: TableConfig{Label: "replica1"}},
+				{Target: TableConfig{Label: "replica2"}, NumInserts: 2, NumDeletes: 1},
+				{Target: TableConfig{Label: `"quoted"`}, Error: fmt.Errorf("connection refused")},
+			},
+			Exceeded: true,
+		},
+		"pets": {},
+	}
+
+	errs := map[string]error{
+		"users": nil,
+		"pets":  fmt.Errorf("source unreachable"),
+	}
+
+	checkedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	filename := filepath.Join(t.TempDir(), "sql_table_sync.prom")
+	require.NoError(t, WriteDriftMetrics(filename, results, errs, checkedAt))
+
+	fileBytes, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	expected := `# HELP sql_table_sync_rows_out_of_sync Number of rows that differ between the source and the target
+# TYPE sql_table_sync_rows_out_of_sync gauge
+sql_table_sync_rows_out_of_sync{job="users",target="replica1"} 0
+sql_table_sync_rows_out_of_sync{job="users",target="replica2"} 3
+# HELP sql_table_sync_in_sync Whether the target is in sync with the source (1) or not (0)
+# TYPE sql_table_sync_in_sync gauge
+sql_table_sync_in_sync{job="users",target="replica1"} 1
+sql_table_sync_in_sync{job="users",target="replica2"} 0
+# HELP sql_table_sync_last_check_timestamp_seconds Unix time that the target was last checked for drift
+# TYPE sql_table_sync_last_check_timestamp_seconds gauge
+sql_table_sync_last_check_timestamp_seconds{job="users",target="replica1"} 1704164645
+sql_table_sync_last_check_timestamp_seconds{job="users",target="replica2"} 1704164645
+sql_table_sync_last_check_timestamp_seconds{job="users",target="\"quoted\""} 1704164645
+# HELP sql_table_sync_check_error Whether the job or target couldn't be checked (1) or not (0)
+# TYPE sql_table_sync_check_error gauge
+sql_table_sync_check_error{job="pets"} 1
+sql_table_sync_check_error{job="users",target="replica1"} 0
+sql_table_sync_check_error{job="users",target="replica2"} 0
+sql_table_sync_check_error{job="users",target="\"quoted\""} 1
+`
+	assert.Equal(t, expected, string(fileBytes))
+
+	// The temp file is renamed into place
+	_, err = os.Stat(filename + ".tmp")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,156 @@
+package sync
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// GenerateMigration writes the SQL statements that would bring each of a single job's targets in
+// sync with its source, without executing them. For each target, the statements are ordered like
+// they would be during a sync (DELETEs -> UPDATEs -> INSERTs), and values are rendered as literals
+// (escaped for the target's driver), so the output can be reviewed and applied later
+func (c Config) GenerateMigration(jobName string, w io.Writer) error {
+	// Find the job with the given name
+	job, ok := c.Jobs[jobName]
+	if !ok {
+		return fmt.Errorf("job '%s' not found in config", jobName)
+	}
+
+	sourceData, err := job.newSourceTable().readSource()
+	if err != nil {
+		return err
+	}
+
+	for i, targetConfig := range job.Targets {
+		target := job.newTable(targetConfig)
+		if target.isNoop() {
+			continue // There is no SQL to generate for a noop target
+		}
+
+		label := targetConfig.Label
+		if label == "" {
+			label = fmt.Sprintf("target[%d]", i)
+		}
+
+		targetData, err := target.readSource()
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+
+		targetSource := sourceData
+		if target.encryption != nil {
+			targetSource, err = target.encryptSource(sourceData)
+			if err != nil {
+				return fmt.Errorf("%s: %w", label, err)
+			}
+		}
+
+		diff := target.diff(targetSource, targetData, diffOptions{})
+
+		var statements []sq.Sqlizer
+		for _, delete := range diff.deletes {
+			statements = append(statements, delete)
+		}
+		for _, update := range diff.updates {
+			statements = append(statements, update)
+		}
+		for _, insert := range diff.inserts {
+			statements = append(statements, insert)
+		}
+
+		if i != 0 {
+			fmt.Fprintln(w) // Add a newline between targets
+		}
+
+		fmt.Fprintf(
+			w,
+			"-- %s (%s): %d deletes, %d updates, %d inserts\n",
+			label,
+			targetConfig.Table,
+			len(diff.deletes),
+			len(diff.updates),
+			len(diff.inserts),
+		)
+
+		for _, statement := range statements {
+			rendered, err := renderStatement(targetConfig.Driver, statement)
+			if err != nil {
+				return fmt.Errorf("%s: %w", label, err)
+			}
+
+			if _, err := fmt.Fprintln(w, rendered+";"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// renderStatement renders the statement with its values embedded as literals, instead of as
+// placeholders
+func renderStatement(driver string, statement sq.Sqlizer) (string, error) {
+	sql, args, err := statement.ToSql()
+	if err != nil {
+		return "", err
+	}
+
+	// Replace each placeholder with its value, in order
+	parts := strings.Split(sql, "?")
+	if len(parts)-1 != len(args) {
+		return "", fmt.Errorf("statement has %d placeholders but %d values", len(parts)-1, len(args))
+	}
+
+	var rendered strings.Builder
+	for i, part := range parts {
+		rendered.WriteString(part)
+
+		if i < len(args) {
+			literal, err := quoteValue(driver, args[i])
+			if err != nil {
+				return "", err
+			}
+			rendered.WriteString(literal)
+		}
+	}
+
+	return rendered.String(), nil
+}
+
+// quoteValue renders a value as a SQL literal for the given driver
+func quoteValue(driver string, val any) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return "NULL", nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return quoteString(v.Format("2006-01-02 15:04:05.999999999")), nil
+	case []byte:
+		return quoteValue(driver, string(v))
+	case string:
+		// mysql treats backslashes in string literals as escapes (by default)
+		if driver == "mysql" {
+			v = strings.ReplaceAll(v, `\`, `\\`)
+		}
+		return quoteString(v), nil
+	}
+
+	return "", fmt.Errorf("cannot render value %v (%T) as SQL", val, val)
+}
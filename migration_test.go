@@ -0,0 +1,115 @@
+package sync
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateMigration(t *testing.T) {
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			age INT
+		)
+	`
+
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:generate_migration_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	require.NoError(t, source.connect())
+	source.MustExec(createTable)
+	source.MustExec(`
+		INSERT INTO users (id, name, age)
+		VALUES (1, 'Alice', 30), (2, 'Bob O''Brien', NULL), (3, 'Charlie', 35)
+	`)
+
+	targetConfig := TableConfig{
+		Label:  "replica",
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:generate_migration_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	require.NoError(t, target.connect())
+	target.MustExec(createTable)
+	target.MustExec("INSERT INTO users (id, name, age) VALUES (1, 'Alice', 31), (4, 'Dan', 40)")
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name", "age"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{targetConfig},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, config.GenerateMigration("users", &buf))
+
+	expected := "-- replica (users): 1 deletes, 1 updates, 2 inserts\n" +
+		"DELETE FROM users WHERE `id` = 4;\n" +
+		"UPDATE users SET `name` = 'Alice', `age` = 30 WHERE `id` = 1;\n" +
+		"INSERT INTO users (`id`,`name`,`age`) VALUES (2,'Bob O''Brien',NULL);\n" +
+		"INSERT INTO users (`id`,`name`,`age`) VALUES (3,'Charlie',35);\n"
+	assert.Equal(t, expected, buf.String())
+
+	// Generating the migration doesn't change the target
+	var count int
+	require.NoError(t, target.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 2, count)
+
+	// Applying the migration should bring the target in sync
+	target.MustExec(buf.String())
+
+	result, err := config.CheckJob("users")
+	require.NoError(t, err)
+	require.Len(t, result.Results, 1)
+	assert.Zero(t, result.Results[0].DriftRows())
+	assert.Equal(t, result.Checksum, result.Results[0].TargetChecksum)
+
+	// Once in sync, there is nothing to apply
+	buf.Reset()
+	require.NoError(t, config.GenerateMigration("users", &buf))
+	assert.Equal(t, "-- replica (users): 0 deletes, 0 updates, 0 inserts\n", buf.String())
+
+	err = config.GenerateMigration("pets", &buf)
+	assert.ErrorContains(t, err, "job 'pets' not found in config")
+}
+
+func TestQuoteValue(t *testing.T) {
+	tests := []struct {
+		driver   string
+		value    any
+		expected string
+	}{
+		{"sqlite3", nil, "NULL"},
+		{"sqlite3", int64(-42), "-42"},
+		{"sqlite3", 1.5, "1.5"},
+		{"sqlite3", true, "1"},
+		{"sqlite3", "it's", "'it''s'"},
+		{"sqlite3", []byte("a\\b"), `'a\b'`},
+		{"mysql", "a\\'b", `'a\\''b'`},
+		{"mysql", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "'2024-01-02 03:04:05'"},
+	}
+
+	for _, tt := range tests {
+		literal, err := quoteValue(tt.driver, tt.value)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, literal)
+	}
+
+	_, err := quoteValue("sqlite3", struct{}{})
+	assert.ErrorContains(t, err, "cannot render value")
+}
@@ -0,0 +1,158 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// NotifyConfig configures a webhook that is sent a summary of each run
+type NotifyConfig struct {
+	// URL is the webhook URL that the summary is POSTed to (as JSON)
+	URL string `yaml:"url"`
+
+	// AuthHeader is an optional value for the request's Authorization header
+	AuthHeader string `yaml:"authHeader"`
+}
+
+// notification is the JSON payload sent to the webhook (and written to the result file)
+type notification struct {
+	Jobs []jobNotification `json:"jobs"`
+}
+
+type jobNotification struct {
+	Name           string               `json:"name"`
+	Checksum       string               `json:"checksum"`
+	SourceRowCount int                  `json:"sourceRowCount"`
+	Error          string               `json:"error,omitempty"`
+	Targets        []targetNotification `json:"targets"`
+}
+
+type targetNotification struct {
+	Label           string `json:"label"`
+	Checksum        string `json:"checksum"`
+	Synced          bool   `json:"synced"`
+	Status          string `json:"status"`
+	Error           string `json:"error,omitempty"`
+	NumInserts      int    `json:"numInserts"`
+	NumUpdates      int    `json:"numUpdates"`
+	NumDeletes      int    `json:"numDeletes"`
+	RowCount        int    `json:"rowCount"`
+	FetchDuration   string `json:"fetchDuration"`
+	CompareDuration string `json:"compareDuration"`
+	WriteDuration   string `json:"writeDuration"`
+}
+
+// SendNotification POSTs a JSON summary of the given results (as returned by ExecAllJobs) to the
+// configured webhook. It is a no-op if no webhook is configured
+func (c Config) SendNotification(
+	results map[string]ExecJobResult,
+	errs map[string]error,
+) error {
+	if c.Notify == nil {
+		return nil
+	}
+
+	payload := buildNotification(results, errs)
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.Notify.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.Notify.AuthHeader != "" {
+		req.Header.Set("Authorization", c.Notify.AuthHeader)
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// WriteResultFile writes the same JSON summary of the given results (as returned by ExecAllJobs)
+// that is sent to the webhook to a file, so that a supervising process can read the results
+// without parsing the CLI's output. The file is written atomically, so a reader never sees a
+// partial file
+func WriteResultFile(
+	filename string,
+	results map[string]ExecJobResult,
+	errs map[string]error,
+) error {
+	body, err := json.MarshalIndent(buildNotification(results, errs), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename it, so an interruption can't leave a partially written file
+	tmpFilename := filename + ".tmp"
+	if err := os.WriteFile(tmpFilename, append(body, '\n'), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFilename, filename)
+}
+
+func buildNotification(results map[string]ExecJobResult, errs map[string]error) notification {
+	jobNames := sortedKeys(results) // So the payload is deterministic
+
+	payload := notification{Jobs: []jobNotification{}}
+
+	for _, jobName := range jobNames {
+		result := results[jobName]
+
+		job := jobNotification{
+			Name:           jobName,
+			Checksum:       result.Checksum,
+			SourceRowCount: result.SourceRowCount,
+			Targets:        []targetNotification{},
+		}
+
+		if err := errs[jobName]; err != nil {
+			job.Error = err.Error()
+		}
+
+		for _, r := range result.Results {
+			target := targetNotification{
+				Label:           r.Target.Label,
+				Checksum:        r.TargetChecksum,
+				Synced:          r.Synced,
+				Status:          string(r.Status),
+				NumInserts:      r.NumInserts,
+				NumUpdates:      r.NumUpdates,
+				NumDeletes:      r.NumDeletes,
+				RowCount:        r.TargetRowCount,
+				FetchDuration:   r.FetchDuration.String(),
+				CompareDuration: r.CompareDuration.String(),
+				WriteDuration:   r.WriteDuration.String(),
+			}
+
+			if r.Error != nil {
+				target.Error = r.Error.Error()
+			}
+
+			job.Targets = append(job.Targets, target)
+		}
+
+		payload.Jobs = append(payload.Jobs, job)
+	}
+
+	return payload
+}
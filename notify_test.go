@@ -0,0 +1,123 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendNotification(t *testing.T) {
+	var received notification
+	var authHeader string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	config := Config{
+		Notify: &NotifyConfig{URL: server.URL, AuthHeader: "Bearer secret"},
+	}
+
+	results := map[string]ExecJobResult{
+		"users": {
+			Checksum:       "abc",
+			SourceRowCount: 10,
+			Results: []SyncResult{
+				{
+					Target:          TableConfig{Label: "target1"},
+					TargetChecksum:  "def",
+					Synced:          true,
+					Status:          SyncStatusSynced,
+					NumInserts:      1,
+					NumUpdates:      2,
+					NumDeletes:      3,
+					TargetRowCount:  10,
+					FetchDuration:   time.Second,
+					CompareDuration: time.Millisecond,
+					WriteDuration:   2 * time.Second,
+				},
+				{
+					Target: TableConfig{Label: "target2"},
+					Error:  fmt.Errorf("connection refused"),
+					Status: SyncStatusError,
+				},
+			},
+		},
+		"pets": {},
+	}
+
+	errs := map[string]error{
+		"users": nil,
+		"pets":  fmt.Errorf("job failed"),
+	}
+
+	err := config.SendNotification(results, errs)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Bearer secret", authHeader)
+
+	expected := notification{
+		Jobs: []jobNotification{
+			{
+				Name:    "pets",
+				Error:   "job failed",
+				Targets: []targetNotification{},
+			},
+			{
+				Name:           "users",
+				Checksum:       "abc",
+				SourceRowCount: 10,
+				Targets: []targetNotification{
+					{
+						Label:           "target1",
+						Checksum:        "def",
+						Synced:          true,
+						Status:          "synced",
+						NumInserts:      1,
+						NumUpdates:      2,
+						NumDeletes:      3,
+						RowCount:        10,
+						FetchDuration:   "1s",
+						CompareDuration: "1ms",
+						WriteDuration:   "2s",
+					},
+					{
+						Label:           "target2",
+						Status:          "error",
+						Error:           "connection refused",
+						FetchDuration:   "0s",
+						CompareDuration: "0s",
+						WriteDuration:   "0s",
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, received)
+}
+
+func TestSendNotification_failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	config := Config{Notify: &NotifyConfig{URL: server.URL}}
+
+	err := config.SendNotification(map[string]ExecJobResult{}, map[string]error{})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "webhook responded with status 500")
+
+	// No webhook configured is a no-op
+	err = Config{}.SendNotification(map[string]ExecJobResult{}, map[string]error{})
+	assert.NoError(t, err)
+}
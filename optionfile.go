@@ -0,0 +1,124 @@
+package sync
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// optionFileSection is the section of a MySQL option file that client credentials are read from
+const optionFileSection = "client"
+
+// withOptionFile returns the table's config with its credentials read from its MySQL option file.
+// The user and password always come from the file, but a host or port that the table specifies
+// directly takes precedence
+func (cfg TableConfig) withOptionFile() (TableConfig, error) {
+	options, err := readOptionFile(cfg.OptionFile)
+	if err != nil {
+		return TableConfig{}, fmt.Errorf("failed to read optionFile '%s': %w", cfg.OptionFile, err)
+	}
+
+	cfg.User = options["user"]
+	cfg.Password = options["password"]
+
+	if cfg.Host == "" {
+		cfg.Host = options["host"]
+	}
+
+	if cfg.Port == 0 && options["port"] != "" {
+		cfg.Port, err = strconv.Atoi(options["port"])
+		if err != nil {
+			return TableConfig{}, fmt.Errorf(
+				"optionFile '%s' has invalid port '%s'", cfg.OptionFile, options["port"],
+			)
+		}
+	}
+
+	return cfg, nil
+}
+
+// readOptionFile reads the options in the [client] section of a MySQL option file (e.g.
+// ~/.my.cnf). Option names are normalized like MySQL does (e.g. "Pass_Word" becomes "pass-word").
+// Options without a value and !include directives are ignored
+func readOptionFile(filename string) (map[string]string, error) {
+	filename, err := expandHome(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	options := map[string]string{}
+	var section string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || line[0] == '#' || line[0] == ';' || line[0] == '!' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+
+		if section != optionFileSection {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.ReplaceAll(name, "_", "-")
+
+		options[name] = optionValue(strings.TrimSpace(value))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// optionValue unquotes an option's value. An unquoted value ends at a "#" comment, so a value that
+// contains "#" (e.g. a password) has to be quoted
+func optionValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+
+	if comment := strings.IndexByte(value, '#'); comment >= 0 {
+		value = strings.TrimSpace(value[:comment])
+	}
+
+	return value
+}
+
+// expandHome expands a leading "~" in the path to the user's home directory
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, path[1:]), nil
+}
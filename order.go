@@ -0,0 +1,19 @@
+package sync
+
+import "slices"
+
+// orderKeys orders a map's keys wherever the order that it is iterated in matters (e.g. which
+// job's error is reported, or the order that jobs are executed in), since Go randomizes the order
+// of map iteration. It sorts them, but tests can replace it to inject a different order
+var orderKeys = slices.Sort[[]string]
+
+// sortedKeys returns the map's keys, ordered by orderKeys
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	orderKeys(keys)
+	return keys
+}
@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setOrderKeys replaces how map keys are ordered until the test is done
+func setOrderKeys(t *testing.T, order func(keys []string)) {
+	t.Helper()
+
+	original := orderKeys
+	t.Cleanup(func() { orderKeys = original })
+	orderKeys = order
+}
+
+func TestSortedKeys(t *testing.T) {
+	m := map[string]int{"users": 1, "teams": 2, "accounts": 3, "events": 4, "zebras": 5}
+
+	// Map iteration is random, so get the keys a few times
+	for range 10 {
+		assert.Equal(t, []string{"accounts", "events", "teams", "users", "zebras"}, sortedKeys(m))
+	}
+
+	assert.Empty(t, sortedKeys(map[string]int{}))
+	assert.Empty(t, sortedKeys[int](nil))
+
+	// An injected order is used instead
+	setOrderKeys(t, func(keys []string) {
+		slices.Sort(keys)
+		slices.Reverse(keys)
+	})
+	assert.Equal(t, []string{"zebras", "users", "teams", "events", "accounts"}, sortedKeys(m))
+}
+
+func TestConfig_deterministic_iteration(t *testing.T) {
+	newJob := func(columns ...string) JobConfig {
+		return JobConfig{
+			Columns:     columns,
+			PrimaryKeys: []string{"id"},
+			Source:      TableConfig{Table: "users", Driver: "sqlite3"},
+			Targets:     []TableConfig{{Table: "users2", Driver: "sqlite3"}},
+		}
+	}
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users":    newJob(),
+			"teams":    newJob(),
+			"accounts": newJob(),
+			"zebras":   newJob("id", "name"),
+		},
+	}
+
+	// Several jobs are invalid, but the first one in order is always reported
+	for range 10 {
+		assert.ErrorContains(t, config.validate(), "job 'accounts': does not specify any columns")
+	}
+
+	jobNames := []string{"zebras", "users", "teams", "accounts"}
+	assert.Equal(t, []string{"accounts", "teams", "users", "zebras"}, config.JobNames())
+
+	// With an injected order, the jobs are validated (and executed) in that order instead
+	setOrderKeys(t, func(keys []string) {
+		slices.SortFunc(keys, func(a, b string) int {
+			return slices.Index(jobNames, a) - slices.Index(jobNames, b)
+		})
+	})
+
+	err := config.validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "job 'users': does not specify any columns")
+	assert.Equal(t, jobNames, config.JobNames())
+
+	// Jobs in jobOrder still come first
+	config.JobOrder = []string{"teams"}
+	assert.Equal(t, []string{"teams", "zebras", "users", "accounts"}, config.JobNames())
+}
@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// defaultPartitionCount is the number of partitions of a job with a partitioned checksum, if it
+// doesn't set its own PartitionCount
+const defaultPartitionCount = 16
+
+// partitionCount returns the number of partitions (see JobConfig.PartitionedChecksum)
+func (job JobConfig) partitionCount() int {
+	if job.PartitionCount > 0 {
+		return job.PartitionCount
+	}
+
+	return defaultPartitionCount
+}
+
+// partitionSource splits the source's rows into the job's partitions, by their primary key modulo
+// the number of partitions. Each partition keeps the source's primary key order, and has its own
+// checksum
+func (job JobConfig) partitionSource(source tableData) ([]tableData, error) {
+	return job.bucketSource(source, job.PrimaryKeys[0], job.partitionCount(), "primary key")
+}
+
+// mergePartitions combines the given partitions' rows, in primary key order, with their checksum
+func (job JobConfig) mergePartitions(partitions []tableData, selected []int) (tableData, error) {
+	merged := tableData{entries: [][]any{}, entryMap: map[primaryKeyTuple][]any{}}
+	for _, i := range selected {
+		merged.entries = append(merged.entries, partitions[i].entries...)
+		for key, row := range partitions[i].entryMap {
+			merged.entryMap[key] = row
+		}
+	}
+
+	t := job.newTable(job.Source)
+	t.sortByKey(merged.entries)
+
+	checksum, err := t.checksum(merged.entries)
+	if err != nil {
+		return tableData{}, err
+	}
+	merged.checksum = checksum
+
+	return merged, nil
+}
+
+// partitionFilter returns the condition that only selects the table's rows in the given
+// partitions. Like shardOf, a negative primary key's partition is still in [0, numPartitions)
+func (t table) partitionFilter(numPartitions int, partitions []int) sq.Sqlizer {
+	column := quoteIdentifier(t.config.Driver, t.primaryKeys[0])
+	partition := fmt.Sprintf("((%s %% %d) + %d) %% %d", column, numPartitions, numPartitions,
+		numPartitions)
+
+	return sq.Eq{partition: partitions}
+}
@@ -3,7 +3,8 @@ package sync
 import (
 	"context"
 	"fmt"
-	"sync"
+	"slices"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -20,6 +21,7 @@ type PingResult struct {
 //   - has the correct credentials
 //   - exists
 //   - has the expected columns
+//   - (for targets) doesn't have other columns that inserts would need a value for
 func (c Config) PingJob(jobName string, timeout time.Duration) ([]PingResult, error) {
 	// Find the job with the given name
 	job, ok := c.Jobs[jobName]
@@ -37,31 +39,18 @@ func (c Config) PingJob(jobName string, timeout time.Duration) ([]PingResult, er
 
 	results = append(results, PingResult{
 		Config: job.Source,
-		Error:  pingWithTimeout(timeout, job.Source, job.Columns),
+		Error:  pingWithRetry(job.PingAttempts, timeout, job.Source, job.Columns),
 	})
 
 	// Ping the target tables (in parallel)
-	var wg sync.WaitGroup
-	resultChan := make(chan PingResult, len(job.Targets))
-
-	for j, target := range job.Targets {
-		wg.Add(1)
-		go func(j int, target TableConfig) {
-			defer wg.Done()
-
-			resultChan <- PingResult{
-				Config: target,
-				Error:  pingWithTimeout(timeout, target, job.Columns),
-			}
-		}(j, target)
+	targets := make([]pingTarget, len(job.Targets))
+	for i, target := range job.Targets {
+		targets[i] = writablePingTarget{target}
 	}
 
-	wg.Wait()         // Wait for all goroutines to finish
-	close(resultChan) // Close the channel to signal that all results have been sent
-
-	// Collect the results from the channel
-	for result := range resultChan {
-		results = append(results, result)
+	errs := pingTargets(timeout, targets, job.Columns, job.MaxConcurrency, job.PingAttempts)
+	for i, target := range job.Targets {
+		results = append(results, PingResult{Config: target, Error: errs[i]})
 	}
 
 	return results, nil
@@ -76,7 +65,7 @@ func (c Config) PingAllJobs(timeout time.Duration) (map[string][]PingResult, err
 	// Iterate over all jobs and "ping" the source and targets
 	results := make(map[string][]PingResult, len(c.Jobs))
 
-	for jobName := range c.Jobs {
+	for _, jobName := range sortedKeys(c.Jobs) {
 		jobResults, err := c.PingJob(jobName, timeout)
 		if err != nil {
 			// This can't actually happen because the only way for PingJob to error is if the job
@@ -90,6 +79,49 @@ func (c Config) PingAllJobs(timeout time.Duration) (map[string][]PingResult, err
 	return results, nil
 }
 
+// pingTargets pings the targets in parallel, with at most maxConcurrency pings at once (if it is
+// positive). The errors are in the same order as the targets
+func pingTargets(
+	timeout time.Duration,
+	targets []pingTarget,
+	columns []string,
+	maxConcurrency int,
+	attempts int,
+) []error {
+	errs := make([]error, len(targets))
+
+	forEachConcurrently(len(targets), maxConcurrency, func(i int) {
+		errs[i] = pingWithRetry(attempts, timeout, targets[i], columns)
+	})
+
+	return errs
+}
+
+// pingBackoff is how long to wait before retrying a failed ping. It doubles after each attempt
+var pingBackoff = 500 * time.Millisecond
+
+// pingWithRetry pings the table up to the given number of attempts (at least once), so that a
+// transient network blip isn't reported as a failure. The last attempt's error is returned
+func pingWithRetry(
+	attempts int,
+	timeout time.Duration,
+	config pingTarget,
+	columns []string,
+) error {
+	backoff := pingBackoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = pingWithTimeout(timeout, config, columns)
+		if err == nil || attempt >= attempts {
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
 // Ping the source and targets with a timeout
 func pingWithTimeout(timeout time.Duration, config pingTarget, columns []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
@@ -116,23 +148,84 @@ type pingTarget interface {
 
 // Ping the source and targets for a given TableConfig
 func (config TableConfig) ping(columns []string) error {
+	return config.pingTable(columns, false)
+}
+
+// writablePingTarget pings a target table. Since rows are inserted into it, it also must not have
+// any required columns (NOT NULL without a default) that aren't synced
+type writablePingTarget struct {
+	config TableConfig
+}
+
+func (p writablePingTarget) ping(columns []string) error {
+	return p.config.pingTable(columns, true)
+}
+
+// pingTable makes sure the table is reachable and has the given columns. If writable is true, it
+// also makes sure that an insert of just those columns wouldn't fail because of the other columns
+func (config TableConfig) pingTable(columns []string, writable bool) error {
 	t := table{config: config}
+	if t.isNoop() {
+		return nil // A noop table is always reachable
+	}
+
 	if err := t.connect(); err != nil {
 		return err
 	}
 	defer t.Close()
 
 	// Make sure we can query the table
-	query := sq.Select(columns...).From(config.Table).Limit(1)
+	query := sq.Select(t.quoteColumns(columns)...).From(config.Table).Limit(1)
 	sql, args, err := query.ToSql()
 	if err != nil {
 		return err
 	}
 
 	rows, err := t.Queryx(sql, args...)
+	if err != nil {
+		return &SchemaError{Target: config, Err: err}
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	if !writable {
+		return nil
+	}
+
+	return t.checkRequiredColumns(columns)
+}
+
+// checkRequiredColumns makes sure that the table has no required columns (see requiredColumns)
+// other than the given ones, since an insert of just those columns would fail
+func (t table) checkRequiredColumns(columns []string) error {
+	required, err := t.requiredColumns()
 	if err != nil {
 		return err
 	}
 
-	return rows.Close()
+	var unsynced []string
+	for _, col := range required {
+		synced := slices.ContainsFunc(columns, func(name string) bool {
+			return strings.EqualFold(name, col)
+		})
+
+		if !synced {
+			unsynced = append(unsynced, col)
+		}
+	}
+
+	if len(unsynced) > 0 {
+		return &SchemaError{
+			Target: t.config,
+			Err: fmt.Errorf(
+				"has required columns that aren't synced, so inserts would fail "+
+					"(give them a default, make them nullable, or add them to columns): %s",
+				strings.Join(unsynced, ", "),
+			),
+		}
+	}
+
+	return nil
 }
@@ -3,6 +3,7 @@ package sync
 import (
 	"fmt"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -245,6 +246,95 @@ func TestPingAllJobs_mysql(t *testing.T) {
 	}
 }
 
+func TestPingJob_required_columns(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:test_ping_required_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	require.NoError(t, source.connect())
+	defer source.Close()
+
+	// The source isn't written to, so it may have required columns that aren't synced
+	source.MustExec(`
+		CREATE TABLE users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			internal_notes TEXT NOT NULL
+		)
+	`)
+
+	// The first target's extra columns are either nullable or have a default
+	okConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:test_ping_required_ok.db?mode=memory&cache=shared",
+	}
+
+	okTarget := table{config: okConfig}
+	require.NoError(t, okTarget.connect())
+	defer okTarget.Close()
+	okTarget.MustExec(`
+		CREATE TABLE users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name TEXT NOT NULL,
+			synced_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			notes TEXT
+		)
+	`)
+
+	// The second target has extra columns that are NOT NULL without a default
+	badConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:test_ping_required_bad.db?mode=memory&cache=shared",
+	}
+
+	badTarget := table{config: badConfig}
+	require.NoError(t, badTarget.connect())
+	defer badTarget.Close()
+	badTarget.MustExec(`
+		CREATE TABLE users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			audit_user TEXT NOT NULL,
+			name TEXT NOT NULL,
+			audit_reason TEXT NOT NULL
+		)
+	`)
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{okConfig, badConfig},
+			},
+		},
+	}
+
+	results, err := config.PingJob("users", 30*time.Second)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.NoError(t, results[0].Error)
+	assert.NoError(t, results[1].Error)
+
+	var schemaErr *SchemaError
+	require.ErrorAs(t, results[2].Error, &schemaErr)
+	assert.Equal(t, badConfig, schemaErr.Target)
+	assert.ErrorContains(
+		t, results[2].Error, "has required columns that aren't synced, so inserts would fail",
+	)
+	assert.ErrorContains(t, results[2].Error, ": audit_user, audit_reason")
+
+	// Inserting into the target would indeed fail
+	_, err = badTarget.Exec("INSERT INTO users (id, name) VALUES (1, 'Alice')")
+	assert.ErrorContains(t, err, "NOT NULL constraint failed")
+}
+
 type sleepPingTarget struct {
 	duration time.Duration
 }
@@ -266,3 +356,87 @@ func TestPingWithTimeout(t *testing.T) {
 	err = pingWithTimeout(30*time.Second, target, nil)
 	require.NoError(t, err)
 }
+
+// concurrencyPingTarget records the most pings that were in progress at once
+type concurrencyPingTarget struct {
+	inProgress    *atomic.Int32
+	maxInProgress *atomic.Int32
+}
+
+func (m concurrencyPingTarget) ping(columns []string) error {
+	current := m.inProgress.Add(1)
+	defer m.inProgress.Add(-1)
+
+	for {
+		max := m.maxInProgress.Load()
+		if current <= max || m.maxInProgress.CompareAndSwap(max, current) {
+			break
+		}
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	return nil
+}
+
+func TestPingTargets(t *testing.T) {
+	pingAll := func(maxConcurrency int) int32 {
+		var inProgress, maxInProgress atomic.Int32
+
+		targets := make([]pingTarget, 8)
+		for i := range targets {
+			targets[i] = concurrencyPingTarget{&inProgress, &maxInProgress}
+		}
+
+		errs := pingTargets(30*time.Second, targets, nil, maxConcurrency, 0)
+		require.Len(t, errs, len(targets))
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+
+		return maxInProgress.Load()
+	}
+
+	// With a cap, no more than that many pings should be in progress at once
+	assert.LessOrEqual(t, pingAll(2), int32(2))
+	assert.Equal(t, int32(1), pingAll(1))
+
+	// Without a cap, the targets are all pinged at once
+	assert.Greater(t, pingAll(0), int32(2))
+}
+
+// flakyPingTarget fails until it has been pinged a number of times
+type flakyPingTarget struct {
+	failures int
+	pings    *int
+}
+
+func (m flakyPingTarget) ping(columns []string) error {
+	*m.pings++
+	if *m.pings <= m.failures {
+		return fmt.Errorf("connection refused")
+	}
+	return nil
+}
+
+func TestPingWithRetry(t *testing.T) {
+	defer func(backoff time.Duration) { pingBackoff = backoff }(pingBackoff)
+	pingBackoff = time.Millisecond
+
+	// A target that fails once should succeed on the second attempt
+	var pings int
+	err := pingWithRetry(3, 30*time.Second, flakyPingTarget{failures: 1, pings: &pings}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pings)
+
+	// Without retries, the first failure is reported
+	pings = 0
+	err = pingWithRetry(0, 30*time.Second, flakyPingTarget{failures: 1, pings: &pings}, nil)
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, 1, pings)
+
+	// If every attempt fails, the last error is reported
+	pings = 0
+	err = pingWithRetry(3, 30*time.Second, flakyPingTarget{failures: 5, pings: &pings}, nil)
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, 3, pings)
+}
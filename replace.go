@@ -0,0 +1,141 @@
+package sync
+
+import (
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// defaultReplaceMaxRows is the most source rows that a job in replace mode can sync, if the job
+// doesn't set its own ReplaceMaxRows
+const defaultReplaceMaxRows = 10000
+
+// syncReplace replaces all of the target's rows with the source's rows in a single transaction,
+// instead of diffing them (see JobConfig.ReplaceMode)
+func (t table) syncReplace(job JobConfig, source tableData) SyncResult {
+	result := SyncResult{Target: t.config}
+
+	maxRows := job.ReplaceMaxRows
+	if maxRows == 0 {
+		maxRows = defaultReplaceMaxRows
+	}
+
+	// Replacing a huge table would rewrite all of it on every sync
+	if len(source.entries) > maxRows {
+		result.Error = fmt.Errorf(
+			"source has %d rows, which is more than replaceMaxRows (%d)",
+			len(source.entries), maxRows,
+		)
+		return result
+	}
+
+	// The target's rows are read (they're few, see ReplaceMaxRows) so that a target that's already
+	// in sync isn't rewritten
+	fetchStart := time.Now()
+	_, fetchSpan := t.tracing.start("fetch")
+	target, err := t.readTarget()
+	result.FetchDuration = time.Since(fetchStart)
+	endSpan(fetchSpan, err)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	if target.spilled != nil {
+		defer target.spilled.close()
+	}
+
+	numRows := target.numRows()
+	result.TargetRowCount = numRows
+
+	if err := job.checkTargetToSourceRatio(len(source.entries), numRows); err != nil {
+		result.Error = err
+		return result
+	}
+
+	compareStart := time.Now()
+	target.checksum, err = t.checksumData(target)
+	result.TargetChecksum = target.checksum
+	result.CompareDuration = time.Since(compareStart)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	// If the checksums match, then the data is already in sync
+	if source.checksum == target.checksum {
+		return result
+	}
+
+	result.NumDeletes = numRows
+	result.NumInserts = len(source.entries)
+
+	// The inserts would fail if the target has required columns that aren't synced
+	if result.NumInserts > 0 {
+		if err := t.checkRequiredColumns(t.columns); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	// In dry-run mode, we report what would have changed but don't write anything
+	if job.DryRun {
+		result.Statements, result.Error = t.planReplace(source.entries)
+		return result
+	}
+
+	if job.Approve != nil && !job.Approve(result) {
+		result.Skipped = true
+		return result
+	}
+
+	writeStart := time.Now()
+	_, writeSpan := t.tracing.start("write")
+	err = t.replaceRows(source.entries)
+	result.WriteDuration = time.Since(writeStart)
+	endSpan(writeSpan, err)
+	if err != nil {
+		result.Error = &SyncError{Target: t.config, Err: err}
+		return result
+	}
+
+	result.Synced = true
+	result.TargetRowCount = len(source.entries)
+	return result
+}
+
+// planReplace returns the statements that replaceRows would execute against the table for the
+// given rows, in the same order
+func (t table) planReplace(rows [][]any) ([]Statement, error) {
+	statements := []sq.Sqlizer{sq.Delete(t.config.Table)}
+	for _, insert := range t.batchInserts(rows) {
+		statements = append(statements, insert)
+	}
+
+	return t.plan(statements)
+}
+
+// replaceRows deletes all of the table's rows and inserts the given rows, in a single transaction.
+// This uses DELETE rather than TRUNCATE, since mysql's TRUNCATE can't be rolled back
+func (t table) replaceRows(rows [][]any) error {
+	tx, err := t.writer().BeginTxx(t.context(), nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() // This is a no-op once the transaction is committed
+	t.tx = tx
+
+	if _, err := t.exec(sq.Delete(t.config.Table)); err != nil {
+		return err
+	}
+
+	for _, insert := range t.batchInserts(rows) {
+		if _, err := t.exec(insert); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,288 @@
+package sync
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SchemaResult contains the results of ensuring a single target table's schema
+type SchemaResult struct {
+	Target TableConfig
+	Error  error
+
+	// Missing is true if the table doesn't exist. MissingColumns are the job's columns that the
+	// table doesn't have (every column, if the table is missing)
+	Missing        bool
+	MissingColumns []string
+
+	// Applied is true if the missing table or columns were created
+	Applied bool
+}
+
+// EnsureSchema checks that each of a single job's targets has at least the job's columns. If apply
+// is true, a missing target table is created, and missing columns are added to it, with the same
+// types as the source's columns. This is additive only: columns are never dropped or changed, so
+// it is safe to run repeatedly
+func (c Config) EnsureSchema(jobName string, apply bool) ([]SchemaResult, error) {
+	// Find the job with the given name
+	job, ok := c.Jobs[jobName]
+	if !ok {
+		return nil, fmt.Errorf("job '%s' not found in config", jobName)
+	}
+
+	source := job.newSourceTable()
+	if err := source.connect(); err != nil {
+		return nil, err
+	}
+	defer source.disconnect()
+
+	sourceTypes, err := source.columnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, col := range job.Columns {
+		if _, ok := sourceTypes[col]; !ok {
+			return nil, &SchemaError{
+				Target: job.Source,
+				Err:    fmt.Errorf("source is missing column '%s'", col),
+			}
+		}
+	}
+
+	var results []SchemaResult
+	for _, targetConfig := range job.Targets {
+		target := job.newTable(targetConfig)
+		if target.isNoop() {
+			continue // A noop target doesn't have a schema
+		}
+
+		result := target.ensureSchema(job, sourceTypes, apply)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// ensureSchema checks (and, if apply is true, creates) the table and its columns
+func (t table) ensureSchema(job JobConfig, sourceTypes map[string]string, apply bool) SchemaResult {
+	result := SchemaResult{Target: t.config}
+
+	if err := t.connect(); err != nil {
+		result.Error = err
+		return result
+	}
+	defer t.disconnect()
+
+	exists, err := t.exists()
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	var statements []string
+
+	if !exists {
+		result.Missing = true
+		result.MissingColumns = job.Columns
+		statements = append(statements, t.createTableStatement(job, sourceTypes))
+	} else {
+		existing, err := t.columnNames()
+		if err != nil {
+			result.Error = err
+			return result
+		}
+
+		for _, col := range job.Columns {
+			found := slices.ContainsFunc(existing, func(name string) bool {
+				return strings.EqualFold(name, col)
+			})
+
+			if !found {
+				result.MissingColumns = append(result.MissingColumns, col)
+				statements = append(statements, t.addColumnStatement(col, sourceTypes[col]))
+			}
+		}
+	}
+
+	if !apply || len(statements) == 0 {
+		return result
+	}
+
+	for _, statement := range statements {
+		if _, err := t.writer().ExecContext(t.context(), statement); err != nil {
+			result.Error = &SchemaError{Target: t.config, Err: err}
+			return result
+		}
+	}
+
+	result.Applied = true
+	return result
+}
+
+// exists returns whether the table exists
+func (t table) exists() (bool, error) {
+	var query string
+	switch t.config.Driver {
+	case "mysql":
+		query = `
+			SELECT COUNT(*) FROM information_schema.TABLES
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+		`
+	case "sqlite3":
+		query = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+	default:
+		return false, fmt.Errorf("unsupported driver: %s", t.config.Driver)
+	}
+
+	var count int
+	row := t.reader().QueryRowxContext(t.context(), query, t.config.Table)
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// columnTypes returns the declared type (e.g. "varchar(255)") of each of the table's columns
+func (t table) columnTypes() (map[string]string, error) {
+	var query string
+	switch t.config.Driver {
+	case "mysql":
+		query = `
+			SELECT COLUMN_NAME, COLUMN_TYPE FROM information_schema.COLUMNS
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+		`
+	case "sqlite3":
+		query = "SELECT name, type FROM pragma_table_info(?)"
+	default:
+		return nil, fmt.Errorf("unsupported driver: %s", t.config.Driver)
+	}
+
+	rows, err := t.reader().QueryxContext(t.context(), query, t.config.Table)
+	if err != nil {
+		return nil, &SchemaError{Target: t.config, Err: err}
+	}
+	defer rows.Close()
+
+	types := map[string]string{}
+	for rows.Next() {
+		var name, columnType string
+		if err := rows.Scan(&name, &columnType); err != nil {
+			return nil, err
+		}
+		types[name] = columnType
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(types) == 0 {
+		return nil, &SchemaError{Target: t.config, Err: fmt.Errorf("table does not exist")}
+	}
+
+	return types, nil
+}
+
+// requiredColumns returns the table's columns that an INSERT must give a value for, because they
+// are NOT NULL and have no default (and aren't otherwise filled in, like an auto-increment column)
+func (t table) requiredColumns() ([]string, error) {
+	switch t.config.Driver {
+	case "mysql":
+		query := `
+			SELECT COLUMN_NAME FROM information_schema.COLUMNS
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+				AND IS_NULLABLE = 'NO' AND COLUMN_DEFAULT IS NULL
+				AND EXTRA NOT LIKE '%auto_increment%' AND EXTRA NOT LIKE '%GENERATED%'
+			ORDER BY ORDINAL_POSITION
+		`
+
+		var columns []string
+		err := sqlx.SelectContext(t.context(), t.reader(), &columns, query, t.config.Table)
+		if err != nil {
+			return nil, &SchemaError{Target: t.config, Err: err}
+		}
+		return columns, nil
+
+	case "sqlite3":
+		var info []struct {
+			Name       string  `db:"name"`
+			Type       string  `db:"type"`
+			NotNull    bool    `db:"notnull"`
+			Default    *string `db:"dflt_value"`
+			PrimaryKey int     `db:"pk"`
+		}
+
+		query := "SELECT name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(?)"
+		err := sqlx.SelectContext(t.context(), t.reader(), &info, query, t.config.Table)
+		if err != nil {
+			return nil, &SchemaError{Target: t.config, Err: err}
+		}
+
+		var numPrimaryKeys int
+		for _, col := range info {
+			if col.PrimaryKey > 0 {
+				numPrimaryKeys++
+			}
+		}
+
+		var columns []string
+		for _, col := range info {
+			// A lone INTEGER PRIMARY KEY is an alias for the rowid, which sqlite fills in
+			isRowID := numPrimaryKeys == 1 && col.PrimaryKey > 0 &&
+				strings.EqualFold(col.Type, "INTEGER")
+
+			if col.NotNull && col.Default == nil && !isRowID {
+				columns = append(columns, col.Name)
+			}
+		}
+		return columns, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported driver: %s", t.config.Driver)
+	}
+}
+
+// createTableStatement builds the CREATE TABLE statement for the table, with the job's columns and
+// the table's primary keys
+func (t table) createTableStatement(job JobConfig, sourceTypes map[string]string) string {
+	definitions := make([]string, 0, len(job.Columns)+1)
+	for _, col := range job.Columns {
+		definitions = append(definitions, t.columnDefinition(col, sourceTypes[col]))
+	}
+
+	// sqlite's implicit rowid doesn't need to be declared
+	if !job.usesRowID() {
+		primaryKeys := strings.Join(t.quoteColumns(t.primaryKeys), ", ")
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", primaryKeys))
+	}
+
+	return fmt.Sprintf(
+		"CREATE TABLE %s (%s)",
+		quoteIdentifier(t.config.Driver, t.config.Table),
+		strings.Join(definitions, ", "),
+	)
+}
+
+// addColumnStatement builds the ALTER TABLE statement that adds the column to the table
+func (t table) addColumnStatement(column, columnType string) string {
+	return fmt.Sprintf(
+		"ALTER TABLE %s ADD COLUMN %s",
+		quoteIdentifier(t.config.Driver, t.config.Table),
+		t.columnDefinition(column, columnType),
+	)
+}
+
+// columnDefinition formats a column's name and type. sqlite allows columns without a type, so the
+// type is omitted if it's empty
+func (t table) columnDefinition(column, columnType string) string {
+	if columnType == "" {
+		return quoteIdentifier(t.config.Driver, column)
+	}
+
+	return quoteIdentifier(t.config.Driver, column) + " " + columnType
+}
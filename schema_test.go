@@ -0,0 +1,159 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureSchema(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:ensure_schema_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	require.NoError(t, source.connect())
+	defer source.Close()
+	source.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			bio TEXT
+		)
+	`)
+	source.MustExec("INSERT INTO users (id, name, bio) VALUES (1, 'Alice', 'a')")
+
+	// The first target already has every column (and an extra one)
+	completeConfig := TableConfig{
+		Label:  "complete",
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:ensure_schema_complete.db?mode=memory&cache=shared",
+	}
+
+	complete := table{config: completeConfig}
+	require.NoError(t, complete.connect())
+	defer complete.Close()
+	complete.MustExec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			bio TEXT,
+			extra TEXT
+		)
+	`)
+
+	// The second target is missing the bio column
+	partialConfig := TableConfig{
+		Label:  "partial",
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:ensure_schema_partial.db?mode=memory&cache=shared",
+	}
+
+	partial := table{config: partialConfig}
+	require.NoError(t, partial.connect())
+	defer partial.Close()
+	partial.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY NOT NULL, name TEXT)")
+
+	// The third target doesn't have the table at all
+	emptyConfig := TableConfig{
+		Label:  "empty",
+		Driver: "sqlite3",
+		Table:  "users",
+		DSN:    "file:ensure_schema_empty.db?mode=memory&cache=shared",
+	}
+
+	empty := table{config: emptyConfig}
+	require.NoError(t, empty.connect())
+	defer empty.Close()
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name", "bio"},
+				Source:      sourceConfig,
+				Targets:     []TableConfig{completeConfig, partialConfig, emptyConfig},
+			},
+		},
+	}
+
+	// Verifying only reports what is missing
+	results, err := config.EnsureSchema("users", false)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	expected := []SchemaResult{
+		{Target: completeConfig},
+		{Target: partialConfig, MissingColumns: []string{"bio"}},
+		{Target: emptyConfig, Missing: true, MissingColumns: []string{"id", "name", "bio"}},
+	}
+	assert.Equal(t, expected, results)
+
+	_, err = empty.columnTypes()
+	assert.ErrorContains(t, err, "table does not exist")
+
+	// Applying creates the missing table and adds the missing column, with the source's types
+	results, err = config.EnsureSchema("users", true)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	for i := range expected {
+		expected[i].Applied = len(expected[i].MissingColumns) > 0
+	}
+	assert.Equal(t, expected, results)
+
+	partialTypes, err := partial.columnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"id": "INTEGER", "name": "TEXT", "bio": "TEXT"}, partialTypes)
+
+	emptyTypes, err := empty.columnTypes()
+	require.NoError(t, err)
+	assert.Equal(
+		t, map[string]string{"id": "INTEGER", "name": "VARCHAR(255)", "bio": "TEXT"}, emptyTypes,
+	)
+
+	// Now that every target has the columns, ensuring the schema again is a no-op, and the job syncs
+	results, err = config.EnsureSchema("users", true)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]SchemaResult{{Target: completeConfig}, {Target: partialConfig}, {Target: emptyConfig}},
+		results,
+	)
+
+	syncResults, err := config.ExecJob("users")
+	require.NoError(t, err)
+	for _, result := range syncResults.Results {
+		require.NoError(t, result.Error)
+	}
+
+	_, err = config.EnsureSchema("pets", false)
+	assert.ErrorContains(t, err, "job 'pets' not found in config")
+}
+
+func TestCreateTableStatement(t *testing.T) {
+	job := JobConfig{
+		PrimaryKeys: []string{"org", "id"},
+		Columns:     []string{"id", "org", "name"},
+	}
+	sourceTypes := map[string]string{"id": "int", "org": "varchar(64)", "name": "varchar(255)"}
+
+	target := job.newTable(TableConfig{Driver: "mysql", Table: "users"})
+	assert.Equal(
+		t,
+		"CREATE TABLE `users` (`id` int, `org` varchar(64), `name` varchar(255), "+
+			"PRIMARY KEY (`org`, `id`))",
+		target.createTableStatement(job, sourceTypes),
+	)
+
+	assert.Equal(
+		t,
+		"ALTER TABLE `users` ADD COLUMN `name` varchar(255)",
+		target.addColumnStatement("name", "varchar(255)"),
+	)
+}
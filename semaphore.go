@@ -0,0 +1,51 @@
+package sync
+
+import "sync"
+
+// semaphore limits how many goroutines can do something at once. A nil semaphore has no limit
+type semaphore chan struct{}
+
+// newSemaphore creates a semaphore that allows up to limit holders at once. If limit is not
+// positive, there is no limit
+func newSemaphore(limit int) semaphore {
+	if limit <= 0 {
+		return nil
+	}
+
+	return make(semaphore, limit)
+}
+
+// acquire blocks until the semaphore has room
+func (s semaphore) acquire() {
+	if s != nil {
+		s <- struct{}{}
+	}
+}
+
+// release frees up room in the semaphore for another holder
+func (s semaphore) release() {
+	if s != nil {
+		<-s
+	}
+}
+
+// forEachConcurrently calls fn for each index in [0, n) in its own goroutine, with at most limit
+// calls in progress at once (if limit is positive), and waits for all of them to return
+func forEachConcurrently(n, limit int, fn func(i int)) {
+	var wg sync.WaitGroup
+	sem := newSemaphore(limit)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			sem.acquire()
+			defer sem.release()
+
+			fn(i)
+		}(i)
+	}
+
+	wg.Wait() // Wait for all goroutines to finish
+}
@@ -0,0 +1,45 @@
+package sync
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEachConcurrently(t *testing.T) {
+	runAll := func(limit int) ([]bool, int32) {
+		var inProgress, maxInProgress atomic.Int32
+		called := make([]bool, 8)
+
+		forEachConcurrently(len(called), limit, func(i int) {
+			current := inProgress.Add(1)
+			defer inProgress.Add(-1)
+
+			for {
+				max := maxInProgress.Load()
+				if current <= max || maxInProgress.CompareAndSwap(max, current) {
+					break
+				}
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			called[i] = true
+		})
+
+		return called, maxInProgress.Load()
+	}
+
+	// Every index is called, and no more than the limit are in progress at once
+	called, maxInProgress := runAll(2)
+	assert.Equal(t, []bool{true, true, true, true, true, true, true, true}, called)
+	assert.LessOrEqual(t, maxInProgress, int32(2))
+
+	_, maxInProgress = runAll(1)
+	assert.Equal(t, int32(1), maxInProgress)
+
+	// Without a limit, they are all called at once
+	_, maxInProgress = runAll(0)
+	assert.Greater(t, maxInProgress, int32(2))
+}
@@ -0,0 +1,97 @@
+package sync
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// shardSource partitions the source's rows into one shard per target (see JobConfig.ShardColumn).
+// Each shard keeps the source's primary key order, and has its own checksum
+func (job JobConfig) shardSource(source tableData) ([]tableData, error) {
+	return job.bucketSource(source, job.ShardColumn, len(job.Targets), "shard column")
+}
+
+// bucketSource splits the source's rows into numBuckets buckets, by their value of the given
+// integer column modulo numBuckets (see shardOf). Each bucket keeps the source's primary key order,
+// and has its own checksum. The description of the column is used in errors
+func (job JobConfig) bucketSource(
+	source tableData,
+	columnName string,
+	numBuckets int,
+	description string,
+) ([]tableData, error) {
+	column := slices.Index(job.syncColumns(), columnName)
+	if column == -1 {
+		return nil, fmt.Errorf("%s '%s' is not in columns", description, columnName)
+	}
+
+	buckets := make([]tableData, numBuckets)
+	for i := range buckets {
+		buckets[i] = tableData{entries: [][]any{}, entryMap: map[primaryKeyTuple][]any{}}
+	}
+
+	t := job.newTable(job.Source)
+	for _, row := range source.entries {
+		bucket, err := shardOf(row[column], numBuckets)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to route row %s by %s '%s': %w",
+				t.rowKeyOf(row), description, columnName, err,
+			)
+		}
+
+		buckets[bucket].entries = append(buckets[bucket].entries, row)
+		buckets[bucket].entryMap[t.keyOf(row)] = row
+	}
+
+	for i := range buckets {
+		checksum, err := t.checksum(buckets[i].entries)
+		if err != nil {
+			return nil, err
+		}
+		buckets[i].checksum = checksum
+	}
+
+	return buckets, nil
+}
+
+// shardOf returns the shard that a row with the given shard column value belongs to, which is the
+// value modulo the number of shards. A negative value's shard is still in [0, numShards)
+func shardOf(value any, numShards int) (int, error) {
+	var n int64
+	switch v := value.(type) {
+	case int64:
+		n = v
+	case int:
+		n = int64(v)
+	case int32:
+		n = int64(v)
+	case uint64:
+		return int(v % uint64(numShards)), nil
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > math.MaxInt64 {
+			return 0, fmt.Errorf("value %v is not an integer", v)
+		}
+		n = int64(v)
+	case []byte:
+		return shardOf(string(v), numShards)
+	case string:
+		var err error
+		if n, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, fmt.Errorf("value '%s' is not an integer", v)
+		}
+	case nil:
+		return 0, fmt.Errorf("value is NULL")
+	default:
+		return 0, fmt.Errorf("value %v (%T) is not an integer", v, v)
+	}
+
+	shard := n % int64(numShards)
+	if shard < 0 {
+		shard += int64(numShards)
+	}
+
+	return int(shard), nil
+}
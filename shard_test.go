@@ -0,0 +1,38 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardOf(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected int
+	}{
+		{int64(0), 0},
+		{int64(7), 1},
+		{int64(-7), 2},
+		{uint64(8), 2},
+		{6.0, 0},
+		{"10", 1},
+		{[]byte("11"), 2},
+	}
+
+	for _, tt := range tests {
+		shard, err := shardOf(tt.value, 3)
+		require.NoError(t, err, "%v", tt.value)
+		assert.Equal(t, tt.expected, shard, "%v", tt.value)
+	}
+
+	_, err := shardOf(nil, 3)
+	assert.EqualError(t, err, "value is NULL")
+
+	_, err = shardOf(1.5, 3)
+	assert.EqualError(t, err, "value 1.5 is not an integer")
+
+	_, err = shardOf("abc", 3)
+	assert.EqualError(t, err, "value 'abc' is not an integer")
+}
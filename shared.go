@@ -0,0 +1,60 @@
+package sync
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// sharedConnections lets tables in the same database (e.g. the sources of jobs that read from the
+// same database) share a single connection pool, instead of each opening their own
+type sharedConnections struct {
+	mu  sync.Mutex
+	dbs map[string]*sqlx.DB // By driver, DSN, and init SQL
+}
+
+func newSharedConnections() *sharedConnections {
+	return &sharedConnections{dbs: map[string]*sqlx.DB{}}
+}
+
+// connect returns the connection pool for the given driver, DSN, and init SQL, opening it if this
+// is the first table to use it
+func (s *sharedConnections) connect(driver, dsn string, initSQL []string) (*sqlx.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Tables with different init SQL need their own pools, since their sessions are set up differently
+	key := driver + "|" + dsn
+	if len(initSQL) > 0 {
+		key += "|" + strings.Join(initSQL, "\x00")
+	}
+	if db, ok := s.dbs[key]; ok {
+		return db, nil
+	}
+
+	db, err := openDB(driver, dsn, initSQL)
+	if err != nil {
+		return nil, err
+	}
+
+	s.dbs[key] = db
+	return db, nil
+}
+
+// close closes every shared connection pool. This should only be called once no table is using
+// them anymore
+func (s *sharedConnections) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for key, db := range s.dbs {
+		if err := db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(s.dbs, key)
+	}
+
+	return firstErr
+}
@@ -0,0 +1,89 @@
+package sync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedConnections(t *testing.T) {
+	sourceConfig := TableConfig{
+		Driver: "sqlite3",
+		DSN:    "file:shared_connections_source.db?mode=memory&cache=shared",
+	}
+
+	source := table{config: sourceConfig}
+	require.NoError(t, source.connect())
+	defer source.disconnect()
+
+	source.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)")
+	source.MustExec("CREATE TABLE IF NOT EXISTS pets (id INTEGER PRIMARY KEY, name TEXT)")
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice')")
+	source.MustExec("INSERT INTO pets (id, name) VALUES (1, 'Rex')")
+
+	targetConfig := TableConfig{
+		Driver: "sqlite3",
+		DSN:    "file:shared_connections_target.db?mode=memory&cache=shared",
+	}
+
+	target := table{config: targetConfig}
+	require.NoError(t, target.connect())
+	defer target.disconnect()
+
+	target.MustExec("CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)")
+	target.MustExec("CREATE TABLE IF NOT EXISTS pets (id INTEGER PRIMARY KEY, name TEXT)")
+
+	newJob := func(tableName string) JobConfig {
+		jobSource, jobTarget := sourceConfig, targetConfig
+		jobSource.Table, jobTarget.Table = tableName, tableName
+
+		return JobConfig{
+			PrimaryKeys: []string{"id"},
+			Columns:     []string{"id", "name"},
+			Source:      jobSource,
+			Targets:     []TableConfig{jobTarget},
+		}
+	}
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": newJob("users"),
+			"pets":  newJob("pets"),
+		},
+	}
+
+	sources := newSharedConnections()
+
+	for _, jobName := range []string{"users", "pets"} {
+		result, err := config.execJob(context.Background(), jobName, sources, nil)
+		require.NoError(t, err)
+		require.Len(t, result.Results, 1)
+		require.NoError(t, result.Results[0].Error)
+		assert.True(t, result.Results[0].Synced)
+	}
+
+	// Both jobs read from the same source database, so only one connection pool was opened (and
+	// it is still open, since it is shared)
+	require.Len(t, sources.dbs, 1)
+
+	db := sources.dbs["sqlite3|"+sourceConfig.DSN]
+	require.NotNil(t, db)
+	require.NoError(t, db.Ping())
+
+	// Closing the shared connections closes the pool
+	require.NoError(t, sources.close())
+	assert.Empty(t, sources.dbs)
+	assert.ErrorContains(t, db.Ping(), "database is closed")
+
+	// ExecAllJobs shares the source connection in the same way
+	target.MustExec("DELETE FROM users")
+	target.MustExec("DELETE FROM pets")
+
+	results, errs := config.ExecAllJobs()
+	for jobName := range config.Jobs {
+		require.NoError(t, errs[jobName])
+		assert.True(t, results[jobName].Results[0].Synced)
+	}
+}
@@ -0,0 +1,234 @@
+package sync
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func init() {
+	// Rows are gob-encoded when they are spilled, so every type a row value can have (other than
+	// gob's basic types) must be registered
+	gob.Register(time.Time{})
+	gob.Register(emptyBytes(true))
+}
+
+// emptyBytes stands in for an empty (but non-nil) []byte when a row is spilled, since gob would
+// decode it as a nil []byte, which is NULL
+type emptyBytes bool
+
+// spilledRows is a table's rows, stored in a temporary sqlite database on disk instead of in
+// memory, so that a table larger than memory can still be diffed. Rows are looked up by their
+// primary key, and iterated in the order they were read (i.e. primary key order)
+type spilledRows struct {
+	dir string // The temporary directory that contains the database
+	db  *sqlx.DB
+	tx  *sqlx.Tx // The transaction that rows are added in, until they are all added
+
+	insert *sqlx.Stmt
+	lookup *sqlx.Stmt
+
+	count int   // The number of rows
+	err   error // The first error from looking up or iterating over the rows (see Err)
+}
+
+// newSpilledRows creates an empty store in a new temporary directory within dir (or the default
+// temporary directory, if dir is empty)
+func newSpilledRows(dir string) (*spilledRows, error) {
+	dir, err := os.MkdirTemp(dir, "sql-table-sync-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spill directory: %w", err)
+	}
+
+	s := &spilledRows{dir: dir}
+	if err := s.open(); err != nil {
+		s.close()
+		return nil, fmt.Errorf("failed to create spill database: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *spilledRows) open() error {
+	var err error
+	s.db, err = sqlx.Open("sqlite3", filepath.Join(s.dir, "rows.db"))
+	if err != nil {
+		return err
+	}
+
+	// A single connection, since the transaction and statements must all use the same one
+	s.db.SetMaxOpenConns(1)
+
+	// The database is thrown away afterwards, so it doesn't need to survive a crash
+	_, err = s.db.Exec(`
+		PRAGMA journal_mode = OFF;
+		PRAGMA synchronous = OFF;
+		CREATE TABLE rows (pos INTEGER PRIMARY KEY, key BLOB NOT NULL, row BLOB NOT NULL);
+	`)
+	if err != nil {
+		return err
+	}
+
+	if s.tx, err = s.db.Beginx(); err != nil {
+		return err
+	}
+
+	s.insert, err = s.tx.Preparex("INSERT INTO rows (pos, key, row) VALUES (?, ?, ?)")
+	return err
+}
+
+// add stores a row. Rows must be added before any are looked up or iterated over (see finish)
+func (s *spilledRows) add(key primaryKeyTuple, row []any) error {
+	keyBytes, err := encodeSpilled([]any{key.First, key.Second, key.Third})
+	if err != nil {
+		return err
+	}
+
+	rowBytes, err := encodeSpilled(row)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.insert.Exec(s.count, keyBytes, rowBytes); err != nil {
+		return err
+	}
+
+	s.count++
+	return nil
+}
+
+// finish commits the added rows and indexes them by their primary key, so they can be looked up
+func (s *spilledRows) finish() error {
+	if err := s.tx.Commit(); err != nil {
+		return err
+	}
+
+	// Indexing once all of the rows are added is much faster than maintaining the index
+	if _, err := s.db.Exec("CREATE INDEX rows_key ON rows (key)"); err != nil {
+		return err
+	}
+
+	// If rows have the same key, the last one wins (like a map)
+	var err error
+	s.lookup, err = s.db.Preparex("SELECT row FROM rows WHERE key = ? ORDER BY pos DESC LIMIT 1")
+	return err
+}
+
+// get returns the row with the given primary key, if there is one. If the lookup fails, it is
+// reported as not found, and the error is recorded (see Err)
+func (s *spilledRows) get(key primaryKeyTuple) ([]any, bool) {
+	if s.err != nil {
+		return nil, false
+	}
+
+	keyBytes, err := encodeSpilled([]any{key.First, key.Second, key.Third})
+	if err != nil {
+		s.err = err
+		return nil, false
+	}
+
+	var rowBytes []byte
+	if err := s.lookup.Get(&rowBytes, keyBytes); err == sql.ErrNoRows {
+		return nil, false
+	} else if err != nil {
+		s.err = err
+		return nil, false
+	}
+
+	row, err := decodeSpilled(rowBytes)
+	if err != nil {
+		s.err = err
+		return nil, false
+	}
+
+	return row, true
+}
+
+// each calls fn with each row, in the order they were added. If iterating fails, it stops early,
+// and the error is recorded (see Err)
+func (s *spilledRows) each(fn func(row []any)) {
+	if s.err != nil {
+		return
+	}
+
+	rows, err := s.db.Query("SELECT row FROM rows ORDER BY pos")
+	if err != nil {
+		s.err = err
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rowBytes []byte
+		if err := rows.Scan(&rowBytes); err != nil {
+			s.err = err
+			return
+		}
+
+		row, err := decodeSpilled(rowBytes)
+		if err != nil {
+			s.err = err
+			return
+		}
+
+		fn(row)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.err = err
+	}
+}
+
+// Err returns the first error from looking up or iterating over the rows, if any
+func (s *spilledRows) Err() error {
+	return s.err
+}
+
+// close deletes the rows (and their temporary directory)
+func (s *spilledRows) close() error {
+	if s.db != nil {
+		s.db.Close()
+	}
+
+	return os.RemoveAll(s.dir)
+}
+
+// encodeSpilled gob-encodes a row's values
+func encodeSpilled(values []any) ([]byte, error) {
+	encoded := make([]any, len(values))
+	for i, val := range values {
+		if b, ok := val.([]byte); ok && b != nil && len(b) == 0 {
+			val = emptyBytes(true)
+		}
+		encoded[i] = val
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(encoded); err != nil {
+		return nil, fmt.Errorf("failed to encode spilled row: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeSpilled decodes a row that was encoded by encodeSpilled
+func decodeSpilled(data []byte) ([]any, error) {
+	var values []any
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&values); err != nil {
+		return nil, fmt.Errorf("failed to decode spilled row: %w", err)
+	}
+
+	for i, val := range values {
+		if _, ok := val.(emptyBytes); ok {
+			values[i] = []byte{}
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,52 @@
+package sync
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpilledRows(t *testing.T) {
+	dir := t.TempDir()
+
+	spilled, err := newSpilledRows(dir)
+	require.NoError(t, err)
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := [][]any{
+		{int64(1), "Alice", []byte("blob"), 1.5, createdAt, true},
+		{int64(2), "", []byte{}, nil, nil, false},
+		{int64(3), "Charlie", nil, 0.0, createdAt, nil},
+	}
+
+	for _, row := range rows {
+		require.NoError(t, spilled.add(primaryKeyTuple{First: row[0]}, row))
+	}
+	require.NoError(t, spilled.finish())
+
+	// Every value round trips with its original type (including empty bytes, which aren't NULL)
+	var iterated [][]any
+	spilled.each(func(row []any) { iterated = append(iterated, row) })
+	require.NoError(t, spilled.Err())
+	assert.Equal(t, rows, iterated)
+
+	row, ok := spilled.get(primaryKeyTuple{First: int64(2)})
+	require.True(t, ok)
+	assert.Equal(t, rows[1], row)
+
+	// Keys match like a map's, so an equal value of a different type doesn't match
+	_, ok = spilled.get(primaryKeyTuple{First: int64(4)})
+	assert.False(t, ok)
+	_, ok = spilled.get(primaryKeyTuple{First: "1"})
+	assert.False(t, ok)
+	require.NoError(t, spilled.Err())
+
+	// Closing deletes the rows from disk
+	require.NoError(t, spilled.close())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
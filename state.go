@@ -0,0 +1,139 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// stateFileMu serializes saving state, since jobs that are executed concurrently (see
+// Config.MaxConcurrency) can share a state file
+var stateFileMu sync.Mutex
+
+// sourceStateStore persists the checksum of each job's source as of the job's last successful
+// sync, so that a job whose source hasn't changed since can be skipped without reading its targets
+type sourceStateStore struct {
+	filename string
+	states   map[string]sourceState // Maps job names to source states
+}
+
+// sourceState is a job's source checksum, along with the checksums of its partitions, if the job
+// has a partitioned checksum (see JobConfig.PartitionedChecksum)
+type sourceState struct {
+	Checksum   string   `json:"checksum"`
+	Partitions []string `json:"partitions"`
+}
+
+// MarshalJSON encodes a state without partitions as just its checksum, which is how every state
+// was encoded before partitions were persisted
+func (s sourceState) MarshalJSON() ([]byte, error) {
+	if len(s.Partitions) == 0 {
+		return json.Marshal(s.Checksum)
+	}
+
+	type state sourceState // Without this method, so it isn't called recursively
+	return json.Marshal(state(s))
+}
+
+// UnmarshalJSON decodes a state that is either just a checksum or an object (see MarshalJSON)
+func (s *sourceState) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*s = sourceState{}
+		return json.Unmarshal(data, &s.Checksum)
+	}
+
+	type state sourceState
+	return json.Unmarshal(data, (*state)(s))
+}
+
+// loadSourceStates reads the state file. A missing file is treated as having no state
+func loadSourceStates(filename string) (*sourceStateStore, error) {
+	store := &sourceStateStore{filename: filename, states: map[string]sourceState{}}
+
+	fileBytes, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(fileBytes, &store.states); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// unchanged returns whether the job's source checksum matches the one from its last successful sync
+func (s *sourceStateStore) unchanged(jobName, checksum string) bool {
+	stored, ok := s.states[jobName]
+	return ok && stored.Checksum == checksum
+}
+
+// driftedPartitions returns the partitions whose checksums differ from the ones from the job's last
+// successful sync. It returns nil if there are no persisted checksums for that many partitions, in
+// which case every partition has to be treated as drifted
+func (s *sourceStateStore) driftedPartitions(jobName string, checksums []string) []int {
+	stored := s.states[jobName].Partitions
+	if len(stored) != len(checksums) {
+		return nil
+	}
+
+	drifted := []int{}
+	for i, checksum := range checksums {
+		if stored[i] != checksum {
+			drifted = append(drifted, i)
+		}
+	}
+
+	return drifted
+}
+
+// save records state as the job's source state and persists the states
+func (s *sourceStateStore) save(jobName string, state sourceState) error {
+	stateFileMu.Lock()
+	defer stateFileMu.Unlock()
+
+	// Another job may have saved its own state since the file was loaded, so start from that
+	latest, err := loadSourceStates(s.filename)
+	if err != nil {
+		return err
+	}
+
+	s.states = latest.states
+	s.states[jobName] = state
+
+	fileBytes, err := json.MarshalIndent(s.states, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename it, so an interruption can't leave a partially written file
+	tmpFilename := s.filename + ".tmp"
+	if err := os.WriteFile(tmpFilename, fileBytes, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFilename, s.filename)
+}
+
+// skipsUnchangedSource returns whether the job can be skipped when its source is unchanged. A dry
+// run always computes the real diff, and a sync of only recently changed rows (see Since) or a
+// range of primary keys (see PrimaryKeyMin) doesn't read the whole source, so none of them use the
+// persisted state
+func (job JobConfig) skipsUnchangedSource() bool {
+	return job.SkipUnchangedSource && job.name != "" && !job.DryRun && !job.RollbackDryRun &&
+		job.Since.IsZero() && !job.hasKeyRange()
+}
+
+// allInSync returns whether every target was successfully synced (or was already in sync)
+func allInSync(results []SyncResult) bool {
+	for _, r := range results {
+		if r.Error != nil || r.Skipped {
+			return false
+		}
+	}
+
+	return true
+}
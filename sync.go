@@ -1,13 +1,23 @@
 package sync
 
 import (
+	"cmp"
+	"context"
 	"crypto/md5"
+	"database/sql"
 	"encoding/hex"
 	"encoding/json"
-	"reflect"
-	"sync"
+	"fmt"
+	"hash"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // SyncResult contains the results of syncing a single target table
@@ -16,272 +26,1820 @@ type SyncResult struct {
 	TargetChecksum string
 	Synced         bool
 	Error          error
+
+	// Status is why the target was (or wasn't) written to, e.g. to tell a target that was already
+	// in sync apart from one whose changes weren't written
+	Status SyncStatus
+
+	// Skipped is true if the target had changes, but writing them wasn't approved (see
+	// JobConfig.Approve)
+	Skipped bool
+
+	// RolledBack is true if the target's changes were written, but then rolled back (see
+	// JobConfig.RollbackDryRun)
+	RolledBack bool
+
+	// Unchanged is true if the target wasn't read at all, because the job's source hadn't changed
+	// since the job's last successful sync (see JobConfig.SkipUnchangedSource)
+	Unchanged bool
+
+	// NumInserts, NumUpdates, and NumDeletes are the number of rows that were inserted, updated,
+	// and deleted in the target. In dry-run mode, these are the rows that would have been changed
+	NumInserts int
+	NumUpdates int
+	NumDeletes int
+
+	// TargetRowCount is the number of rows that the target has after the sync (or, if nothing was
+	// written, the number that it has now). It is derived from the rows that were read, so it is 0
+	// if the target couldn't be read (or wasn't, see Unchanged), and only counts the rows in
+	// Partitions if only those were read. After a full sync, it should match the job's
+	// SourceRowCount, so a mismatch means another writer changed the target concurrently
+	TargetRowCount int
+
+	// Partitions are the partitions of the target that were read and diffed, if the job has a
+	// partitioned checksum and only those partitions had changed (see
+	// JobConfig.PartitionedChecksum). It is nil if the whole target was read
+	Partitions []int
+
+	// FetchDuration is how long it took to read the target's rows. CompareDuration is how long it
+	// took to checksum and diff the target against the source. WriteDuration is how long it took to
+	// execute the statements against the target
+	FetchDuration   time.Duration
+	CompareDuration time.Duration
+	WriteDuration   time.Duration
+
+	// SkippedColumns are the job's columns that the target doesn't have, which weren't synced to it
+	// (see JobConfig.SkipMissingColumns)
+	SkippedColumns []string
+
+	// Updates are the key and changed columns of each row that was updated, and Inserts and Deletes
+	// are the values of each row that was inserted and deleted. They are only recorded if the job is
+	// verbose
+	Updates []RowUpdate
+	Inserts []RowValues
+	Deletes []RowValues
+
+	// Statements are the statements that would have been executed against the target (in order),
+	// if the job weren't a dry run (see JobConfig.DryRun). They are only recorded in dry-run mode
+	Statements []Statement
+
+	// PoolStats are the target's connection pool stats at the end of the sync (before it was
+	// disconnected). These are useful for diagnosing pool exhaustion, e.g. a high WaitCount means
+	// that statements waited for a connection. By then, none of its connections are in use (see
+	// PeakInUseConnections)
+	PoolStats sql.DBStats
+
+	// PeakInUseConnections is the most of the target's connections that were in use at once during
+	// the sync, as sampled while its rows were read and its statements were executed. Compared to
+	// PoolStats.MaxOpenConnections, it shows how close the sync came to exhausting the pool
+	PeakInUseConnections int
+}
+
+// Statement is a SQL statement that is executed against a target, with the args for its
+// placeholders. In direct statement mode, its values are inlined into its SQL, so it has no args
+// (see TableConfig.StatementMode)
+type Statement struct {
+	SQL  string
+	Args []any
+}
+
+// SyncStatus is the outcome of syncing a single target (see SyncResult.Status)
+type SyncStatus string
+
+const (
+	// SyncStatusInSync means the target already matched the source, so nothing was written
+	SyncStatusInSync SyncStatus = "in-sync"
+
+	// SyncStatusSynced means the target's changes were written
+	SyncStatusSynced SyncStatus = "synced"
+
+	// SyncStatusSkipped means the target had changes, but writing them wasn't approved (see
+	// JobConfig.Approve)
+	SyncStatusSkipped SyncStatus = "skipped"
+
+	// SyncStatusUnchanged means the target wasn't read at all, because the job's source hadn't
+	// changed since its last successful sync (see JobConfig.SkipUnchangedSource)
+	SyncStatusUnchanged SyncStatus = "unchanged"
+
+	// SyncStatusDryRun means the target had changes, but they weren't written (or were rolled back)
+	// because the job is a dry run (see JobConfig.DryRun and JobConfig.RollbackDryRun)
+	SyncStatusDryRun SyncStatus = "dry-run"
+
+	// SyncStatusError means the target couldn't be synced (see SyncResult.Error)
+	SyncStatusError SyncStatus = "error"
+)
+
+// status derives the result's status from how the target was synced. A target that has changes
+// that weren't written (or skipped) can only be in a dry run
+func (r SyncResult) status() SyncStatus {
+	switch {
+	case r.Error != nil:
+		return SyncStatusError
+	case r.Unchanged:
+		return SyncStatusUnchanged
+	case r.Skipped:
+		return SyncStatusSkipped
+	case r.Synced:
+		return SyncStatusSynced
+	case r.RolledBack || r.DriftRows() > 0:
+		return SyncStatusDryRun
+	default:
+		return SyncStatusInSync
+	}
+}
+
+// RowUpdate describes a target row that was (or, in dry-run mode, would be) updated
+type RowUpdate struct {
+	// KeyColumns are the row's primary key columns: the target's own primary keys, if it has them,
+	// and otherwise the job's
+	KeyColumns []string
+
+	Key     []any    // The row's primary key values, in the same order as KeyColumns
+	Columns []string // The columns whose values differed from the source
+
+	// Source and Target are the changed columns' values in the source and in the target (before
+	// the update), in the same order as Columns. Bytes are converted to strings
+	Source []any
+	Target []any
+}
+
+// RowValues describes a target row that was (or, in dry-run mode, would be) inserted or deleted
+type RowValues struct {
+	KeyColumns []string // The row's primary key columns (see RowUpdate)
+	Key        []any    // The row's primary key values, in the same order as KeyColumns
+	Columns    []string // The row's columns
+
+	// Values are the row's values, in the same order as Columns: the source's, if the row was
+	// inserted, and the target's (before the delete), if it was deleted. Bytes are converted to
+	// strings
+	Values []any
+}
+
+// tableData contains the rows read from a table, along with their checksum
+type tableData struct {
+	checksum string
+	entries  [][]any                   // Rows ordered by primary key
+	entryMap map[primaryKeyTuple][]any // Rows by primary key
+
+	// If set, the rows are stored on disk, instead of in entries and entryMap
+	spilled *spilledRows
+}
+
+// get returns the row with the given primary key, if there is one
+func (d tableData) get(key primaryKeyTuple) ([]any, bool) {
+	if d.spilled != nil {
+		return d.spilled.get(key)
+	}
+
+	row, ok := d.entryMap[key]
+	return row, ok
+}
+
+// each calls fn with each row, in primary key order
+func (d tableData) each(fn func(row []any)) {
+	if d.spilled != nil {
+		d.spilled.each(fn)
+		return
+	}
+
+	for _, row := range d.entries {
+		fn(row)
+	}
+}
+
+// numRows returns the number of rows
+func (d tableData) numRows() int {
+	if d.spilled != nil {
+		return d.spilled.count
+	}
+
+	return len(d.entries)
+}
+
+// result returns the result of syncing a job's targets to the (source's) rows
+func (d tableData) result(results []SyncResult) ExecJobResult {
+	return ExecJobResult{Checksum: d.checksum, SourceRowCount: d.numRows(), Results: results}
+}
+
+// newTable creates a table (either the source or a target) for the job
+func (job JobConfig) newTable(config TableConfig) table {
+	primaryKeys := job.PrimaryKeys
+	if len(config.PrimaryKeys) > 0 {
+		primaryKeys = config.PrimaryKeys // The target has its own primary keys
+	}
+
+	return table{
+		config:             config,
+		primaryKeys:        primaryKeys,
+		primaryKeyIndices:  job.getPrimaryKeyIndices(primaryKeys),
+		columns:            job.syncColumns(),
+		checksumIndices:    job.checksumIndices(primaryKeys),
+		comparison:         newComparison(job),
+		maxMemoryBytes:     job.MaxMemoryBytes,
+		spillThresholdRows: job.SpillThresholdRows,
+		spillDir:           job.SpillDir,
+		typeHints:          job.TypeHints,
+		emptyHandling:      job.emptyHandling(),
+		batchSize:          job.batchSize(),
+		insertIgnore:       job.InsertIgnore,
+		typedScan:          job.TypedScan,
+		encryption:         job.newTableEncryption(config, false),
+		connectLimits:      job.connectLimits,
+		tracing:            job.tracing,
+	}
+}
+
+// newSourceTable creates the job's source table
+func (job JobConfig) newSourceTable() table {
+	source := job.newTable(job.Source)
+	source.indexHint = job.SourceIndexHint
+	source.encryption = job.newTableEncryption(job.Source, true)
+	return source
+}
+
+// syncTargets syncs each of the job's targets to its source. If sources is non-nil, the source's
+// connection pool is shared with other jobs that read from the same database
+func (job JobConfig) syncTargets(sources *sharedConnections) (ExecJobResult, error) {
+	if !job.Since.IsZero() && job.IncrementalColumn == "" {
+		return ExecJobResult{}, fmt.Errorf(
+			"job has no incrementalColumn configured, so it can't use since",
+		)
+	}
+
+	// Replacing the target's rows with only the recently changed source rows would delete the rest
+	if !job.Since.IsZero() && job.ReplaceMode {
+		return ExecJobResult{}, fmt.Errorf("job uses replaceMode, so it can't use since")
+	}
+
+	if job.RollbackDryRun && !job.DryRun {
+		// A target is replaced in its own transaction, which is always committed
+		if job.ReplaceMode {
+			return ExecJobResult{}, fmt.Errorf("job uses replaceMode, so it can't use rollbackDryRun")
+		}
+
+		// A checkpoint would record progress that was rolled back
+		if job.CheckpointFile != "" {
+			return ExecJobResult{}, fmt.Errorf("job uses checkpointFile, so it can't use rollbackDryRun")
+		}
+	}
+
+	if err := job.checkKeyRange(); err != nil {
+		return ExecJobResult{}, err
+	}
+
+	if !job.Phases.all() {
+		// A target is replaced all at once, so it has no phases
+		if job.ReplaceMode {
+			return ExecJobResult{}, fmt.Errorf("job uses replaceMode, so it can't run a subset of phases")
+		}
+
+		// A checkpoint would resume after source rows whose statements were in skipped phases
+		if job.CheckpointFile != "" {
+			return ExecJobResult{}, fmt.Errorf("job uses checkpointFile, so it can't run a subset of phases")
+		}
+	}
+
+	source := job.newSourceTable()
+	source.shared = sources
+
+	// Only read the source rows that changed since the given time
+	if !job.Since.IsZero() {
+		column := quoteIdentifier(job.Source.Driver, job.IncrementalColumn)
+		source.where = sq.GtOrEq{column: job.Since}
+	}
+
+	// Only read the source rows in the job's primary key range (if it has one)
+	source.where = andWhere(source.where, job.keyRangeFilter(job.Source.Driver))
+
+	return job.syncTargetsFrom(source)
+}
+
+// sourceReader reads the rows that a job syncs to its targets. This is usually the job's source
+// table, but can also be a file (see ImportJob)
+type sourceReader interface {
+	readSource() (tableData, error)
+}
+
+// readSource connects to the table and reads all of its rows
+func (t table) readSource() (tableData, error) {
+	// Connect to the source
+	if err := t.connect(); err != nil {
+		return tableData{}, err
+	}
+
+	// Close the source connection pool when we're done reading
+	defer t.disconnect()
+
+	return t.getData()
+}
+
+// syncTargetsFrom syncs each of the job's targets to the rows read from the given source
+func (job JobConfig) syncTargetsFrom(source sourceReader) (ExecJobResult, error) {
+	var span trace.Span
+	job.tracing, span = job.tracing.start("syncTargets")
+
+	result, err := job.syncTargetsTraced(source)
+	endJobSpan(span, result, err)
+
+	return result, err
+}
+
+// syncTargetsTraced syncs each of the job's targets, within the span of syncTargetsFrom
+func (job JobConfig) syncTargetsTraced(source sourceReader) (ExecJobResult, error) {
+	if err := job.checkPrimaryKeyIndices(); err != nil {
+		return ExecJobResult{}, err
+	}
+
+	targetConfigs := job.Targets
+	if job.MaxTargets > 0 && job.MaxTargets < len(targetConfigs) {
+		targetConfigs = targetConfigs[:job.MaxTargets]
+	}
+
+	targets := make([]table, len(targetConfigs))
+	for i, target := range targetConfigs {
+		targets[i] = job.newTable(target)
+		targets[i].peakInUse = &connectionPeak{}
+
+		// Target rows outside of the job's primary key range (if it has one) are never read, so
+		// they are never updated or deleted
+		targets[i].where = job.keyRangeFilter(target.Driver)
+	}
+
+	// Get all rows from the source and put them in a map by their primary key
+	_, fetchSpan := job.tracing.start("fetch", attribute.String("sync.target", "source"))
+	sourceData, err := source.readSource()
+	fetchSpan.SetAttributes(attribute.Int("sync.rows.source", sourceData.numRows()))
+	endSpan(fetchSpan, err)
+	if err != nil {
+		return ExecJobResult{}, canceledError(job.tracing.context(), err)
+	}
+
+	// An empty source would delete every target row, which is almost always a mistake (e.g. the
+	// source is misconfigured or was truncated). When only recently changed rows are read, nothing
+	// is deleted, and there may simply be no recent changes. A range of primary keys can also simply
+	// have no rows, and only the target rows in the range are deleted
+	if len(sourceData.entries) == 0 && !job.AllowEmptySource && job.Since.IsZero() &&
+		!job.hasKeyRange() {
+		return ExecJobResult{}, fmt.Errorf("source is empty (set allowEmptySource to sync it anyway)")
+	}
+
+	var states *sourceStateStore
+	if job.skipsUnchangedSource() {
+		states, err = loadSourceStates(job.StateFile)
+		if err != nil {
+			return ExecJobResult{}, err
+		}
+
+		// If the source hasn't changed since the job was last synced, then (assuming nothing else
+		// writes to them) neither have the targets, so they don't need to be read
+		if states.unchanged(job.name, sourceData.checksum) {
+			results := make([]SyncResult, len(targets))
+			for i, target := range targets {
+				results[i] = SyncResult{
+					Target:    target.config,
+					Unchanged: true,
+					Status:    SyncStatusUnchanged,
+				}
+			}
+			return sourceData.result(results), nil
+		}
+	}
+
+	// With a partitioned checksum, only the partitions whose checksums changed since the job was
+	// last synced are read from the targets (and diffed), unless there are no checksums to compare
+	state := sourceState{Checksum: sourceData.checksum}
+	var drifted []int
+	var driftedSource tableData
+	if states != nil && job.PartitionedChecksum {
+		partitions, err := job.partitionSource(sourceData)
+		if err != nil {
+			return ExecJobResult{}, err
+		}
+
+		state.Partitions = make([]string, len(partitions))
+		for i, partition := range partitions {
+			state.Partitions[i] = partition.checksum
+		}
+
+		drifted = states.driftedPartitions(job.name, state.Partitions)
+		if drifted != nil {
+			if driftedSource, err = job.mergePartitions(partitions, drifted); err != nil {
+				return ExecJobResult{}, err
+			}
+		}
+	}
+
+	// A dry run (e.g. a check) diffs every source row, and leaves the checkpoints as they are for
+	// the next sync to resume from
+	var checkpoints *checkpointStore
+	if job.CheckpointFile != "" && !job.DryRun {
+		checkpoints, err = loadCheckpoints(job.CheckpointFile)
+		if err != nil {
+			return ExecJobResult{}, err
+		}
+	}
+
+	// If the targets are shards, each one is only synced to the source rows in its shard
+	var shards []tableData
+	if job.ShardColumn != "" {
+		shards, err = job.shardSource(sourceData)
+		if err != nil {
+			return ExecJobResult{}, err
+		}
+	}
+
+	// Each goroutine writes its own target's result, so the results are in the same order as the
+	// job's targets. This also diffs the targets concurrently when checking (i.e. a dry run), with
+	// the same limit on how many targets are handled at once
+	results := make([]SyncResult, len(targets))
+
+	ctx := job.tracing.context()
+
+	forEachConcurrently(len(targets), job.MaxConcurrency, func(i int) {
+		target := targets[i]
+
+		var span trace.Span
+		target.tracing, span = job.tracing.start("syncTarget", tableAttributes(target.config)...)
+		defer func() { endTargetSpan(span, results[i]) }()
+
+		// A target that was waiting to be synced when the job was cancelled isn't connected to
+		err := ctx.Err()
+		if err == nil {
+			// Connect to each target
+			_, connectSpan := target.tracing.start("connect")
+			err = target.connect()
+			endSpan(connectSpan, err)
+		}
+		if err != nil {
+			results[i] = SyncResult{
+				Target: target.config,
+				Error:  canceledError(ctx, err),
+				Status: SyncStatusError,
+			}
+			return
+		}
+
+		targetSource := sourceData
+		if shards != nil {
+			targetSource = shards[i]
+		}
+
+		if drifted != nil {
+			targetSource = driftedSource
+			target.where = andWhere(
+				target.where, target.partitionFilter(job.partitionCount(), drifted),
+			)
+		}
+
+		result := target.syncTarget(job, targetSource, checkpoints)
+		if drifted != nil {
+			result.Partitions = drifted
+		}
+		if target.DB != nil {
+			result.PoolStats = target.Stats()
+		}
+		result.PeakInUseConnections = target.peakInUse.get()
+		target.disconnect() // Close the target's connection pool
+
+		if result.Error != nil {
+			result.Error = canceledError(ctx, result.Error)
+		}
+
+		result.Status = result.status()
+		results[i] = result
+	})
+
+	// Only persist the source's state once every one of the job's targets is in sync with it
+	if states != nil && len(targets) == len(job.Targets) && job.Phases.all() &&
+		allInSync(results) {
+		if err := states.save(job.name, state); err != nil {
+			return sourceData.result(results), fmt.Errorf("failed to save source state: %w", err)
+		}
+	}
+
+	return sourceData.result(results), nil
+}
+
+// checkTargetToSourceRatio returns an error if a target has more rows than the job's
+// MaxTargetToSourceRatio allows, before the target's extra rows are deleted. A sync that doesn't
+// delete (e.g. with Since) is never limited
+func (job JobConfig) checkTargetToSourceRatio(sourceRows, targetRows int) error {
+	ratio := job.MaxTargetToSourceRatio
+	if ratio <= 0 || !job.Since.IsZero() || !job.Phases.has(PhaseDeletes) {
+		return nil
+	}
+
+	if float64(targetRows) > float64(sourceRows)*ratio {
+		return fmt.Errorf(
+			"target has %d rows, which is more than maxTargetToSourceRatio (%g) times the "+
+				"source's %d rows",
+			targetRows, ratio, sourceRows,
+		)
+	}
+
+	return nil
+}
+
+// canceledError returns the context's error if it was cancelled (which is why the sync failed with
+// err, however the driver reported it), and otherwise err
+func canceledError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	return err
+}
+
+func (t table) syncTarget(
+	job JobConfig,
+	source tableData,
+	checkpoints *checkpointStore,
+) SyncResult {
+	if t.isNoop() {
+		return t.syncNoop(job, source)
+	}
+
+	result := SyncResult{Target: t.config}
+
+	// An encrypted target stores (and is compared by) the encrypted values of the source's rows
+	if t.encryption != nil {
+		var err error
+		source, err = t.encryptSource(source)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	// Only sync the columns that the target has
+	if job.SkipMissingColumns {
+		var err error
+		source, result.SkippedColumns, err = t.skipMissingColumns(job, source)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	// A target with its own primary keys matches the source's rows by them instead
+	if len(t.config.PrimaryKeys) > 0 {
+		var err error
+		source, err = t.rekey(source)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	// A target in replace mode isn't diffed at all
+	if job.ReplaceMode {
+		return t.syncReplace(job, source)
+	}
+
+	// Read and write the target in a single transaction, so the rows that are diffed can't change
+	// before the diff is applied. A dry run never writes, so it doesn't need one
+	if job.TargetTransaction && !job.DryRun {
+		tx, err := t.writer().BeginTxx(t.context(), nil)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+
+		defer tx.Rollback() // This is a no-op once the transaction is committed
+		t.tx = tx
+	}
+
+	fetchStart := time.Now()
+	_, fetchSpan := t.tracing.start("fetch")
+	target, err := t.readTarget()
+	result.FetchDuration = time.Since(fetchStart)
+	endSpan(fetchSpan, err)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	result.TargetRowCount = target.numRows()
+
+	if target.spilled != nil {
+		defer target.spilled.close()
+	}
+
+	if err := job.checkTargetToSourceRatio(source.numRows(), result.TargetRowCount); err != nil {
+		result.Error = err
+		return result
+	}
+
+	compareStart := time.Now()
+	target.checksum, err = t.checksumData(target)
+	result.TargetChecksum = target.checksum
+	result.CompareDuration = time.Since(compareStart)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	// If the checksums match, then the data is already in sync
+	if source.checksum == target.checksum {
+		if checkpoints != nil {
+			result.Error = checkpoints.clear(t.config.id())
+		}
+		return result
+	}
+
+	// If the source and target are both sqlite, we can sync with set-based statements instead.
+	// These compare and copy values exactly, so they can't be used when values are normalized or
+	// coerced. They also don't compute the changes up front, so there is nothing to approve, and
+	// they run on their own connection, so they can't be part of the target's transaction (or be
+	// rolled back). They also copy every source row (and delete the target rows that aren't in it),
+	// so they can't be used for a shard, only some partitions, a range of primary keys, or only the
+	// recently changed rows, or to encrypt or decrypt values (or ignore conflicting inserts)
+	attach := job.AttachSQLite && !job.DryRun && !job.RollbackDryRun && job.Approve == nil &&
+		job.Phases.all() && t.comparison.exact() && len(job.TypeHints) == 0 &&
+		len(t.emptyHandling) == 0 && t.tx == nil && job.ShardColumn == "" && t.where == nil &&
+		job.Since.IsZero() && len(job.EncryptColumns) == 0 && !job.InsertIgnore
+	if attach && canAttach(job.Source, t.config) {
+		if err := t.checkRequiredColumns(t.columns); err != nil {
+			result.Error = err
+			return result
+		}
+
+		writeStart := time.Now()
+		_, writeSpan := t.tracing.start("write")
+		result.NumInserts, result.NumUpdates, result.NumDeletes, err = t.syncAttached(job.Source)
+		result.WriteDuration = time.Since(writeStart)
+		endSpan(writeSpan, err)
+		if err != nil {
+			result.Error = &SyncError{Target: t.config, Err: err}
+			return result
+		}
+
+		result.Synced = true
+		result.TargetRowCount += result.NumInserts - result.NumDeletes
+
+		// Every row was synced, so a checkpoint from an earlier (interrupted) sync is stale
+		if checkpoints != nil {
+			result.Error = checkpoints.clear(t.config.id())
+		}
+		return result
+	}
+
+	// If the source only contains the rows that changed recently, target rows that are missing
+	// from it weren't necessarily deleted
+	opts := diffOptions{
+		skipInserts: !job.Phases.has(PhaseInserts),
+		skipUpdates: !job.Phases.has(PhaseUpdates),
+		skipDeletes: !job.Since.IsZero() || !job.Phases.has(PhaseDeletes),
+		recordRows:  job.Verbose,
+	}
+
+	// If we are resuming from a checkpoint, skip the source rows that were already synced
+	if checkpoints != nil {
+		opts.skip = checkpoints.position(t.config.id(), source.entries, t.keyOf)
+	}
+
+	compareStart = time.Now()
+	diff := t.diff(source, target, opts)
+	result.CompareDuration += time.Since(compareStart)
+
+	// Reading spilled rows can fail partway through the diff, in which case it's incomplete
+	if target.spilled != nil && target.spilled.Err() != nil {
+		result.Error = target.spilled.Err()
+		return result
+	}
+
+	result.NumInserts = len(diff.inserts)
+	result.NumUpdates = len(diff.updates)
+	result.NumDeletes = len(diff.deletes)
+
+	if job.Verbose {
+		result.Updates = diff.updateChanges
+		result.Inserts = t.rowsValues(diff.insertRows)
+		result.Deletes = t.rowsValues(diff.deleteRows)
+	}
+
+	// The checksums can differ even when every row is considered equal (e.g. when comparing floats
+	// with a tolerance), in which case there is nothing to write
+	if result.DriftRows() == 0 {
+		if checkpoints != nil {
+			result.Error = checkpoints.clear(t.config.id())
+		}
+		return result
+	}
+
+	// The inserts would fail if the target has required columns that aren't synced, by which time
+	// the deletes and updates would already be written, so find out before anything is written.
+	// This is also reported by a dry run, since the sync would fail
+	if len(diff.inserts) > 0 {
+		if err := t.checkRequiredColumns(t.columns); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	// In dry-run mode, we report what would have changed but don't write anything
+	if job.DryRun {
+		result.Statements, result.Error = t.planDiff(diff)
+		return result
+	}
+
+	if job.Approve != nil && !job.Approve(result) {
+		result.Skipped = true
+		return result
+	}
+
+	if job.RollbackDryRun {
+		return t.applyDiffAndRollback(diff, result)
+	}
+
+	writeStart := time.Now()
+	_, writeSpan := t.tracing.start("write")
+	if checkpoints != nil {
+		err = t.applyDiffWithCheckpoints(diff, source.entries, checkpoints)
+	} else {
+		err = t.applyDiffInTransaction(diff)
+	}
+	result.WriteDuration = time.Since(writeStart)
+	endSpan(writeSpan, err)
+	if err != nil {
+		result.Error = &SyncError{Target: t.config, Err: err}
+		return result
+	}
+
+	result.Synced = true
+	result.TargetRowCount += result.NumInserts - result.NumDeletes
+	return result
+}
+
+// applyDiffInTransaction applies the diff in a single transaction (the target's, if it has one),
+// which is rolled back if any of its statements fail, so a failed sync doesn't leave the target
+// half-synced
+func (t table) applyDiffInTransaction(diff tableDiff) error {
+	if t.tx == nil {
+		tx, err := t.writer().BeginTxx(t.context(), nil)
+		if err != nil {
+			return err
+		}
+
+		defer tx.Rollback() // This is a no-op once the transaction is committed
+		t.tx = tx
+	}
+
+	if err := t.applyDiff(diff); err != nil {
+		if rollbackErr := t.tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (and failed to roll back: %s)", err, rollbackErr)
+		}
+		return fmt.Errorf("%w (rolled back)", err)
+	}
+
+	return t.tx.Commit()
+}
+
+// applyDiffAndRollback applies the diff in a transaction (the target's, if it has one), and then
+// rolls it back, so that the target's constraints are checked without changing it
+func (t table) applyDiffAndRollback(diff tableDiff, result SyncResult) SyncResult {
+	writeStart := time.Now()
+	_, writeSpan := t.tracing.start("write", attribute.Bool("sync.rolled_back", true))
+
+	if t.tx == nil {
+		tx, err := t.writer().BeginTxx(t.context(), nil)
+		if err != nil {
+			endSpan(writeSpan, err)
+			result.Error = err
+			return result
+		}
+		t.tx = tx
+	}
+
+	err := t.applyDiff(diff)
+	if rollbackErr := t.tx.Rollback(); err == nil {
+		err = rollbackErr
+	}
+	result.WriteDuration = time.Since(writeStart)
+	endSpan(writeSpan, err)
+	if err != nil {
+		result.Error = &SyncError{Target: t.config, Err: err}
+		return result
+	}
+
+	result.RolledBack = true
+	return result
+}
+
+// syncNoop "syncs" a noop target without executing any SQL. In "in-sync" mode, the target always
+// matches the source. In "empty" mode, the target always needs every source row to be inserted
+func (t table) syncNoop(job JobConfig, source tableData) SyncResult {
+	result := SyncResult{
+		Target:         t.config,
+		TargetChecksum: source.checksum,
+		TargetRowCount: len(source.entries),
+	}
+
+	if t.config.DSN == noopModeEmpty {
+		result.TargetRowCount = 0
+
+		var err error
+		result.TargetChecksum, err = t.checksum([][]any{})
+		result.Error = err
+		result.NumInserts = len(source.entries)
+		result.Synced = result.NumInserts > 0 && !job.DryRun
+
+		if result.Synced && job.Approve != nil && !job.Approve(result) {
+			result.Synced = false
+			result.Skipped = true
+		}
+
+		// There is nothing to roll back, but the result should look like any other target's
+		if result.Synced && job.RollbackDryRun {
+			result.Synced = false
+			result.RolledBack = true
+		}
+
+		if result.Synced {
+			result.TargetRowCount = result.NumInserts
+		}
+	}
+
+	return result
+}
+
+// Phase is a set of the kinds of statements that a sync executes (see JobConfig.Phases)
+type Phase int
+
+const (
+	PhaseInserts Phase = 1 << iota
+	PhaseUpdates
+	PhaseDeletes
+
+	AllPhases = PhaseInserts | PhaseUpdates | PhaseDeletes
+)
+
+// has returns whether the set includes the phase. An empty set includes every phase
+func (p Phase) has(phase Phase) bool {
+	return p == 0 || p&phase != 0
+}
+
+// all returns whether the set includes every phase
+func (p Phase) all() bool {
+	return p == 0 || p&AllPhases == AllPhases
+}
+
+// tableDiff contains the statements needed to bring a target in sync with the source
+type tableDiff struct {
+	inserts []sq.InsertBuilder
+	updates []sq.UpdateBuilder
+	deletes []sq.DeleteBuilder
+
+	// insertPositions and updatePositions are the indices of the source rows that each INSERT and
+	// UPDATE was built from
+	insertPositions []int
+	updatePositions []int
+
+	// insertRows are the source rows that each INSERT inserts, so they can be batched together
+	insertRows [][]any
+
+	// updateChanges are the key and changed columns of the row that each UPDATE updates
+	updateChanges []RowUpdate
+
+	// updateKeys and deleteKeys are the keys of the rows that each UPDATE and DELETE changes
+	updateKeys []rowKey
+	deleteKeys []rowKey
+
+	// deleteRows are the target rows that each DELETE deletes, if they're recorded (see
+	// diffOptions)
+	deleteRows [][]any
+}
+
+// diffOptions configures which statements diff builds
+type diffOptions struct {
+	skip        int  // The number of (ordered) source rows that are already in sync
+	skipInserts bool // Don't insert source rows that are missing from the target
+	skipUpdates bool // Don't update target rows that differ from the source
+	recordRows  bool // Record the deleted rows (see tableDiff.deleteRows)
+	skipDeletes bool // Don't delete target rows that are missing from the source
+}
+
+// diff compares the source rows to the target rows and determines which statements need to be
+// executed against the target
+func (t table) diff(source, target tableData, opts diffOptions) tableDiff {
+	tableName := t.config.Table
+	columns := t.quoteColumns(t.columns)
+	primaryKeys := t.quoteColumns(t.primaryKeys)
+
+	var diff tableDiff
+
+	pkSet := map[string]struct{}{}
+	for _, pk := range t.primaryKeys {
+		pkSet[pk] = struct{}{}
+	}
+
+	// Iterate over source rows (in primary key order) and perform INSERTs or UPDATEs as needed
+	for i := opts.skip; i < len(source.entries); i++ {
+		val := source.entries[i]
+		key := t.keyOf(val)
+		targetVal, ok := target.get(key)
+
+		// If the key doesn't exist in the target, then we need to INSERT
+		if !ok {
+			if opts.skipInserts {
+				continue
+			}
+
+			insert := t.insert().Values(val...)
+			diff.inserts = append(diff.inserts, insert)
+			diff.insertPositions = append(diff.insertPositions, i)
+			diff.insertRows = append(diff.insertRows, val)
+			continue
+		}
+
+		if opts.skipUpdates {
+			continue
+		}
+
+		// If the key exists in the target, then we need to check if there is a diff
+		changed := t.comparison.changedColumns(t.columns, val, targetVal)
+		if len(changed) == 0 {
+			continue // No diff, so we skip this row
+		}
+
+		// There is a diff, perform an UPDATE
+		update := sq.
+			Update(tableName).
+			Where(key.whereClause(primaryKeys))
+
+		var hasUpdate bool
+		for i, col := range t.columns {
+			if _, ok := pkSet[col]; ok {
+				continue // Skip updating primary key columns
+			}
+
+			if !t.comparison.updates(col) {
+				continue // e.g. let the database update the column itself
+			}
+
+			update = update.Set(columns[i], val[i])
+			hasUpdate = true
+		}
+
+		if hasUpdate {
+			diff.updates = append(diff.updates, update)
+			diff.updatePositions = append(diff.updatePositions, i)
+			sourceValues, targetValues := t.changedValues(changed, val, targetVal)
+			diff.updateChanges = append(diff.updateChanges, RowUpdate{
+				KeyColumns: t.primaryKeys,
+				Key:        t.keyValues(val),
+				Columns:    changed,
+				Source:     sourceValues,
+				Target:     targetValues,
+			})
+			diff.updateKeys = append(diff.updateKeys, t.rowKeyOf(val))
+		}
+	}
+
+	if opts.skipDeletes {
+		return diff
+	}
+
+	// Iterate over target rows (in primary key order) and DELETE any that weren't in the source
+	target.each(func(val []any) {
+		key := t.keyOf(val)
+		if _, ok := source.entryMap[key]; ok {
+			return
+		}
+
+		delete := sq.
+			Delete(tableName).
+			Where(key.whereClause(primaryKeys))
+
+		diff.deletes = append(diff.deletes, delete)
+		diff.deleteKeys = append(diff.deleteKeys, t.rowKeyOf(val))
+		if opts.recordRows {
+			diff.deleteRows = append(diff.deleteRows, val)
+		}
+	})
+
+	return diff
+}
+
+// applyDiff executes the statements in the diff against the target (DELETEs -> UPDATEs -> INSERTs)
+func (t table) applyDiff(diff tableDiff) error {
+	for i, delete := range diff.deletes {
+		if _, err := t.exec(delete); err != nil {
+			return fmt.Errorf("failed to delete row %s: %w", diff.deleteKeys[i], err)
+		}
+	}
+
+	for i, update := range diff.updates {
+		if _, err := t.exec(update); err != nil {
+			return fmt.Errorf("failed to update row %s: %w", diff.updateKeys[i], err)
+		}
+	}
+
+	batchSize := t.insertBatchSize()
+	for i, insert := range t.batchInserts(diff.insertRows) {
+		if _, err := t.exec(insert); err != nil {
+			batch := diff.insertRows[i*batchSize : min((i+1)*batchSize, len(diff.insertRows))]
+			return t.insertError(batch, err)
+		}
+	}
+
+	return nil
+}
+
+// planDiff returns the statements that applyDiff would execute against the target, in the same
+// order
+func (t table) planDiff(diff tableDiff) ([]Statement, error) {
+	var statements []sq.Sqlizer
+	for _, delete := range diff.deletes {
+		statements = append(statements, delete)
+	}
+	for _, update := range diff.updates {
+		statements = append(statements, update)
+	}
+	for _, insert := range t.batchInserts(diff.insertRows) {
+		statements = append(statements, insert)
+	}
+
+	return t.plan(statements)
+}
+
+// plan renders the statements the way that exec would execute them against the target
+func (t table) plan(statements []sq.Sqlizer) ([]Statement, error) {
+	planned := make([]Statement, len(statements))
+	for i, statement := range statements {
+		if t.config.StatementMode == statementModeDirect {
+			rendered, err := renderStatement(t.config.Driver, statement)
+			if err != nil {
+				return nil, err
+			}
+
+			planned[i] = Statement{SQL: rendered}
+			continue
+		}
+
+		sql, args, err := statement.ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		planned[i] = Statement{SQL: sql, Args: args}
+	}
+
+	return planned, nil
+}
+
+// insertError describes a failed INSERT of the given rows by their keys
+func (t table) insertError(rows [][]any, err error) error {
+	if len(rows) == 1 {
+		return fmt.Errorf("failed to insert row %s: %w", t.rowKeyOf(rows[0]), err)
+	}
+
+	return fmt.Errorf(
+		"failed to insert %d rows (%s to %s): %w",
+		len(rows), t.rowKeyOf(rows[0]), t.rowKeyOf(rows[len(rows)-1]), err,
+	)
+}
+
+// maxPlaceholders is the most placeholders that a single statement can have, by driver
+var maxPlaceholders = map[string]int{
+	"mysql":   65535,
+	"sqlite3": 32766,
 }
 
-func (job JobConfig) syncTargets() (string, []SyncResult, error) {
-	primaryKeyIndices := job.getPrimaryKeyIndices()
+// defaultBatchSize is the number of rows that are inserted per INSERT statement, if the job doesn't
+// set its own BatchSize
+const defaultBatchSize = 500
 
-	source := table{
-		config:            job.Source,
-		primaryKeys:       job.PrimaryKeys,
-		primaryKeyIndices: primaryKeyIndices,
-		columns:           job.Columns,
+// batchSize returns the number of rows to insert per statement (see JobConfig.BatchSize)
+func (job JobConfig) batchSize() int {
+	if job.BatchSize > 0 {
+		return job.BatchSize
 	}
 
-	// Connect to the source
-	if err := source.connect(); err != nil {
-		return "", nil, err
+	return defaultBatchSize
+}
+
+// insertBatchSize is the number of rows to insert per statement. This is the table's batch size,
+// reduced (if needed) so that a statement never exceeds the driver's placeholder limit
+func (t table) insertBatchSize() int {
+	size := max(t.batchSize, 1)
+
+	if limit, ok := maxPlaceholders[t.config.Driver]; ok && len(t.columns) > 0 {
+		size = min(size, max(limit/len(t.columns), 1))
+	}
+
+	return size
+}
+
+// insert builds an INSERT of the table's columns, without any rows. If the table ignores
+// conflicting inserts, a row whose primary key is already in the table is skipped instead of
+// failing the statement: with INSERT IGNORE for mysql, and ON CONFLICT DO NOTHING otherwise
+func (t table) insert() sq.InsertBuilder {
+	insert := sq.Insert(t.config.Table).Columns(t.quoteColumns(t.columns)...)
+	if !t.insertIgnore {
+		return insert
+	}
+
+	if t.config.Driver == "mysql" {
+		return insert.Options("IGNORE")
 	}
 
-	targets := make([]table, len(job.Targets))
-	for i, target := range job.Targets {
-		targets[i] = table{
-			config:            target,
-			primaryKeys:       job.PrimaryKeys,
-			primaryKeyIndices: primaryKeyIndices,
-			columns:           job.Columns,
+	primaryKeys := strings.Join(t.quoteColumns(t.primaryKeys), ", ")
+	return insert.Suffix(fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", primaryKeys))
+}
+
+// batchInserts builds the INSERTs for the given rows, with up to insertBatchSize rows each
+func (t table) batchInserts(rows [][]any) []sq.InsertBuilder {
+	batchSize := t.insertBatchSize()
+
+	var inserts []sq.InsertBuilder
+	for start := 0; start < len(rows); start += batchSize {
+		insert := t.insert()
+		for _, row := range rows[start:min(start+batchSize, len(rows))] {
+			insert = insert.Values(row...)
 		}
+		inserts = append(inserts, insert)
 	}
 
-	// Get all rows from the source table and put them in a map by their primary key
-	sourceEntries, sourceMap, err := source.getEntries()
+	return inserts
+}
+
+// getData gets all rows from the table and computes their checksum
+func (t table) getData() (tableData, error) {
+	entries, entryMap, err := t.getEntries()
 	if err != nil {
-		return "", nil, err
+		return tableData{}, err
 	}
 
-	// Close the source connection pool
-	source.Close()
-
-	sourceChecksum, err := checksumData(sourceEntries)
+	checksum, err := t.checksum(entries)
 	if err != nil {
-		return "", nil, err
+		return tableData{}, err
 	}
 
-	var wg sync.WaitGroup
-	resultChan := make(chan SyncResult, len(targets))
+	return tableData{checksum: checksum, entries: entries, entryMap: entryMap}, nil
+}
+
+// selectQuery builds the query used to read the table's rows. It only ever selects the configured
+// columns (in order), never `SELECT *`, so that wide tables don't load columns we don't sync
+func (t table) selectQuery() (sq.SelectBuilder, error) {
+	if len(t.columns) == 0 {
+		return sq.SelectBuilder{}, fmt.Errorf("no columns to select")
+	}
 
-	for _, target := range targets {
-		wg.Add(1)
-		go func(target table) {
-			defer wg.Done()
+	for _, col := range t.columns {
+		if col == "*" {
+			return sq.SelectBuilder{}, fmt.Errorf("cannot select wildcard column")
+		}
+	}
 
-			// Connect to each target
-			if err := target.connect(); err != nil {
-				resultChan <- SyncResult{
-					Target: target.config,
-					Error:  err,
-				}
-				return
-			}
+	// An index hint goes right after the table name, e.g. `FROM users FORCE INDEX (PRIMARY)`
+	from := t.config.Table
+	if t.indexHint != "" {
+		from += " " + t.indexHint
+	}
 
-			checksum, synced, err := target.syncTarget(sourceChecksum, sourceMap)
-			target.Close() // Close the target's connection pool
+	query := sq.
+		Select(t.quoteColumns(t.columns)...).
+		From(from).
+		OrderBy(t.quoteColumns(t.primaryKeys)...)
 
-			resultChan <- SyncResult{
-				Target:         target.config,
-				TargetChecksum: checksum,
-				Synced:         synced,
-				Error:          err,
-			}
-		}(target)
+	if t.where != nil {
+		query = query.Where(t.where)
 	}
 
-	wg.Wait()         // Wait for all goroutines to finish
-	close(resultChan) // Close the channel to signal that all results have been sent
+	// Lock the rows that are read, so they can't change until the transaction commits. sqlite
+	// doesn't support this (or need it), since a transaction that reads a table locks all of it
+	if t.tx != nil && t.config.Driver == "mysql" {
+		query = query.Suffix("FOR UPDATE")
+	}
+
+	return query, nil
+}
+
+func (t table) getEntries() ([][]any, map[primaryKeyTuple][]any, error) {
+	entryList := [][]any{}
+	entryMap := map[primaryKeyTuple][]any{}
+
+	var estimatedBytes int64
 
-	// Collect the results from the channel
-	results := make([]SyncResult, 0, len(targets))
-	for result := range resultChan {
-		results = append(results, result)
+	err := t.scanRows(func(cols []any) error {
+		// Bail out as soon as the table is too large, rather than after loading all of it
+		if t.maxMemoryBytes > 0 {
+			estimatedBytes += estimateRowSize(cols)
+			if estimatedBytes > t.maxMemoryBytes {
+				return fmt.Errorf(
+					"table '%s' exceeds maxMemoryBytes (estimated more than %d bytes); "+
+						"consider raising the limit or syncing the table in smaller pieces",
+					t.config.Table,
+					t.maxMemoryBytes,
+				)
+			}
+		}
+
+		entryList = append(entryList, cols)
+		entryMap[t.keyOf(cols)] = cols
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return sourceChecksum, results, nil
+	return entryList, entryMap, nil
 }
 
-func (t table) syncTarget(
-	sourceChecksum string,
-	sourceMap map[primaryKeyTuple][]any,
-) (string, bool, error) {
-	targetEntries, targetMap, err := t.getEntries()
+// scanRows reads the table's rows (in primary key order), and calls fn with each one, after its
+// values are coerced and normalized. It stops at the first error
+func (t table) scanRows(fn func(row []any) error) error {
+	fetchAll, err := t.selectQuery()
 	if err != nil {
-		return "", false, err
+		return err
 	}
 
-	targetChecksum, err := checksumData(targetEntries)
+	sql, args, err := fetchAll.ToSql()
 	if err != nil {
-		return "", false, err
+		return err
 	}
 
-	// If the checksums match, then the data is already in sync
-	if sourceChecksum == targetChecksum {
-		return targetChecksum, false, nil
+	rows, err := t.reader().QueryxContext(t.context(), sql, args...)
+	if err != nil {
+		return &SchemaError{Target: t.config, Err: err}
 	}
 
-	tableName := t.config.Table
+	defer rows.Close()
+	t.samplePool() // The rows' connection is in use until they're closed
 
-	var inserts []sq.InsertBuilder
-	var updates []sq.UpdateBuilder
-	var deletes []sq.DeleteBuilder
-
-	// Iterate over source rows and perform INSERTs or UPDATEs as needed
-	for key, val := range sourceMap {
-		// If the key doesn't exist in targetMap, then we need to INSERT
-		if _, ok := targetMap[key]; !ok {
-			insert := sq.Insert(tableName).Columns(t.columns...).Values(val...)
-			inserts = append(inserts, insert)
-		} else {
-			// If the key exists in targetMap, then we need to check if there is a diff
+	return t.scanEach(rows, fn)
+}
 
-			// Remove the key from the targetMap (to keep track of which rows we need to delete)
-			delete(targetMap, key)
+// scanEach calls fn with each of the query's rows (see scanRows). The query must return exactly
+// the table's columns, since rows are indexed by their position (e.g. to find their primary keys)
+func (t table) scanEach(rows *sqlx.Rows, fn func(row []any) error) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	if len(columns) != len(t.columns) {
+		return fmt.Errorf(
+			"table '%s': query returned %d columns %v, but expected %d columns %v",
+			t.config.Table, len(columns), columns, len(t.columns), t.columns,
+		)
+	}
 
-			if reflect.DeepEqual(val, targetMap[key]) {
-				continue // No diff, so we skip this row
+	// Every row is the same length as the query's columns, so if each primary key is in them, each
+	// row has its primary keys. Otherwise, finding a row's key (see keyOf) would panic
+	for i, idx := range t.primaryKeyIndices {
+		if idx < 0 || idx >= len(columns) {
+			key := fmt.Sprint(idx)
+			if i < len(t.primaryKeys) {
+				key = t.primaryKeys[i]
 			}
 
-			// There is a diff, perform an UPDATE
-			update := sq.
-				Update(tableName).
-				Where(key.whereClause(t.primaryKeys, t.primaryKeyIndices))
+			return fmt.Errorf(
+				"table '%s': primary key '%s' is column %d, but the query only returned %d columns %v",
+				t.config.Table, key, idx, len(columns), columns,
+			)
+		}
+	}
 
-			pkSet := map[string]struct{}{}
-			for _, pk := range t.primaryKeys {
-				pkSet[pk] = struct{}{}
-			}
+	readRow, err := t.readRows()
+	if err != nil {
+		return err
+	}
 
-			var hasUpdate bool
-			for i, col := range t.columns {
-				if _, ok := pkSet[col]; ok {
-					continue // Skip updating primary key columns
-				}
+	scan := rows.SliceScan
+	if t.typedScan {
+		if scan, err = t.typedScanner(rows); err != nil {
+			return err
+		}
+	}
+
+	for rows.Next() {
+		cols, err := scan()
+		if err != nil {
+			return err
+		}
+
+		if err := readRow(cols); err != nil {
+			return err
+		}
+
+		if err := t.coerceRow(cols); err != nil {
+			return fmt.Errorf("table '%s': %w", t.config.Table, err)
+		}
+
+		t.convertEmpty(cols)
+		normalizeTimes(cols)
+
+		if err := fn(cols); err != nil {
+			return err
+		}
+	}
 
-				update = update.Set(col, val[i])
-				hasUpdate = true
+	return rows.Err()
+}
+
+// readTarget reads the target's rows. If the target has more rows
+// than its spill threshold, its rows are spilled to disk (see spilledRows) as they are read,
+// instead of being held in memory. The caller must close the spilled rows, if there are any
+func (t table) readTarget() (tableData, error) {
+	if t.spillThresholdRows <= 0 {
+		entries, entryMap, err := t.getEntries()
+		if err != nil {
+			return tableData{}, err
+		}
+
+		return tableData{entries: entries, entryMap: entryMap}, nil
+	}
+
+	entries := [][]any{}
+	var spilled *spilledRows
+
+	err := t.scanRows(func(row []any) error {
+		if spilled == nil && len(entries) < t.spillThresholdRows {
+			entries = append(entries, row)
+			return nil
+		}
+
+		// The threshold was reached, so move the rows that were already read to disk
+		if spilled == nil {
+			var err error
+			if spilled, err = newSpilledRows(t.spillDir); err != nil {
+				return err
 			}
 
-			if hasUpdate {
-				updates = append(updates, update)
+			for _, entry := range entries {
+				if err := spilled.add(t.keyOf(entry), entry); err != nil {
+					return err
+				}
 			}
+			entries = nil
+		}
+
+		return spilled.add(t.keyOf(row), row)
+	})
+	if err == nil && spilled != nil {
+		err = spilled.finish()
+	}
+	if err != nil {
+		if spilled != nil {
+			spilled.close()
 		}
+		return tableData{}, err
 	}
 
-	// Iterate over target rows and DELETE any that weren't in the source
-	for key := range targetMap {
-		delete := sq.
-			Delete(tableName).
-			Where(key.whereClause(t.primaryKeys, t.primaryKeyIndices))
+	if spilled != nil {
+		return tableData{spilled: spilled}, nil
+	}
 
-		deletes = append(deletes, delete)
+	entryMap := make(map[primaryKeyTuple][]any, len(entries))
+	for _, row := range entries {
+		entryMap[t.keyOf(row)] = row
 	}
 
-	// Actually execute the statements (DELETEs -> UPDATEs -> INSERTs)
-	for _, delete := range deletes {
-		if _, err := delete.RunWith(t.DB).Exec(); err != nil {
-			return "", false, err
+	return tableData{entries: entries, entryMap: entryMap}, nil
+}
+
+// keyOf builds the primary key tuple for a row
+func (t table) keyOf(row []any) primaryKeyTuple {
+	pkTuple := primaryKeyTuple{}
+	for i, idx := range t.primaryKeyIndices {
+		val := row[idx]
+
+		// Convert []byte to string (because []byte is unhashable and can't be in a map key)
+		if _, ok := val.([]byte); ok {
+			val = string(val.([]byte))
+		}
+
+		switch i {
+		case 0:
+			pkTuple.First = val
+		case 1:
+			pkTuple.Second = val
+		case 2:
+			pkTuple.Third = val
 		}
 	}
 
-	for _, update := range updates {
-		if _, err := update.RunWith(t.DB).Exec(); err != nil {
-			return "", false, err
+	return pkTuple
+}
+
+// sortByKey orders the rows (in place) by the table's primary keys
+func (t table) sortByKey(rows [][]any) {
+	slices.SortStableFunc(rows, func(a, b []any) int {
+		for _, idx := range t.primaryKeyIndices {
+			if c := compareValues(a[idx], b[idx]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+}
+
+// compareValues orders two values (e.g. primary key values). NULLs come first, then numbers, then
+// strings (and other values, by their string representation)
+func compareValues(a, b any) int {
+	rank := func(v any) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case int64, uint64, float64:
+			return 1
+		default:
+			return 2
 		}
 	}
 
-	for _, insert := range inserts {
-		if _, err := insert.RunWith(t.DB).Exec(); err != nil {
-			return "", false, err
+	if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+		return c
+	}
+
+	switch rank(a) {
+	case 1:
+		return compareNumbers(a, b)
+	case 2:
+		return cmp.Compare(stringOf(a), stringOf(b))
+	}
+
+	return 0
+}
+
+// stringOf converts a value to a string. Unlike fmt.Sprint, []byte is converted as text
+func stringOf(v any) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// compareNumbers compares two numbers. Integers are compared exactly, since a float64 can't
+// represent every int64 or uint64 (e.g. a BIGINT UNSIGNED that's too big for an int64)
+func compareNumbers(a, b any) int {
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return cmp.Compare(x, y)
+		case uint64:
+			if x < 0 {
+				return -1
+			}
+			return cmp.Compare(uint64(x), y)
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint64:
+			return cmp.Compare(x, y)
+		case int64:
+			return -compareNumbers(y, x)
 		}
 	}
 
-	return targetChecksum, true, nil
+	return cmp.Compare(numberAsFloat(a), numberAsFloat(b))
 }
 
-func (t table) getEntries() ([][]any, map[primaryKeyTuple][]any, error) {
-	fetchAll := sq.
-		Select(t.columns...).
-		From(t.config.Table).
-		OrderBy(t.primaryKeys...)
+func numberAsFloat(v any) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	}
+	return v.(float64)
+}
 
-	sql, args, err := fetchAll.ToSql()
+// rekey keys (and orders) the source's rows by the table's primary keys, for a target whose
+// primary keys differ from the job's. The rows must be unique by the target's primary keys
+func (t table) rekey(source tableData) (tableData, error) {
+	entries := slices.Clone(source.entries)
+	t.sortByKey(entries)
+
+	entryMap := make(map[primaryKeyTuple][]any, len(entries))
+	for _, row := range entries {
+		key := t.keyOf(row)
+		if _, ok := entryMap[key]; ok {
+			return tableData{}, fmt.Errorf(
+				"source has multiple rows with the target's primary key %s", t.rowKeyOf(row),
+			)
+		}
+		entryMap[key] = row
+	}
+
+	checksum, err := t.checksum(entries)
 	if err != nil {
-		return nil, nil, err
+		return tableData{}, err
 	}
 
-	rows, err := t.Queryx(sql, args...)
+	return tableData{checksum: checksum, entries: entries, entryMap: entryMap}, nil
+}
+
+// skipMissingColumns narrows the table to the job's columns that it actually has, and projects the
+// source's rows onto them. It returns the projected source and the columns that were skipped. A
+// missing primary key can't be skipped, since rows are matched by it
+func (t *table) skipMissingColumns(job JobConfig, source tableData) (tableData, []string, error) {
+	existing, err := t.columnNames()
 	if err != nil {
-		return nil, nil, err
+		return tableData{}, nil, err
 	}
 
-	defer rows.Close()
+	var kept, skipped []string
+	for _, col := range job.Columns {
+		found := slices.ContainsFunc(existing, func(name string) bool {
+			return strings.EqualFold(name, col)
+		})
 
-	entryList := [][]any{}
-	entryMap := map[primaryKeyTuple][]any{}
+		if found {
+			kept = append(kept, col)
+		} else if slices.Contains(job.PrimaryKeys, col) || slices.Contains(t.primaryKeys, col) {
+			return tableData{}, nil, &SchemaError{
+				Target: t.config,
+				Err:    fmt.Errorf("table is missing primary key column '%s'", col),
+			}
+		} else {
+			skipped = append(skipped, col)
+		}
+	}
 
-	for rows.Next() {
-		cols, err := rows.SliceScan()
-		if err != nil {
-			return nil, nil, err
+	if len(skipped) == 0 {
+		return source, nil, nil
+	}
+
+	// Find where each of the remaining columns is in the source's rows
+	allColumns := job.syncColumns()
+
+	narrowed := job
+	narrowed.Columns = kept
+
+	t.columns = narrowed.syncColumns()
+	t.primaryKeyIndices = narrowed.getPrimaryKeyIndices(t.primaryKeys)
+	t.checksumIndices = narrowed.checksumIndices(t.primaryKeys)
+
+	indices := make([]int, len(t.columns))
+	for i, col := range t.columns {
+		indices[i] = slices.Index(allColumns, col)
+	}
+
+	entries := make([][]any, len(source.entries))
+	entryMap := make(map[primaryKeyTuple][]any, len(source.entries))
+	for i, row := range source.entries {
+		entries[i] = make([]any, len(indices))
+		for j, idx := range indices {
+			entries[i][j] = row[idx]
 		}
+		entryMap[t.keyOf(entries[i])] = entries[i]
+	}
 
-		entryList = append(entryList, cols)
+	checksum, err := t.checksum(entries)
+	if err != nil {
+		return tableData{}, nil, err
+	}
 
-		pkTuple := primaryKeyTuple{}
-		for i, idx := range t.primaryKeyIndices {
-			val := cols[idx]
+	return tableData{checksum: checksum, entries: entries, entryMap: entryMap}, skipped, nil
+}
 
-			// Convert []byte to string (because []byte is unhashable and can't be in a map key)
-			if _, ok := val.([]byte); ok {
-				val = string(val.([]byte))
-			}
+// keyValues returns the row's primary key values, in the same order as the primary keys. Like in
+// keyOf, []byte values are converted to strings
+func (t table) keyValues(row []any) []any {
+	values := make([]any, len(t.primaryKeyIndices))
+	for i, idx := range t.primaryKeyIndices {
+		values[i] = row[idx]
+		if b, ok := values[i].([]byte); ok {
+			values[i] = string(b)
+		}
+	}
 
-			switch i {
-			case 0:
-				pkTuple.First = val
-			case 1:
-				pkTuple.Second = val
-			case 2:
-				pkTuple.Third = val
+	return values
+}
+
+// rowsValues describes each of the rows that are inserted or deleted (see RowValues)
+func (t table) rowsValues(rows [][]any) []RowValues {
+	var described []RowValues
+	for _, row := range rows {
+		values := make([]any, len(row))
+		for i, val := range row {
+			if b, ok := val.([]byte); ok {
+				val = string(b)
 			}
+			values[i] = val
 		}
 
-		entryMap[pkTuple] = cols
+		described = append(described, RowValues{
+			KeyColumns: t.primaryKeys,
+			Key:        t.keyValues(row),
+			Columns:    t.columns,
+			Values:     values,
+		})
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, nil, err
+	return described
+}
+
+// changedValues returns the values of the changed columns in the source row and in the target row
+// (see RowUpdate)
+func (t table) changedValues(changed []string, source, target []any) ([]any, []any) {
+	sourceValues := make([]any, len(changed))
+	targetValues := make([]any, len(changed))
+	for i, column := range changed {
+		idx := slices.Index(t.columns, column)
+		sourceValues[i] = source[idx]
+		targetValues[i] = target[idx]
+
+		if b, ok := sourceValues[i].([]byte); ok {
+			sourceValues[i] = string(b)
+		}
+		if b, ok := targetValues[i].([]byte); ok {
+			targetValues[i] = string(b)
+		}
 	}
 
-	return entryList, entryMap, nil
+	return sourceValues, targetValues
 }
 
-func checksumData(data [][]any) (string, error) {
-	// Serialize the data to JSON
-	jsonData, err := json.Marshal(data)
+// checksum computes the checksum of the table's rows, after normalizing them for comparison. If
+// the table has checksum columns, only those columns are checksummed
+func (t table) checksum(entries [][]any) (string, error) {
+	if entries == nil {
+		return checksumData(nil)
+	}
+
+	columns := t.checksumColumns()
+	c := newChecksummer()
+	for _, row := range entries {
+		if err := c.add(t.checksumRow(columns, row)); err != nil {
+			return "", err
+		}
+	}
+
+	return c.sum(), nil
+}
+
+// checksumData computes the checksum of the table's rows (like checksum), whether they are in
+// memory or spilled to disk
+func (t table) checksumData(data tableData) (string, error) {
+	if data.spilled == nil {
+		return t.checksum(data.entries)
+	}
+
+	columns := t.checksumColumns()
+	c := newChecksummer()
+
+	var err error
+	data.spilled.each(func(row []any) {
+		if err == nil {
+			err = c.add(t.checksumRow(columns, row))
+		}
+	})
+	if err == nil {
+		err = data.spilled.Err()
+	}
 	if err != nil {
 		return "", err
 	}
 
-	// Compute the MD5 checksum of the JSON data
-	hash := md5.New()
-	if _, err := hash.Write(jsonData); err != nil {
-		return "", err
+	return c.sum(), nil
+}
+
+// checksumColumns returns the names of the columns that are checksummed
+func (t table) checksumColumns() []string {
+	if t.checksumIndices == nil {
+		return t.columns
+	}
+
+	columns := make([]string, len(t.checksumIndices))
+	for i, idx := range t.checksumIndices {
+		columns[i] = t.columns[idx]
+	}
+
+	return columns
+}
+
+// checksumRow returns the values of the row's checksummed columns, normalized for comparison
+func (t table) checksumRow(columns []string, row []any) []any {
+	if t.checksumIndices != nil {
+		projected := make([]any, len(t.checksumIndices))
+		for i, idx := range t.checksumIndices {
+			projected[i] = row[idx]
+		}
+		row = projected
 	}
 
-	// Convert the checksum to a hexadecimal string
-	checksum := hex.EncodeToString(hash.Sum(nil))
-	return checksum, nil
+	return t.comparison.normalizeRow(columns, row)
 }
 
-func (job JobConfig) getPrimaryKeyIndices() []int {
+// checksumIndices returns the indices of the columns that are checksummed: the job's checksum
+// columns (or every column, if there aren't any) other than its auto-update columns and the
+// columns that aren't data columns, plus the given primary keys (in case they aren't checksum
+// columns, e.g. a target's own primary keys or sqlite's implicit rowid). It returns nil if every
+// column is checksummed
+func (job JobConfig) checksumIndices(primaryKeys []string) []int {
+	if len(job.ChecksumColumns) == 0 && len(job.AutoUpdateColumns) == 0 &&
+		len(job.DataColumns) == 0 {
+		return nil
+	}
+
+	var indices []int
+	for i, col := range job.syncColumns() {
+		checksummed := len(job.ChecksumColumns) == 0 || slices.Contains(job.ChecksumColumns, col)
+		if slices.Contains(job.AutoUpdateColumns, col) {
+			checksummed = false
+		}
+		if len(job.DataColumns) > 0 && !slices.Contains(job.DataColumns, col) {
+			checksummed = false
+		}
+
+		if checksummed || slices.Contains(primaryKeys, col) {
+			indices = append(indices, i)
+		}
+	}
+
+	return indices
+}
+
+// checksumData computes the MD5 checksum of the data's JSON encoding. Rows are encoded and hashed
+// one at a time, so the JSON of the whole table is never held in memory at once
+func checksumData(data [][]any) (string, error) {
+	// Match the encoding of a nil slice
+	if data == nil {
+		hash := md5.Sum([]byte("null"))
+		return hex.EncodeToString(hash[:]), nil
+	}
+
+	c := newChecksummer()
+	for _, row := range data {
+		if err := c.add(row); err != nil {
+			return "", err
+		}
+	}
+
+	return c.sum(), nil
+}
+
+// checksummer computes the checksum of a (non-nil) list of rows, one row at a time
+type checksummer struct {
+	hash    hash.Hash
+	numRows int
+}
+
+func newChecksummer() *checksummer {
+	c := &checksummer{hash: md5.New()}
+	c.hash.Write([]byte("["))
+	return c
+}
+
+// add hashes the next row
+func (c *checksummer) add(row []any) error {
+	// Serialize the row to JSON
+	jsonRow, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	if c.numRows != 0 {
+		c.hash.Write([]byte(","))
+	}
+
+	c.hash.Write(jsonRow)
+	c.numRows++
+	return nil
+}
+
+// sum returns the checksum of the rows that were added, as a hexadecimal string
+func (c *checksummer) sum() string {
+	c.hash.Write([]byte("]"))
+	return hex.EncodeToString(c.hash.Sum(nil))
+}
+
+// rowIDColumn is sqlite's implicit row identifier, which can be used as a primary key for tables
+// that don't have one
+const rowIDColumn = "rowid"
+
+// usesRowID returns whether the job matches rows by sqlite's implicit rowid
+func (job JobConfig) usesRowID() bool {
+	return len(job.PrimaryKeys) == 1 &&
+		job.PrimaryKeys[0] == rowIDColumn &&
+		!slices.Contains(job.Columns, rowIDColumn)
+}
+
+// syncColumns returns the columns that are read and written during a sync. This is the job's
+// columns, plus the rowid (first) if the job uses it as its primary key
+func (job JobConfig) syncColumns() []string {
+	if job.usesRowID() {
+		return append([]string{rowIDColumn}, job.Columns...)
+	}
+
+	return job.Columns
+}
+
+func (job JobConfig) getPrimaryKeyIndices(primaryKeys []string) []int {
 	// Create a map of column names to their index in the columns slice
 	columnIndices := map[string]int{}
-	for i, col := range job.Columns {
+	for i, col := range job.syncColumns() {
 		columnIndices[col] = i
 	}
 
 	// Determine the indices of the primary keys in the columns slice
 	var primaryKeyIndices []int
-	for _, pk := range job.PrimaryKeys {
+	for _, pk := range primaryKeys {
 		if _, ok := columnIndices[pk]; ok {
 			primaryKeyIndices = append(primaryKeyIndices, columnIndices[pk])
 		}
@@ -290,16 +1848,51 @@ func (job JobConfig) getPrimaryKeyIndices() []int {
 	return primaryKeyIndices
 }
 
-// We are not allowed to have a slice as a map key, so we use a struct instead
+// checkPrimaryKeyIndices checks that the i-th primary key index points at the i-th primary key,
+// for the job's primary keys and any target's own primary keys. Primary key tuples (see keyOf) and
+// WHERE clauses (see whereClause) both rely on this, so that rows are matched correctly no matter
+// what order the primary keys are listed in
+func (job JobConfig) checkPrimaryKeyIndices() error {
+	columns := job.syncColumns()
+
+	keySets := [][]string{job.PrimaryKeys}
+	for _, target := range job.Targets {
+		if len(target.PrimaryKeys) > 0 {
+			keySets = append(keySets, target.PrimaryKeys)
+		}
+	}
+
+	for _, primaryKeys := range keySets {
+		primaryKeyIndices := job.getPrimaryKeyIndices(primaryKeys)
+
+		if len(primaryKeyIndices) != len(primaryKeys) {
+			return fmt.Errorf("primary keys %v are not all in columns %v", primaryKeys, columns)
+		}
+
+		for i, idx := range primaryKeyIndices {
+			if columns[idx] != primaryKeys[i] {
+				return fmt.Errorf(
+					"primary key '%s' has index %d, which is column '%s'",
+					primaryKeys[i], idx, columns[idx],
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// We are not allowed to have a slice as a map key, so we use a struct instead. Unlike joining the
+// values into a string, this can't make two distinct keys collide (e.g. "a|b" + "c" vs "a" + "b|c")
 // For now, we limit to a maximum of 3 primary key columns
 type primaryKeyTuple struct{ First, Second, Third any }
 
-func (key primaryKeyTuple) whereClause(primaryKeys []string, primaryKeyIndices []int) sq.Eq {
+// whereClause matches the row with the key. The key's values are in the same order as the primary
+// keys (see keyOf), regardless of where the primary keys are in the columns
+func (key primaryKeyTuple) whereClause(primaryKeys []string) sq.Eq {
 	where := sq.Eq{}
 
-	for i, idx := range primaryKeyIndices {
-		columnName := primaryKeys[idx]
-
+	for i, columnName := range primaryKeys {
 		switch i {
 		case 0:
 			where[columnName] = key.First
@@ -312,3 +1905,45 @@ func (key primaryKeyTuple) whereClause(primaryKeys []string, primaryKeyIndices [
 
 	return where
 }
+
+// rowKey is a row's primary key, with the names of its columns, for logs and error messages (see
+// String)
+type rowKey struct {
+	columns []string
+	values  []any
+}
+
+// rowKeyOf returns the row's primary key
+func (t table) rowKeyOf(row []any) rowKey {
+	return rowKey{columns: t.primaryKeys, values: t.keyValues(row)}
+}
+
+// String renders the key as e.g. `id=1, email="a@b.com"`. Text values are quoted (and escaped),
+// so a value that contains the separator (or an "=") can't be mistaken for another column
+func (key rowKey) String() string {
+	parts := make([]string, len(key.values))
+	for i, val := range key.values {
+		var rendered string
+		switch v := val.(type) {
+		case nil:
+			rendered = "NULL"
+		case string:
+			rendered = strconv.Quote(v)
+		case []byte:
+			rendered = strconv.Quote(string(v))
+		case time.Time:
+			rendered = v.Format(time.RFC3339Nano)
+		default:
+			rendered = fmt.Sprint(v)
+		}
+
+		column := fmt.Sprintf("column%d", i)
+		if i < len(key.columns) {
+			column = key.columns[i]
+		}
+
+		parts[i] = column + "=" + rendered
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans that trace a job's execution with the injected trace.Tracer (see
+// Config.Tracer), as children of the span that is in progress. It also carries the job's context
+// (see ExecJobContext), so the job's queries are cancelled with it. Its zero value creates no spans
+type tracer struct {
+	tracer trace.Tracer
+	ctx    context.Context // Has the span that new spans are children of
+}
+
+// newTracer creates a tracer whose spans are created by the given trace.Tracer (if it isn't nil),
+// as children of the context's span (if it has one)
+func newTracer(t trace.Tracer, ctx context.Context) tracer {
+	return tracer{tracer: t, ctx: ctx}
+}
+
+// context returns the context that has the span in progress
+func (t tracer) context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+
+	return t.ctx
+}
+
+// start starts a child span, and returns a tracer whose spans are children of it
+func (t tracer) start(name string, attrs ...attribute.KeyValue) (tracer, trace.Span) {
+	if t.tracer == nil {
+		return t, trace.SpanFromContext(context.Background()) // A span that does nothing
+	}
+
+	ctx, span := t.tracer.Start(t.context(), name, trace.WithAttributes(attrs...))
+	return tracer{tracer: t.tracer, ctx: ctx}, span
+}
+
+// endSpan ends the span, recording the error (if there is one) as its status
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// tableAttributes returns the span attributes that identify a table
+func tableAttributes(config TableConfig) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("sync.target", config.Label),
+		attribute.String("sync.table", config.Table),
+		attribute.String("sync.driver", config.Driver),
+	}
+}
+
+// endTargetSpan ends a target's span, with the row counts and status of its sync
+func endTargetSpan(span trace.Span, result SyncResult) {
+	span.SetAttributes(
+		attribute.String("sync.status", string(result.Status)),
+		attribute.Int("sync.rows.target", result.TargetRowCount),
+		attribute.Int("sync.rows.inserted", result.NumInserts),
+		attribute.Int("sync.rows.updated", result.NumUpdates),
+		attribute.Int("sync.rows.deleted", result.NumDeletes),
+	)
+
+	endSpan(span, result.Error)
+}
+
+// endJobSpan ends a job's span, with the number of source rows that were read
+func endJobSpan(span trace.Span, result ExecJobResult, err error) {
+	span.SetAttributes(attribute.Int("sync.rows.source", result.SourceRowCount))
+	endSpan(span, err)
+}
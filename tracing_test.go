@@ -0,0 +1,156 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestExecJob_tracing(t *testing.T) {
+	createTable := "CREATE TABLE IF NOT EXISTS users (id INTEGER PRIMARY KEY, name TEXT)"
+
+	sourceDSN := "file:tracing_source.db?mode=memory&cache=shared"
+	source := sqlx.MustConnect("sqlite3", sourceDSN)
+	defer source.Close()
+	source.MustExec(createTable)
+	source.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	emptyDSN := "file:tracing_empty.db?mode=memory&cache=shared"
+	empty := sqlx.MustConnect("sqlite3", emptyDSN)
+	defer empty.Close()
+	empty.MustExec(createTable)
+
+	inSyncDSN := "file:tracing_in_sync.db?mode=memory&cache=shared"
+	inSync := sqlx.MustConnect("sqlite3", inSyncDSN)
+	defer inSync.Close()
+	inSync.MustExec(createTable)
+	inSync.MustExec("INSERT INTO users (id, name) VALUES (1, 'Alice'), (2, 'Bob')")
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	config := Config{
+		Jobs: map[string]JobConfig{
+			"users": {
+				PrimaryKeys: []string{"id"},
+				Columns:     []string{"id", "name"},
+				Source:      TableConfig{Driver: "sqlite3", DSN: sourceDSN, Table: "users"},
+				Targets: []TableConfig{
+					{Label: "empty", Driver: "sqlite3", DSN: emptyDSN, Table: "users"},
+					{Label: "in-sync", Driver: "sqlite3", DSN: inSyncDSN, Table: "users"},
+					{Label: "missing", Driver: "sqlite3", DSN: inSyncDSN, Table: "missing_users"},
+				},
+			},
+		},
+		Tracer: provider.Tracer("sql-table-sync"),
+	}
+
+	result, err := config.ExecJob("users")
+	require.NoError(t, err)
+	require.Len(t, result.Results, 3)
+	require.Error(t, result.Results[2].Error)
+
+	spans := recorder.Ended()
+	byID := map[trace.SpanID]sdktrace.ReadOnlySpan{}
+	for _, span := range spans {
+		byID[span.SpanContext().SpanID()] = span
+	}
+
+	// Identifies each span by its ancestors' names (and their targets' labels)
+	var pathOf func(span sdktrace.ReadOnlySpan) string
+	pathOf = func(span sdktrace.ReadOnlySpan) string {
+		name := span.Name()
+		if target, ok := spanAttribute(span, "sync.target"); ok && name == "syncTarget" {
+			name += "(" + target.AsString() + ")"
+		}
+
+		parent, ok := byID[span.Parent().SpanID()]
+		if !ok {
+			return name
+		}
+		return pathOf(parent) + "/" + name
+	}
+
+	paths := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range spans {
+		paths[pathOf(span)] = span
+	}
+
+	var names []string
+	for path := range paths {
+		names = append(names, path)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"ExecJob",
+		"ExecJob/syncTargets",
+		"ExecJob/syncTargets/fetch",
+		"ExecJob/syncTargets/syncTarget(empty)",
+		"ExecJob/syncTargets/syncTarget(empty)/connect",
+		"ExecJob/syncTargets/syncTarget(empty)/fetch",
+		"ExecJob/syncTargets/syncTarget(empty)/write",
+		"ExecJob/syncTargets/syncTarget(in-sync)",
+		"ExecJob/syncTargets/syncTarget(in-sync)/connect",
+		"ExecJob/syncTargets/syncTarget(in-sync)/fetch",
+		"ExecJob/syncTargets/syncTarget(missing)",
+		"ExecJob/syncTargets/syncTarget(missing)/connect",
+		"ExecJob/syncTargets/syncTarget(missing)/fetch",
+	}, names)
+	require.Len(t, spans, len(names)) // Each span has its own path
+
+	attributeOf := func(path string, key attribute.Key) attribute.Value {
+		value, ok := spanAttribute(paths[path], key)
+		require.True(t, ok, "%s has no %s", path, key)
+		return value
+	}
+
+	// The job's span has its name and how many rows were read from the source
+	assert.Equal(t, "users", attributeOf("ExecJob", "sync.job").AsString())
+	assert.Equal(t, int64(2), attributeOf("ExecJob", "sync.rows.source").AsInt64())
+
+	// Each target's span has its row counts and status
+	emptyPath := "ExecJob/syncTargets/syncTarget(empty)"
+	assert.Equal(t, "users", attributeOf(emptyPath, "sync.table").AsString())
+	assert.Equal(t, int64(2), attributeOf(emptyPath, "sync.rows.inserted").AsInt64())
+	assert.Equal(t, int64(0), attributeOf(emptyPath, "sync.rows.deleted").AsInt64())
+	assert.Equal(t, "synced", attributeOf(emptyPath, "sync.status").AsString())
+	assert.Equal(t, codes.Unset, paths[emptyPath].Status().Code)
+
+	inSyncPath := "ExecJob/syncTargets/syncTarget(in-sync)"
+	assert.Equal(t, int64(0), attributeOf(inSyncPath, "sync.rows.inserted").AsInt64())
+	assert.Equal(t, "in-sync", attributeOf(inSyncPath, "sync.status").AsString())
+
+	// A target that fails has an error status, as does the phase that failed
+	missingPath := "ExecJob/syncTargets/syncTarget(missing)"
+	assert.Equal(t, "error", attributeOf(missingPath, "sync.status").AsString())
+	assert.Equal(t, codes.Error, paths[missingPath].Status().Code)
+	assert.Equal(t, codes.Error, paths[missingPath+"/fetch"].Status().Code)
+	assert.Equal(t, codes.Unset, paths[missingPath+"/connect"].Status().Code)
+
+	// Without a tracer, nothing is traced (and the job still runs)
+	recorder = tracetest.NewSpanRecorder()
+	provider.RegisterSpanProcessor(recorder)
+	config.Tracer = nil
+
+	_, err = config.ExecJob("users")
+	require.NoError(t, err)
+	assert.Empty(t, recorder.Ended())
+}
+
+// spanAttribute returns the value of the span's attribute with the given key
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+
+	return attribute.Value{}, false
+}
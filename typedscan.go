@@ -0,0 +1,271 @@
+package sync
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// scanType is the Go type that a column's values are scanned into by a typed scan (see
+// JobConfig.TypedScan)
+type scanType int
+
+const (
+	scanAny    scanType = iota // Whatever the driver returns (like SliceScan)
+	scanInt                    // int64 (or uint64, if it's too big for an int64)
+	scanFloat                  // float64
+	scanString                 // string
+	scanBytes                  // []byte
+	scanTime                   // time.Time
+)
+
+// scanTypeOf returns the scan type for a column's database type (e.g. "BIGINT", "varchar(255)",
+// or "UNSIGNED INT"). Like sqlite's type affinity, a type that isn't known exactly is matched by
+// what it contains (e.g. any type with "INT" in it is an integer). Decimals are scanned as strings,
+// so they stay exact. Types that can't be matched (including columns without a declared type) are
+// scanned as whatever the driver returns
+func scanTypeOf(databaseType string) scanType {
+	name, _, _ := strings.Cut(strings.ToUpper(strings.TrimSpace(databaseType)), "(")
+	name = strings.TrimSpace(strings.TrimPrefix(name, "UNSIGNED "))
+
+	switch name {
+	case "DATE", "DATETIME", "TIMESTAMP":
+		return scanTime
+	case "BOOL", "BOOLEAN", "YEAR":
+		return scanInt
+	case "DECIMAL", "NUMERIC", "JSON", "ENUM", "SET", "TIME":
+		return scanString
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY":
+		return scanBytes
+	}
+
+	switch {
+	case strings.Contains(name, "INT"):
+		return scanInt
+	case strings.Contains(name, "CHAR"), strings.Contains(name, "CLOB"),
+		strings.Contains(name, "TEXT"):
+		return scanString
+	case strings.Contains(name, "REAL"), strings.Contains(name, "FLOA"),
+		strings.Contains(name, "DOUB"):
+		return scanFloat
+	}
+
+	return scanAny
+}
+
+// typedValue scans a single column's value into its scan type. NULL is always scanned as nil
+type typedValue struct {
+	scanType scanType
+	value    any
+}
+
+// Scan implements sql.Scanner
+func (v *typedValue) Scan(src any) error {
+	if src == nil {
+		v.value = nil
+		return nil
+	}
+
+	var err error
+	switch v.scanType {
+	case scanInt:
+		// A BIGINT UNSIGNED can be too big for an int64, in which case it's kept as a uint64
+		if n, ok := bigUnsigned(src); ok {
+			v.value = n
+		} else {
+			v.value, err = scanAsInt(src)
+		}
+	case scanFloat:
+		v.value, err = scanAsFloat(src)
+	case scanString:
+		v.value = scanAsString(src)
+	case scanBytes:
+		v.value = []byte(scanAsString(src)) // Always a copy, since the driver can reuse its buffer
+	case scanTime:
+		v.value, err = scanAsTime(src)
+	default:
+		// Like SliceScan, bytes are copied, since the driver can reuse its buffer
+		if b, ok := src.([]byte); ok {
+			src = append([]byte(nil), b...)
+		}
+		v.value = src
+	}
+
+	return err
+}
+
+// scanAsInt converts an integer (of any size), a whole float, a bool, or text to an int64. Drivers
+// return sized integers and float32s too, e.g. go-sql-driver/mysql does for queries with
+// placeholders, which use its binary protocol
+func scanAsInt(src any) (int64, error) {
+	switch s := src.(type) {
+	case int64:
+		return s, nil
+	case int:
+		return int64(s), nil
+	case int8:
+		return int64(s), nil
+	case int16:
+		return int64(s), nil
+	case int32:
+		return int64(s), nil
+	case uint8:
+		return int64(s), nil
+	case uint16:
+		return int64(s), nil
+	case uint32:
+		return int64(s), nil
+	case uint:
+		if uint64(s) <= math.MaxInt64 {
+			return int64(s), nil
+		}
+	case uint64:
+		if s <= math.MaxInt64 {
+			return int64(s), nil
+		}
+	case float32:
+		if f := float64(s); f == float64(int64(f)) {
+			return int64(f), nil
+		}
+	case bool:
+		if s {
+			return 1, nil
+		}
+		return 0, nil
+	case float64:
+		if s == float64(int64(s)) {
+			return int64(s), nil
+		}
+	case []byte, string:
+		if n, err := strconv.ParseInt(scanAsString(s), 10, 64); err == nil {
+			return n, nil
+		}
+	}
+
+	return 0, fmt.Errorf("cannot scan %s (%T) as an integer", stringOf(src), src)
+}
+
+// bigUnsigned converts an unsigned integer (or text) that is too big for an int64 to a uint64. It
+// returns false for any other value, including the unsigned integers that fit in an int64, so that
+// every integer that can be an int64 is one
+func bigUnsigned(src any) (uint64, bool) {
+	var n uint64
+	switch s := src.(type) {
+	case uint64:
+		n = s
+	case uint:
+		n = uint64(s)
+	case []byte, string:
+		var err error
+		if n, err = strconv.ParseUint(scanAsString(s), 10, 64); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	return n, n > math.MaxInt64
+}
+
+// scanAsFloat converts a float or an integer (of any size), or text, to a float64. A float32 is
+// converted via its shortest decimal representation, so e.g. a FLOAT's 0.1 is 0.1 (like it is when
+// it's read as text), rather than 0.10000000149011612
+func scanAsFloat(src any) (float64, error) {
+	switch s := src.(type) {
+	case float64:
+		return s, nil
+	case float32:
+		return strconv.ParseFloat(strconv.FormatFloat(float64(s), 'g', -1, 32), 64)
+	case uint64:
+		return float64(s), nil // It can be too big for an int64
+	case uint:
+		return float64(s), nil
+	case int64, int, int8, int16, int32, uint8, uint16, uint32:
+		i, _ := scanAsInt(s) // These always fit in an int64
+		return float64(i), nil
+	case []byte, string:
+		if f, err := strconv.ParseFloat(scanAsString(s), 64); err == nil {
+			return f, nil
+		}
+	}
+
+	return 0, fmt.Errorf("cannot scan %s (%T) as a float", stringOf(src), src)
+}
+
+func scanAsString(src any) string {
+	switch s := src.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case int64:
+		return strconv.FormatInt(s, 10)
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case bool:
+		if s {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return s.Format(sqlTimeFormat)
+	}
+
+	return fmt.Sprint(src)
+}
+
+// timeLayouts are the layouts that times which are read as text are parsed with (in UTC)
+var timeLayouts = []string{sqlTimeFormat, time.RFC3339Nano, time.DateOnly}
+
+func scanAsTime(src any) (time.Time, error) {
+	switch s := src.(type) {
+	case time.Time:
+		return s, nil
+	case []byte, string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, scanAsString(s)); err == nil {
+				return t, nil
+			}
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("cannot scan %s (%T) as a time", stringOf(src), src)
+}
+
+// typedScanner returns a function that scans the query's current row with each column's scan type,
+// which is introspected from the query's column types once, instead of for each row
+func (t table) typedScanner(rows *sqlx.Rows) (func() ([]any, error), error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	scanTypes := make([]scanType, len(columnTypes))
+	for i, columnType := range columnTypes {
+		scanTypes[i] = scanTypeOf(columnType.DatabaseTypeName())
+	}
+
+	return func() ([]any, error) {
+		values := make([]typedValue, len(scanTypes))
+		dest := make([]any, len(scanTypes))
+		for i, scanType := range scanTypes {
+			values[i].scanType = scanType
+			dest[i] = &values[i]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("table '%s': %w", t.config.Table, err)
+		}
+
+		row := make([]any, len(values))
+		for i, value := range values {
+			row[i] = value.value
+		}
+
+		return row, nil
+	}, nil
+}
@@ -0,0 +1,112 @@
+package sync
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanTypeOf(t *testing.T) {
+	tests := map[string]scanType{
+		"INTEGER":         scanInt,
+		"BIGINT":          scanInt,
+		"UNSIGNED BIGINT": scanInt,
+		"tinyint(1)":      scanInt,
+		"BOOLEAN":         scanInt,
+		"YEAR":            scanInt,
+		"DOUBLE":          scanFloat,
+		"REAL":            scanFloat,
+		"float":           scanFloat,
+		"DECIMAL":         scanString,
+		"VARCHAR":         scanString,
+		"varchar(255)":    scanString,
+		"TEXT":            scanString,
+		"JSON":            scanString,
+		"BLOB":            scanBytes,
+		"VARBINARY":       scanBytes,
+		"DATETIME":        scanTime,
+		"timestamp":       scanTime,
+		"DATE":            scanTime,
+		"":                scanAny,
+		"GEOMETRY":        scanAny,
+	}
+
+	for databaseType, expected := range tests {
+		assert.Equal(t, expected, scanTypeOf(databaseType), databaseType)
+	}
+}
+
+func TestTypedValue_Scan(t *testing.T) {
+	scan := func(scanType scanType, src any) (any, error) {
+		v := typedValue{scanType: scanType}
+		err := v.Scan(src)
+		return v.value, err
+	}
+
+	tests := []struct {
+		scanType scanType
+		src      any
+		expected any
+	}{
+		{scanInt, int64(42), int64(42)},
+		{scanInt, []byte("42"), int64(42)},
+		{scanInt, true, int64(1)},
+		{scanInt, float64(3), int64(3)},
+
+		// go-sql-driver/mysql returns sized types for queries with placeholders
+		{scanInt, int8(-4), int64(-4)},
+		{scanInt, int32(5), int64(5)},
+		{scanInt, uint16(6), int64(6)},
+		{scanInt, uint32(7), int64(7)},
+		{scanInt, uint64(8), int64(8)},
+		{scanInt, uint64(math.MaxInt64), int64(math.MaxInt64)},
+		{scanInt, float32(9), int64(9)},
+
+		// An unsigned integer that doesn't fit in an int64 is kept as a uint64, rather than wrapped
+		// around
+		{scanInt, uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{scanInt, uint64(math.MaxInt64) + 1, uint64(math.MaxInt64) + 1},
+		{scanInt, []byte("18446744073709551615"), uint64(math.MaxUint64)},
+
+		{scanFloat, float32(0.1), 0.1}, // Not 0.10000000149011612
+		{scanFloat, float32(1.5), 1.5},
+		{scanFloat, int32(10), float64(10)},
+		{scanFloat, uint64(math.MaxUint64), float64(math.MaxUint64)},
+
+		{scanFloat, []byte("1.5"), 1.5},
+		{scanFloat, int64(2), float64(2)},
+		{scanString, []byte("Alice"), "Alice"},
+		{scanString, int64(42), "42"},
+		{scanBytes, "abc", []byte("abc")},
+		{
+			scanTime,
+			[]byte("2024-01-02 03:04:05"),
+			time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		{scanTime, "2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{scanAny, []byte("raw"), []byte("raw")},
+		{scanInt, nil, nil},
+	}
+
+	for _, test := range tests {
+		value, err := scan(test.scanType, test.src)
+		require.NoError(t, err, test.src)
+		assert.Equal(t, test.expected, value, test.src)
+	}
+
+	// Values that can't be converted are errors, rather than silently scanned as another type
+	_, err := scan(scanInt, []byte("forty-two"))
+	assert.ErrorContains(t, err, "cannot scan forty-two ([]uint8) as an integer")
+
+	_, err = scan(scanInt, 1.5)
+	assert.ErrorContains(t, err, "as an integer")
+
+	_, err = scan(scanInt, float32(1.5))
+	assert.ErrorContains(t, err, "as an integer")
+
+	_, err = scan(scanTime, "yesterday")
+	assert.ErrorContains(t, err, "cannot scan yesterday (string) as a time")
+}